@@ -2,49 +2,95 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/bernardinorafael/internal/_shared/envconf"
 	"github.com/bernardinorafael/internal/_shared/loggerconf"
 	"github.com/bernardinorafael/internal/infra/database/pg"
 	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/infra/oidc"
+	"github.com/bernardinorafael/internal/infra/token"
 	"github.com/bernardinorafael/internal/mailer"
 	"github.com/bernardinorafael/internal/modules/account"
+	"github.com/bernardinorafael/internal/modules/account/alert"
+	"github.com/bernardinorafael/internal/modules/account/attempt"
+	"github.com/bernardinorafael/internal/modules/account/history"
+	"github.com/bernardinorafael/internal/modules/account/magiclink"
+	"github.com/bernardinorafael/internal/modules/account/mfa"
+	"github.com/bernardinorafael/internal/modules/account/onetime"
+	"github.com/bernardinorafael/internal/modules/account/pat"
 	"github.com/bernardinorafael/internal/modules/account/session"
+	"github.com/bernardinorafael/internal/modules/audit"
 	"github.com/bernardinorafael/internal/modules/email"
+	"github.com/bernardinorafael/internal/modules/oauth"
+	"github.com/bernardinorafael/internal/modules/oauth/authcode"
+	"github.com/bernardinorafael/internal/modules/oauth/client"
 	"github.com/bernardinorafael/internal/modules/org"
 	"github.com/bernardinorafael/internal/modules/permission"
+	"github.com/bernardinorafael/internal/modules/phone"
 	"github.com/bernardinorafael/internal/modules/role"
+	"github.com/bernardinorafael/internal/modules/serviceaccount"
+	"github.com/bernardinorafael/internal/modules/sso"
+	"github.com/bernardinorafael/internal/modules/sso/connection"
+	"github.com/bernardinorafael/internal/modules/sso/identity"
+	"github.com/bernardinorafael/internal/modules/sso/loginstate"
 	"github.com/bernardinorafael/internal/modules/team"
 	"github.com/bernardinorafael/internal/modules/user"
 	userrepo "github.com/bernardinorafael/internal/modules/user/repository"
 	usersvc "github.com/bernardinorafael/internal/modules/user/services"
+	"github.com/bernardinorafael/internal/sms"
 	"github.com/bernardinorafael/internal/uploader"
+	"github.com/bernardinorafael/pkg/crypto"
 	"github.com/bernardinorafael/pkg/logger"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
 )
 
+// How long a session check is trusted before hitting the database again
+const sessionCacheTTL = time.Second * 30
+
 func main() {
 	ctx := context.Background()
 
+	env, err := envconf.New()
+	if err != nil {
+		panic(err)
+	}
+
+	withIP, err := middleware.NewWithIP(env.TrustedProxies)
+	if err != nil {
+		panic(err)
+	}
+
+	// Browsers send the session cookies along, so only the apps we serve may read the responses
+	allowedOrigins := env.AllowedOrigins
+	if len(allowedOrigins) == 0 {
+		allowedOrigins = []string{env.FrontEndURL}
+	}
+	for _, origin := range allowedOrigins {
+		if strings.Contains(origin, "*") {
+			panic(fmt.Sprintf("allowed origin %q must not contain wildcards", origin))
+		}
+	}
+
 	r := chi.NewRouter()
 	r.Use(middleware.WithRecoverPanic)
-	r.Use(middleware.WithIP)
+	r.Use(withIP)
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
+		AllowedOrigins:   allowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Auth-Mode"},
+		ExposedHeaders:   []string{"X-CSRF-Token"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
+	// Registered after CORS, so preflight requests are answered before it
+	r.Use(middleware.WithCSRF)
 
-	env, err := envconf.New()
-	if err != nil {
-		panic(err)
-	}
 	// NOTE: To turn off logging, set env.Debug to false
 	// NOTE: Passing empty string as name to avoid logging the name of the service
 	log := loggerconf.New("", env.Debug)
@@ -57,6 +103,33 @@ func main() {
 	defer db.Close()
 	log.Info(ctx, "Database connected")
 
+	// HS256 refresh tokens signed with the legacy secret are accepted until the cutoff
+	var legacyUntil time.Time
+	if env.JWTSecret != "" {
+		legacyUntil, err = time.Parse(time.RFC3339, env.JWTSecretUntil)
+		if err != nil {
+			log.Errorw(ctx, "invalid legacy jwt secret cutoff", logger.Err(err))
+			panic(err)
+		}
+	}
+	keys, err := token.LoadKeySet(env.JWTKeysDir, env.JWTSigningKeyID, env.JWTSecret, legacyUntil)
+	if err != nil {
+		log.Errorw(ctx, "failed to load token keys", logger.Err(err))
+		panic(err)
+	}
+
+	crypto.SetPasswordParams(crypto.PasswordParams{
+		Memory:      env.PasswordHashMemory,
+		Iterations:  env.PasswordHashIterations,
+		Parallelism: env.PasswordHashParallelism,
+	})
+
+	mfaCipher, err := crypto.NewCipher(env.MFAEncryptionKey)
+	if err != nil {
+		log.Errorw(ctx, "failed to create mfa cipher", logger.Err(err))
+		panic(err)
+	}
+
 	uploader := uploader.NewUploader(ctx, log)
 
 	mailer := mailer.New(ctx, log, mailer.Config{
@@ -66,33 +139,119 @@ func main() {
 		OperationTimeout: time.Second * 10,
 	})
 
+	smsSender, err := sms.New(log, sms.Config{
+		Driver:           env.SMSDriver,
+		FilePath:         env.SMSFilePath,
+		TwilioAccountSID: env.TwilioAccountSID,
+		TwilioAuthToken:  env.TwilioAuthToken,
+		TwilioFrom:       env.TwilioFrom,
+	})
+	if err != nil {
+		log.Errorw(ctx, "failed to create sms sender", logger.Err(err))
+		panic(err)
+	}
+
 	// Repositories
 	permissionRepo := permission.NewRepository(db.GetDB())
 	userRepo := userrepo.New(db.GetDB())
 	emailRepo := email.NewRepository(db.GetDB())
 	roleRepo := role.NewRepository(db.GetDB())
 	teamRepo := team.NewRepository(db.GetDB())
-	accRepo := account.NewRepository(db.GetDB())
+	accRepo := account.NewRepository(db.GetDB(), userRepo)
 	orgRepo := org.NewRepo(db.GetDB())
-	sessionRepo := session.NewRepo(db.GetDB())
+	sessionRepo := session.NewCachedRepo(session.NewRepo(db.GetDB()), sessionCacheTTL)
+	activationRepo := onetime.NewRepo(db.GetDB(), onetime.Activation)
+	recoveryRepo := onetime.NewRepo(db.GetDB(), onetime.PasswordReset)
+	mfaRepo := mfa.NewRepo(db.GetDB(), mfaCipher)
+	historyRepo := history.NewRepo(db.GetDB())
+	patRepo := pat.NewRepo(db.GetDB())
+	magicLinkRepo := magiclink.NewRepo(db.GetDB())
+	alertRepo := alert.NewRepo(db.GetDB())
+	attemptRepo := attempt.NewRepo(db.GetDB())
+	oauthClientRepo := client.NewRepo(db.GetDB())
+	authCodeRepo := authcode.NewRepo(db.GetDB())
+	ssoConnectionRepo := connection.NewRepo(db.GetDB())
+	identityRepo := identity.NewRepo(db.GetDB())
+	loginStateRepo := loginstate.NewRepo(db.GetDB())
+	serviceAccountRepo := serviceaccount.NewRepo(db.GetDB())
+	auditRepo := audit.NewRepo(db.GetDB())
+	phoneRepo := phone.NewRepository(db.GetDB())
 
 	// Services
 	emailService := email.NewService(log, emailRepo, mailer)
+	phoneService := phone.NewService(log, phoneRepo, smsSender)
 	permissionService := permission.NewService(log, permissionRepo)
-	accService := account.NewService(ctx, log, accRepo, userRepo, sessionRepo, mailer, env.JWTSecret)
+	accService := account.NewService(
+		ctx,
+		log,
+		accRepo,
+		userRepo,
+		sessionRepo,
+		activationRepo,
+		recoveryRepo,
+		attemptRepo,
+		mfaRepo,
+		historyRepo,
+		patRepo,
+		magicLinkRepo,
+		alertRepo,
+		permissionRepo,
+		auditRepo,
+		phoneRepo,
+		mailer,
+		smsSender,
+		keys,
+		env.FrontEndURL,
+		env.MaxSessionsPerUser,
+	)
 	roleService := role.NewService(log, roleRepo)
 	teamService := team.NewService(log, teamRepo)
-	userService := usersvc.New(log, userRepo, emailService, mailer, uploader)
+	userService := usersvc.New(log, userRepo, attemptRepo, sessionRepo, permissionRepo, emailService, mailer, uploader)
 	orgService := org.NewService(log, orgRepo)
+	serviceAccountService := serviceaccount.NewService(log, serviceAccountRepo, orgService, roleRepo, keys)
+	oauthService := oauth.NewService(
+		log,
+		oauthClientRepo,
+		authCodeRepo,
+		userRepo,
+		accService,
+		serviceAccountService,
+		permissionRepo,
+		keys,
+		env.IssuerURL,
+		env.FrontEndURL,
+	)
+	ssoService := sso.NewService(
+		log,
+		ssoConnectionRepo,
+		identityRepo,
+		loginStateRepo,
+		orgService,
+		accRepo,
+		emailRepo,
+		accService,
+		userService,
+		teamService,
+		oidc.NewClient(nil, env.SSOAllowPrivateIssuers),
+		env.IssuerURL,
+		env.FrontEndURL,
+	)
+
+	// Middlewares
+	auth := middleware.NewWithAuth(log, keys, sessionRepo, accService, serviceAccountRepo, accService)
 
 	// Controllers
-	email.NewController(ctx, log, emailService, env.JWTSecret).RegisterRoute(r)
-	team.NewController(ctx, log, teamService, env.JWTSecret).RegisterRoute(r)
-	user.NewController(ctx, log, userService, env.JWTSecret).RegisterRoute(r)
-	role.NewController(ctx, log, roleService, env.JWTSecret).RegisterRoute(r)
-	account.NewController(ctx, log, accService, env.JWTSecret).RegisterRoute(r)
-	org.NewController(ctx, log, orgService, env.JWTSecret).RegisterRoute(r)
-	permission.NewController(ctx, log, permissionService, env.JWTSecret).RegisterRoute(r)
+	email.NewController(ctx, log, emailService, auth).RegisterRoute(r)
+	phone.NewController(ctx, log, phoneService, auth).RegisterRoute(r)
+	team.NewController(ctx, log, teamService, auth).RegisterRoute(r)
+	user.NewController(ctx, log, userService, auth).RegisterRoute(r)
+	role.NewController(ctx, log, roleService, auth).RegisterRoute(r)
+	account.NewController(ctx, log, accService, auth).RegisterRoute(r)
+	org.NewController(ctx, log, orgService, auth).RegisterRoute(r)
+	permission.NewController(ctx, log, permissionService, auth).RegisterRoute(r)
+	oauth.NewController(ctx, log, oauthService, auth).RegisterRoute(r)
+	sso.NewController(ctx, log, ssoService, auth).RegisterRoute(r)
+	serviceaccount.NewController(ctx, log, serviceAccountService, auth).RegisterRoute(r)
 
 	log.Info(ctx, "Server started")
 	err = http.ListenAndServe(":"+env.Port, r)
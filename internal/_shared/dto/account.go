@@ -4,17 +4,82 @@ import (
 	"time"
 )
 
+// AccountResponse leaves out the refresh token in cookie mode, it is only sent as a cookie
 type AccountResponse struct {
 	SessionID           string `json:"session_id"`
 	AccessToken         string `json:"access_token"`
-	RefreshToken        string `json:"refresh_token"`
+	RefreshToken        string `json:"refresh_token,omitempty"`
 	AccessTokenExpires  int64  `json:"access_token_expires"`
 	RefreshTokenExpires int64  `json:"refresh_token_expires"`
+	// PasswordExpired asks the client to have the user change the password
+	PasswordExpired bool `json:"password_expired"`
 }
 
-type RenewAccessToken struct {
+type Login struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	EvictOldest bool   `json:"evict_oldest"`
+	UserAgent   string `json:"-"`
+	IP          string `json:"-"`
+}
+
+type LoginMFA struct {
+	MFAToken    string `json:"mfa_token"`
+	Code        string `json:"code"`
+	EvictOldest bool   `json:"evict_oldest"`
+	UserAgent   string `json:"-"`
+	IP          string `json:"-"`
+}
+
+type LoginMagicLink struct {
+	Token       string `json:"token"`
+	EvictOldest bool   `json:"evict_oldest"`
+	UserAgent   string `json:"-"`
+	IP          string `json:"-"`
+}
+
+// Reauthenticate takes either the password or, for accounts with two-factor authentication,
+// a TOTP or recovery code
+type Reauthenticate struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+	IP       string `json:"-"`
+}
+
+// ReauthenticationResponse holds an access token carrying the new auth time, the refresh token is kept
+type ReauthenticationResponse struct {
+	SessionID          string `json:"session_id"`
 	AccessToken        string `json:"access_token"`
 	AccessTokenExpires int64  `json:"access_token_expires"`
+	AuthTime           int64  `json:"auth_time"`
+}
+
+type ForgotPassword struct {
+	Username string `json:"username"`
+	// Channel is how the reset link is sent, email when empty or sms to the verified phone
+	// when a real sms driver is configured
+	Channel string `json:"channel"`
+}
+
+type MFAChallenge struct {
+	MFARequired     bool   `json:"mfa_required"`
+	MFAToken        string `json:"mfa_token"`
+	MFATokenExpires int64  `json:"mfa_token_expires"`
+}
+
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RenewAccessToken struct {
+	SessionID           string `json:"session_id"`
+	AccessToken         string `json:"access_token"`
+	RefreshToken        string `json:"refresh_token,omitempty"`
+	AccessTokenExpires  int64  `json:"access_token_expires"`
+	RefreshTokenExpires int64  `json:"refresh_token_expires"`
+	// Scope is what the user granted the OAuth client the session was opened for
+	Scope string `json:"scope,omitempty"`
 }
 
 type CreateAccount struct {
@@ -26,10 +91,43 @@ type CreateAccount struct {
 }
 
 type SessionResponse struct {
-	ID      string    `json:"id"`
-	Agent   string    `json:"agent"`
-	IP      string    `json:"ip"`
-	Revoked bool      `json:"revoked"`
-	Expired bool      `json:"expired"`
-	Created time.Time `json:"created"`
+	ID             string `json:"id"`
+	Agent          string `json:"agent"`
+	Browser        string `json:"browser"`
+	BrowserVersion string `json:"browser_version"`
+	OS             string `json:"os"`
+	DeviceType     string `json:"device_type"`
+	IsBot          bool   `json:"is_bot"`
+	IP             string `json:"ip"`
+	Revoked        bool   `json:"revoked"`
+	Expired        bool   `json:"expired"`
+	// Current marks the session the request was made with
+	Current    bool      `json:"current"`
+	LastActive time.Time `json:"last_active"`
+	Created    time.Time `json:"created"`
+	// Impersonated marks the sessions opened by someone else acting as the user
+	Impersonated   bool    `json:"impersonated"`
+	ImpersonatorID *string `json:"impersonator_id,omitempty"`
+}
+
+type CreatePersonalToken struct {
+	Name string `json:"name"`
+	// Permissions restricts the token to these permission keys, all of the owner's when omitted
+	Permissions []string   `json:"permissions"`
+	Expires     *time.Time `json:"expires"`
+}
+
+type Impersonate struct {
+	UserID string `json:"user_id"`
+	// Reason is kept on the audit trail, such as the support ticket being worked on
+	Reason    string `json:"reason"`
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
+}
+
+// ImpersonationResponse has no refresh token, impersonating ends when the access token expires
+type ImpersonationResponse struct {
+	SessionID          string `json:"session_id"`
+	AccessToken        string `json:"access_token"`
+	AccessTokenExpires int64  `json:"access_token_expires"`
 }
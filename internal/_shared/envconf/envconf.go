@@ -11,6 +11,9 @@ type Env struct {
 	Environment string `mapstructure:"ENVIRONMENT"`
 	Name        string `mapstructure:"NAME"`
 	Debug       bool   `mapstructure:"DEBUG"`
+	// TrustedProxies are the IPs or CIDRs of the reverse proxies allowed to
+	// forward the client IP, comma separated
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
 
 	DSN    string `mapstructure:"DB_POSTGRES_DSN"`
 	DBName string `mapstructure:"DB_NAME"`
@@ -18,9 +21,41 @@ type Env struct {
 
 	ResendAPIKey string `mapstructure:"RESEND_API_KEY"`
 
-	JWTSecret           string        `mapstructure:"JWT_SECRET"`
+	// SMSDriver is log, file or twilio, text messages are disabled when empty
+	SMSDriver        string `mapstructure:"SMS_DRIVER"`
+	SMSFilePath      string `mapstructure:"SMS_FILE_PATH"`
+	TwilioAccountSID string `mapstructure:"TWILIO_ACCOUNT_SID"`
+	TwilioAuthToken  string `mapstructure:"TWILIO_AUTH_TOKEN"`
+	TwilioFrom       string `mapstructure:"TWILIO_FROM"`
+
+	FrontEndURL string `mapstructure:"FRONT_END_URL"`
+	// AllowedOrigins are the origins browsers may send credentialed requests from,
+	// comma separated. Only FrontEndURL when empty
+	AllowedOrigins []string `mapstructure:"ALLOWED_ORIGINS"`
+	// IssuerURL is the public URL of this API, used as issuer of the OpenID Connect tokens
+	IssuerURL string `mapstructure:"ISSUER_URL"`
+	// SSOAllowPrivateIssuers lets SSO connections use http issuers on private addresses, for local development
+	SSOAllowPrivateIssuers bool `mapstructure:"SSO_ALLOW_PRIVATE_ISSUERS"`
+
+	// JWTSecret only verifies HS256 refresh tokens issued before the signing keys
+	JWTSecret string `mapstructure:"JWT_SECRET"`
+	// JWTSecretUntil is the RFC 3339 date after which JWTSecret is dropped
+	JWTSecretUntil      string        `mapstructure:"JWT_SECRET_UNTIL"`
+	JWTKeysDir          string        `mapstructure:"JWT_KEYS_DIR"`
+	JWTSigningKeyID     string        `mapstructure:"JWT_SIGNING_KEY_ID"`
 	JwtExpiresIn        int           `mapstructure:"JWT_EXPIRES"`
 	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+
+	// MaxSessionsPerUser caps the concurrent sessions of users whose organization doesn't set it,
+	// 5 when unset, 0 or less disables the cap
+	MaxSessionsPerUser int `mapstructure:"MAX_SESSIONS_PER_USER"`
+
+	// MFAEncryptionKey encrypts the TOTP secrets at rest, base64 encoded 32 bytes
+	MFAEncryptionKey string `mapstructure:"MFA_ENCRYPTION_KEY"`
+
+	PasswordHashMemory      uint32 `mapstructure:"PASSWORD_HASH_MEMORY"`
+	PasswordHashIterations  uint32 `mapstructure:"PASSWORD_HASH_ITERATIONS"`
+	PasswordHashParallelism uint8  `mapstructure:"PASSWORD_HASH_PARALLELISM"`
 }
 
 func New() (*Env, error) {
@@ -31,6 +66,8 @@ func New() (*Env, error) {
 	viper.AddConfigPath(".")
 	viper.AutomaticEnv()
 
+	viper.SetDefault("MAX_SESSIONS_PER_USER", 5)
+
 	err := viper.ReadInConfig()
 	if err != nil {
 		return nil, err
@@ -33,6 +33,11 @@ const (
 	PhoneNotVerified        ErrorCode = "PHONE_NOT_VERIFIED"
 	MaxSessionsReached      ErrorCode = "MAX_SESSIONS_REACHED"
 	Expired                 ErrorCode = "EXPIRED"
+	TooManyRequests         ErrorCode = "TOO_MANY_REQUESTS"
+	MissingPermission       ErrorCode = "MISSING_PERMISSION"
+	ImpersonationForbidden  ErrorCode = "IMPERSONATION_FORBIDDEN"
+	InvalidCSRFToken        ErrorCode = "INVALID_CSRF_TOKEN"
+	ReauthenticationNeeded  ErrorCode = "REAUTHENTICATION_NEEDED"
 )
 
 type ApplicationError struct {
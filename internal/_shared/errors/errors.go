@@ -2,7 +2,10 @@ package errors
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func NewHttpError(w http.ResponseWriter, err error) {
@@ -53,6 +56,15 @@ func NewConflictError(msg string, code ErrorCode, err error, fields []Field) App
 	return newApplicationError(httpCode, code, msg, err, fields)
 }
 
+// NewTooManyRequestsError tells the client how long to wait through the retry_after field, in seconds
+func NewTooManyRequestsError(msg string, retryAfter time.Duration, err error) ApplicationError {
+	httpCode := http.StatusTooManyRequests
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return newApplicationError(httpCode, TooManyRequests, msg, err, []Field{
+		{Field: "retry_after", Msg: strconv.Itoa(seconds)},
+	})
+}
+
 func NewUnprocessableEntityError(msg string, err error) ApplicationError {
 	httpCode := http.StatusUnprocessableEntity
 	return newApplicationError(httpCode, ValidationField, msg, err, nil)
@@ -0,0 +1,15 @@
+CREATE TABLE
+	IF NOT EXISTS "account_activations" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"account_id" VARCHAR(255) NOT NULL,
+		"user_id" VARCHAR(255) NOT NULL,
+		"token_hash" VARCHAR(255) UNIQUE NOT NULL,
+		"is_consumed" BOOLEAN NOT NULL DEFAULT FALSE,
+		"is_valid" BOOLEAN NOT NULL DEFAULT TRUE,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		"expires" TIMESTAMPTZ NOT NULL,
+		CONSTRAINT "activations_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE,
+		CONSTRAINT "activations_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+	);
+
+CREATE INDEX idx_account_activations_account_id ON "account_activations" ("account_id");
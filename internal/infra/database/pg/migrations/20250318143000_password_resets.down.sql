@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_password_resets_account_id;
+
+DROP TABLE IF EXISTS "password_resets";
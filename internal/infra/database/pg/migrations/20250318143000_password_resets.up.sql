@@ -0,0 +1,15 @@
+CREATE TABLE
+	IF NOT EXISTS "password_resets" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"account_id" VARCHAR(255) NOT NULL,
+		"user_id" VARCHAR(255) NOT NULL,
+		"token_hash" VARCHAR(255) UNIQUE NOT NULL,
+		"is_consumed" BOOLEAN NOT NULL DEFAULT FALSE,
+		"is_valid" BOOLEAN NOT NULL DEFAULT TRUE,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		"expires" TIMESTAMPTZ NOT NULL,
+		CONSTRAINT "password_resets_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE,
+		CONSTRAINT "password_resets_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+	);
+
+CREATE INDEX idx_password_resets_account_id ON "password_resets" ("account_id");
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_sessions_family_id;
+
+ALTER TABLE "sessions"
+DROP COLUMN IF EXISTS "family_id",
+DROP COLUMN IF EXISTS "rotated";
@@ -0,0 +1,10 @@
+ALTER TABLE "sessions"
+ADD COLUMN "family_id" VARCHAR(255),
+ADD COLUMN "rotated" BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Every existing session starts its own family
+UPDATE "sessions" SET "family_id" = "id" WHERE "family_id" IS NULL;
+
+ALTER TABLE "sessions" ALTER COLUMN "family_id" SET NOT NULL;
+
+CREATE INDEX idx_sessions_family_id ON "sessions" ("family_id");
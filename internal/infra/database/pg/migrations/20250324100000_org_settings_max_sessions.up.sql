@@ -0,0 +1,2 @@
+ALTER TABLE "organization_settings"
+ADD COLUMN "max_sessions_per_user" INTEGER;
@@ -0,0 +1,23 @@
+CREATE TABLE
+	IF NOT EXISTS "account_mfa" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"account_id" VARCHAR(255) UNIQUE NOT NULL,
+		"secret" VARCHAR(255) NOT NULL,
+		"is_enabled" BOOLEAN NOT NULL DEFAULT FALSE,
+		"last_used_step" BIGINT NOT NULL DEFAULT 0,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		"updated" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		CONSTRAINT "mfa_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE
+	);
+
+CREATE TABLE
+	IF NOT EXISTS "mfa_recovery_codes" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"account_id" VARCHAR(255) NOT NULL,
+		"code_hash" VARCHAR(255) NOT NULL,
+		"is_used" BOOLEAN NOT NULL DEFAULT FALSE,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		"updated" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		UNIQUE ("account_id", "code_hash"),
+		CONSTRAINT "recovery_codes_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE
+	);
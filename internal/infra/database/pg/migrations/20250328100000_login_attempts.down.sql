@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_login_attempts_ip_created;
+
+DROP INDEX IF EXISTS idx_login_attempts_username_created;
+
+DROP TABLE IF EXISTS "login_attempts";
+
+ALTER TABLE "users"
+DROP COLUMN IF EXISTS "locked_until";
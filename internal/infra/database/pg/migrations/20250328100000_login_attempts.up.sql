@@ -0,0 +1,14 @@
+ALTER TABLE "users"
+ADD COLUMN "locked_until" TIMESTAMPTZ;
+
+CREATE TABLE
+	IF NOT EXISTS "login_attempts" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"username" VARCHAR(255) NOT NULL,
+		"ip" VARCHAR(255) NOT NULL,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+	);
+
+CREATE INDEX idx_login_attempts_username_created ON "login_attempts" ("username", "created");
+
+CREATE INDEX idx_login_attempts_ip_created ON "login_attempts" ("ip", "created");
@@ -0,0 +1,12 @@
+ALTER TABLE "accounts"
+DROP COLUMN IF EXISTS "password_changed";
+
+ALTER TABLE "organization_settings"
+DROP COLUMN IF EXISTS "password_min_length",
+DROP COLUMN IF EXISTS "password_require_uppercase",
+DROP COLUMN IF EXISTS "password_require_lowercase",
+DROP COLUMN IF EXISTS "password_require_number",
+DROP COLUMN IF EXISTS "password_require_special",
+DROP COLUMN IF EXISTS "password_max_age_days",
+DROP COLUMN IF EXISTS "password_history_depth",
+DROP COLUMN IF EXISTS "password_banned_words";
@@ -0,0 +1,12 @@
+ALTER TABLE "organization_settings"
+ADD COLUMN "password_min_length" INTEGER NOT NULL DEFAULT 6,
+ADD COLUMN "password_require_uppercase" BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN "password_require_lowercase" BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN "password_require_number" BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN "password_require_special" BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN "password_max_age_days" INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN "password_history_depth" INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN "password_banned_words" TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE "accounts"
+ADD COLUMN "password_changed" TIMESTAMPTZ NOT NULL DEFAULT NOW ();
@@ -0,0 +1,6 @@
+ALTER TABLE "organization_settings"
+ALTER COLUMN "password_history_depth" SET DEFAULT 0;
+
+DROP INDEX IF EXISTS idx_password_history_account_id_created;
+
+DROP TABLE IF EXISTS "password_history";
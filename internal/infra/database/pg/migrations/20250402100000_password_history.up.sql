@@ -0,0 +1,15 @@
+CREATE TABLE
+	IF NOT EXISTS "password_history" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"account_id" VARCHAR(255) NOT NULL,
+		"password_hash" VARCHAR(255) NOT NULL,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		CONSTRAINT "password_history_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE
+	);
+
+CREATE INDEX idx_password_history_account_id_created ON "password_history" ("account_id", "created");
+
+-- New organizations keep the last 5 passwords unless they configure otherwise
+-- Existing ones keep their depth, 0 may have been set on purpose to turn the check off
+ALTER TABLE "organization_settings"
+ALTER COLUMN "password_history_depth" SET DEFAULT 5;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_oauth_authorization_codes_expires;
+
+DROP TABLE IF EXISTS "oauth_authorization_codes";
+
+DROP TABLE IF EXISTS "oauth_clients";
@@ -0,0 +1,31 @@
+CREATE TABLE
+	IF NOT EXISTS "oauth_clients" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"name" VARCHAR(255) NOT NULL,
+		-- Public clients (SPAs, native apps) have no secret and rely on PKCE alone
+		"secret_hash" VARCHAR(255),
+		"redirect_uris" TEXT[] NOT NULL DEFAULT '{}',
+		"is_confidential" BOOLEAN NOT NULL DEFAULT FALSE,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		"updated" TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+	);
+
+CREATE TABLE
+	IF NOT EXISTS "oauth_authorization_codes" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"code_hash" VARCHAR(255) NOT NULL UNIQUE,
+		"client_id" VARCHAR(255) NOT NULL,
+		"account_id" VARCHAR(255) NOT NULL,
+		"user_id" VARCHAR(255) NOT NULL,
+		"redirect_uri" TEXT NOT NULL,
+		"scope" TEXT NOT NULL,
+		"nonce" TEXT NOT NULL DEFAULT '',
+		"code_challenge" VARCHAR(255) NOT NULL,
+		"is_used" BOOLEAN NOT NULL DEFAULT FALSE,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		"expires" TIMESTAMPTZ NOT NULL,
+		CONSTRAINT "oauth_authorization_codes_client_id_fkey" FOREIGN KEY ("client_id") REFERENCES "oauth_clients" ("id") ON DELETE CASCADE,
+		CONSTRAINT "oauth_authorization_codes_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE
+	);
+
+CREATE INDEX idx_oauth_authorization_codes_expires ON "oauth_authorization_codes" ("expires");
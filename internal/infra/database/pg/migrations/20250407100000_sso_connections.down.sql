@@ -0,0 +1,27 @@
+-- Irreversible once several users have no phone number, such as the ones provisioned by
+-- a provider, as the constraint allows a single empty number. Fails explicitly then,
+-- those users must be given a number or removed first
+DO $$
+BEGIN
+	IF (SELECT COUNT(*) FROM "users" WHERE "phone_number" = '') > 1 THEN
+		RAISE EXCEPTION 'several users have no phone number, users.phone_number can''t be unique again';
+	END IF;
+END
+$$;
+
+DROP INDEX IF EXISTS "users_phone_number_key";
+
+ALTER TABLE "users"
+ADD CONSTRAINT "users_phone_number_key" UNIQUE ("phone_number");
+
+DROP INDEX IF EXISTS idx_sso_login_states_expires;
+
+DROP TABLE IF EXISTS "sso_login_states";
+
+DROP INDEX IF EXISTS idx_linked_identities_user_id;
+
+DROP TABLE IF EXISTS "linked_identities";
+
+DROP INDEX IF EXISTS idx_sso_connections_org_id;
+
+DROP TABLE IF EXISTS "sso_connections";
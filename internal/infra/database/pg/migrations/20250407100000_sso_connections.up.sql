@@ -0,0 +1,65 @@
+CREATE TABLE
+	IF NOT EXISTS "sso_connections" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"org_id" VARCHAR(255) NOT NULL,
+		"name" VARCHAR(255) NOT NULL,
+		"issuer" TEXT NOT NULL,
+		"client_id" VARCHAR(255) NOT NULL,
+		"client_secret" TEXT NOT NULL,
+		"scopes" TEXT[] NOT NULL DEFAULT '{openid,email,profile}',
+		-- Users signing in for the first time are created when enabled
+		"auto_provision" BOOLEAN NOT NULL DEFAULT FALSE,
+		-- Team and role given to provisioned users, both or none
+		"default_team_id" VARCHAR(255),
+		"default_role_id" VARCHAR(255),
+		"is_enabled" BOOLEAN NOT NULL DEFAULT TRUE,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		"updated" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		CONSTRAINT "sso_connections_org_id_fkey" FOREIGN KEY ("org_id") REFERENCES "organizations" ("id") ON DELETE CASCADE,
+		CONSTRAINT "sso_connections_default_team_id_fkey" FOREIGN KEY ("default_team_id") REFERENCES "teams" ("id") ON DELETE SET NULL,
+		CONSTRAINT "sso_connections_default_role_id_fkey" FOREIGN KEY ("default_role_id") REFERENCES "roles" ("id") ON DELETE SET NULL
+	);
+
+CREATE INDEX idx_sso_connections_org_id ON "sso_connections" ("org_id");
+
+CREATE TABLE
+	IF NOT EXISTS "linked_identities" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"user_id" VARCHAR(255) NOT NULL,
+		"connection_id" VARCHAR(255) NOT NULL,
+		"issuer" TEXT NOT NULL,
+		"subject" VARCHAR(255) NOT NULL,
+		"email" VARCHAR(255) NOT NULL DEFAULT '',
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		"last_login" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		UNIQUE ("issuer", "subject"),
+		CONSTRAINT "linked_identities_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE,
+		CONSTRAINT "linked_identities_connection_id_fkey" FOREIGN KEY ("connection_id") REFERENCES "sso_connections" ("id") ON DELETE CASCADE
+	);
+
+CREATE INDEX idx_linked_identities_user_id ON "linked_identities" ("user_id");
+
+CREATE TABLE
+	IF NOT EXISTS "sso_login_states" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"state_hash" VARCHAR(255) NOT NULL UNIQUE,
+		"connection_id" VARCHAR(255) NOT NULL,
+		"nonce" VARCHAR(255) NOT NULL,
+		"code_verifier" VARCHAR(255) NOT NULL,
+		-- Set once the provider calls back, the ticket is exchanged for a session
+		"ticket_hash" VARCHAR(255) UNIQUE,
+		"account_id" VARCHAR(255),
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		"expires" TIMESTAMPTZ NOT NULL,
+		CONSTRAINT "sso_login_states_connection_id_fkey" FOREIGN KEY ("connection_id") REFERENCES "sso_connections" ("id") ON DELETE CASCADE
+	);
+
+CREATE INDEX idx_sso_login_states_expires ON "sso_login_states" ("expires");
+
+-- Users provisioned by a provider may have no phone number, only real numbers must be unique
+ALTER TABLE "users"
+DROP CONSTRAINT IF EXISTS "users_phone_number_key";
+
+CREATE UNIQUE INDEX "users_phone_number_key" ON "users" ("phone_number")
+WHERE
+	"phone_number" <> '';
@@ -0,0 +1,19 @@
+CREATE TABLE
+	IF NOT EXISTS "personal_access_tokens" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"account_id" VARCHAR(255) NOT NULL,
+		"name" VARCHAR(255) NOT NULL,
+		"token_hash" VARCHAR(255) NOT NULL UNIQUE,
+		-- Leading characters of the token, so users can tell their tokens apart
+		"hint" VARCHAR(255) NOT NULL,
+		-- NULL grants every permission of the owner, otherwise only the listed keys
+		"permissions" TEXT[],
+		"revoked" BOOLEAN NOT NULL DEFAULT FALSE,
+		"expires" TIMESTAMPTZ,
+		"last_used" TIMESTAMPTZ,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		"updated" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		CONSTRAINT "personal_access_tokens_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE
+	);
+
+CREATE INDEX idx_personal_access_tokens_account_id ON "personal_access_tokens" ("account_id");
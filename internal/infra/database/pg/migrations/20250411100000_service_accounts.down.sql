@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_service_accounts_org_id;
+
+DROP TABLE IF EXISTS "service_accounts";
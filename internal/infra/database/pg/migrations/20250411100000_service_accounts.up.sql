@@ -0,0 +1,15 @@
+CREATE TABLE
+	IF NOT EXISTS "service_accounts" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"org_id" VARCHAR(255) NOT NULL,
+		"name" VARCHAR(255) NOT NULL,
+		"secret_hash" VARCHAR(255) NOT NULL,
+		"role_id" VARCHAR(255) NOT NULL,
+		"last_used" TIMESTAMPTZ,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		"updated" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		CONSTRAINT "service_accounts_org_id_fkey" FOREIGN KEY ("org_id") REFERENCES "organizations" ("id") ON DELETE CASCADE,
+		CONSTRAINT "service_accounts_role_id_fkey" FOREIGN KEY ("role_id") REFERENCES "roles" ("id") ON DELETE CASCADE
+	);
+
+CREATE INDEX idx_service_accounts_org_id ON "service_accounts" ("org_id");
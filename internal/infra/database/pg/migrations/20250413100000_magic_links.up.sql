@@ -0,0 +1,13 @@
+CREATE TABLE
+	IF NOT EXISTS "magic_links" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"account_id" VARCHAR(255) NOT NULL,
+		"token_hash" VARCHAR(255) UNIQUE NOT NULL,
+		"is_consumed" BOOLEAN NOT NULL DEFAULT FALSE,
+		"is_valid" BOOLEAN NOT NULL DEFAULT TRUE,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		"expires" TIMESTAMPTZ NOT NULL,
+		CONSTRAINT "magic_links_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE
+	);
+
+CREATE INDEX idx_magic_links_account_id ON "magic_links" ("account_id");
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_audit_logs_subject_id_created;
+
+DROP INDEX IF EXISTS idx_audit_logs_actor_id_created;
+
+DROP TABLE IF EXISTS "audit_logs";
+
+ALTER TABLE "sessions"
+DROP COLUMN IF EXISTS "impersonator_id";
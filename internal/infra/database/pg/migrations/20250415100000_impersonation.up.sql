@@ -0,0 +1,22 @@
+-- Sessions opened by support staff acting as the user, NULL for the user's own sessions
+ALTER TABLE "sessions"
+ADD COLUMN "impersonator_id" VARCHAR(255);
+
+CREATE TABLE
+	IF NOT EXISTS "audit_logs" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"action" VARCHAR(255) NOT NULL,
+		"actor_id" VARCHAR(255) NOT NULL,
+		-- user or service, the actor of an impersonated request is the real one
+		"actor_type" VARCHAR(50) NOT NULL,
+		-- User the action was performed on or on behalf of
+		"subject_id" VARCHAR(255),
+		"detail" TEXT NOT NULL DEFAULT '',
+		"ip" VARCHAR(255) NOT NULL DEFAULT '',
+		"agent" VARCHAR(255) NOT NULL DEFAULT '',
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+	);
+
+CREATE INDEX idx_audit_logs_actor_id_created ON "audit_logs" ("actor_id", "created");
+
+CREATE INDEX idx_audit_logs_subject_id_created ON "audit_logs" ("subject_id", "created");
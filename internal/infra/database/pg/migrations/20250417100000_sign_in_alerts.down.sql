@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_sessions_username_created;
+
+DROP INDEX IF EXISTS idx_sign_in_alerts_account_id;
+
+DROP TABLE IF EXISTS "sign_in_alerts";
@@ -0,0 +1,17 @@
+CREATE TABLE
+	IF NOT EXISTS "sign_in_alerts" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"account_id" VARCHAR(255) NOT NULL,
+		-- Family of the session the alert was sent for, revoked when the user denies the sign-in
+		"session_id" VARCHAR(255) NOT NULL,
+		"token_hash" VARCHAR(255) UNIQUE NOT NULL,
+		"is_consumed" BOOLEAN NOT NULL DEFAULT FALSE,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		"expires" TIMESTAMPTZ NOT NULL,
+		CONSTRAINT "sign_in_alerts_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE
+	);
+
+CREATE INDEX idx_sign_in_alerts_account_id ON "sign_in_alerts" ("account_id");
+
+-- Known devices are looked up among the recent sessions of the user on every sign-in
+CREATE INDEX IF NOT EXISTS idx_sessions_username_created ON "sessions" ("username", "created");
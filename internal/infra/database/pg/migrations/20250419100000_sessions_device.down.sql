@@ -0,0 +1,14 @@
+ALTER TABLE "sessions"
+DROP COLUMN IF EXISTS "last_active",
+DROP COLUMN IF EXISTS "is_bot",
+DROP COLUMN IF EXISTS "device_type",
+DROP COLUMN IF EXISTS "os",
+DROP COLUMN IF EXISTS "browser_version",
+DROP COLUMN IF EXISTS "browser";
+
+UPDATE "sessions"
+SET
+	"agent" = LEFT ("agent", 255);
+
+ALTER TABLE "sessions"
+ALTER COLUMN "agent" TYPE VARCHAR(255);
@@ -0,0 +1,21 @@
+-- agent now holds the raw User-Agent header, the fields below are parsed from it
+ALTER TABLE "sessions"
+ALTER COLUMN "agent" TYPE VARCHAR(512),
+ADD COLUMN "browser" VARCHAR(255) NOT NULL DEFAULT '',
+ADD COLUMN "browser_version" VARCHAR(255) NOT NULL DEFAULT '',
+ADD COLUMN "os" VARCHAR(255) NOT NULL DEFAULT '',
+ADD COLUMN "device_type" VARCHAR(50) NOT NULL DEFAULT '',
+ADD COLUMN "is_bot" BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN "last_active" TIMESTAMPTZ NOT NULL DEFAULT NOW ();
+
+-- Sessions created before stored "<browser> em <os>" as the agent
+UPDATE "sessions"
+SET
+	"browser" = split_part ("agent", ' em ', 1),
+	"os" = split_part ("agent", ' em ', 2)
+WHERE
+	"agent" LIKE '% em %';
+
+UPDATE "sessions"
+SET
+	"last_active" = "created";
@@ -0,0 +1,5 @@
+ALTER TABLE "users"
+DROP COLUMN IF EXISTS "banned_at",
+DROP COLUMN IF EXISTS "banned_by",
+DROP COLUMN IF EXISTS "banned_until",
+DROP COLUMN IF EXISTS "ban_reason";
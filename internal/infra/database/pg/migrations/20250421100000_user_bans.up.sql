@@ -0,0 +1,7 @@
+ALTER TABLE "users"
+ADD COLUMN "ban_reason" TEXT,
+-- NULL keeps the ban until the user is unbanned, it is lifted on its own once passed
+ADD COLUMN "banned_until" TIMESTAMPTZ,
+-- User who banned, NULL when the ban was not issued through the API
+ADD COLUMN "banned_by" VARCHAR(255),
+ADD COLUMN "banned_at" TIMESTAMPTZ;
@@ -0,0 +1,5 @@
+-- When the user last proved who they are on the session, by signing in or re-authenticating
+ALTER TABLE "sessions"
+ADD COLUMN "auth_time" TIMESTAMPTZ NOT NULL DEFAULT now();
+
+UPDATE "sessions" SET "auth_time" = "created";
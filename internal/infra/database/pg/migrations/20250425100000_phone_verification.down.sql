@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "phone_validations";
+
+ALTER TABLE "users"
+DROP COLUMN IF EXISTS "phone_verified";
@@ -0,0 +1,21 @@
+ALTER TABLE "users"
+ADD COLUMN "phone_verified" BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE
+	IF NOT EXISTS "phone_validations" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"user_id" VARCHAR(255) NOT NULL,
+		-- The number the code was sent to, a code never verifies another one
+		"phone_number" VARCHAR(255) NOT NULL,
+		-- verification or mfa
+		"purpose" VARCHAR(32) NOT NULL,
+		"code_hash" VARCHAR(255) NOT NULL,
+		"attempts" INT NOT NULL DEFAULT 0,
+		"is_consumed" BOOLEAN NOT NULL DEFAULT FALSE,
+		"is_valid" BOOLEAN NOT NULL DEFAULT TRUE,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT now(),
+		"expires" TIMESTAMPTZ NOT NULL,
+		CONSTRAINT "phone_validations_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+	);
+
+CREATE INDEX IF NOT EXISTS "idx_phone_validations_user_id_purpose" ON "phone_validations" ("user_id", "purpose", "created" DESC);
@@ -0,0 +1,31 @@
+CREATE TABLE
+	IF NOT EXISTS "phones" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"user_id" VARCHAR(255) NOT NULL,
+		"phone_number" VARCHAR(255) NOT NULL UNIQUE,
+		"is_primary" BOOLEAN NOT NULL DEFAULT FALSE,
+		"is_verified" BOOLEAN NOT NULL DEFAULT FALSE,
+		"created" TIMESTAMPTZ NOT NULL DEFAULT now(),
+		"updated" TIMESTAMPTZ NOT NULL DEFAULT now(),
+		CONSTRAINT "phones_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+	);
+
+CREATE INDEX idx_phones_user_id ON "phones" ("user_id");
+
+-- users.phone_number mirrors the primary phone, a user has at most one
+CREATE UNIQUE INDEX idx_phones_primary_user_id ON "phones" ("user_id")
+WHERE
+	"is_primary";
+
+INSERT INTO "phones" ("id", "user_id", "phone_number", "is_primary", "is_verified", "created", "updated")
+SELECT
+	'phone_' || md5(random()::text || "id"),
+	"id",
+	"phone_number",
+	TRUE,
+	"phone_verified",
+	"created",
+	"updated"
+FROM "users"
+WHERE
+	"phone_number" <> '';
@@ -0,0 +1,2 @@
+ALTER TABLE "phone_validations"
+DROP COLUMN IF EXISTS "challenge_id";
@@ -0,0 +1,3 @@
+-- Second factor codes only count for the login challenge they were texted for
+ALTER TABLE "phone_validations"
+ADD COLUMN "challenge_id" VARCHAR(255);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "platform_admins";
+
+DROP TABLE IF EXISTS "platform_permissions";
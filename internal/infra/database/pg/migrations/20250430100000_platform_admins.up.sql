@@ -0,0 +1,25 @@
+-- Permissions over the whole platform, held apart from the roles organizations manage
+-- No API writes to these tables, admins are granted through the database
+CREATE TABLE
+	IF NOT EXISTS "platform_permissions" (
+		"key" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"description" VARCHAR(255) NOT NULL
+	);
+
+CREATE TABLE
+	IF NOT EXISTS "platform_admins" (
+		"id" VARCHAR(255) PRIMARY KEY NOT NULL,
+		"user_id" VARCHAR(255) NOT NULL,
+		"permission_key" VARCHAR(255) NOT NULL,
+		-- Limits the grant to the users of an organization, NULL grants it on every organization
+		"org_id" VARCHAR(255),
+		"created" TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+		CONSTRAINT "user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE,
+		CONSTRAINT "permission_key_fkey" FOREIGN KEY ("permission_key") REFERENCES "platform_permissions" ("key") ON DELETE CASCADE,
+		CONSTRAINT "organization_id_fkey" FOREIGN KEY ("org_id") REFERENCES "organizations" ("id") ON DELETE CASCADE
+	);
+
+CREATE INDEX idx_platform_admins_user_id ON "platform_admins" ("user_id");
+
+INSERT INTO "platform_permissions" ("key", "description")
+VALUES ('platform:impersonate', 'Act as other users to help them, every request is audited');
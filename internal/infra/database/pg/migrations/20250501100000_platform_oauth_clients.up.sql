@@ -0,0 +1,2 @@
+INSERT INTO "platform_permissions" ("key", "description")
+VALUES ('platform:oauth-clients', 'Register and delete the OAuth clients signing users in through the platform');
@@ -0,0 +1,3 @@
+ALTER TABLE "sessions"
+DROP COLUMN IF EXISTS "scope",
+DROP COLUMN IF EXISTS "client_id";
@@ -0,0 +1,4 @@
+-- Sessions opened for an OAuth client acting for the user, NULL for first-party sessions
+ALTER TABLE "sessions"
+ADD COLUMN "client_id" VARCHAR(255),
+ADD COLUMN "scope" TEXT;
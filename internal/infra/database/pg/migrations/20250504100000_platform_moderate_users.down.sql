@@ -0,0 +1 @@
+DELETE FROM "platform_permissions" WHERE "key" = 'platform:users-moderate';
@@ -0,0 +1,2 @@
+INSERT INTO "platform_permissions" ("key", "description")
+VALUES ('platform:users-moderate', 'Ban and unban users, limited to an organization when granted on one');
@@ -0,0 +1,2 @@
+ALTER TABLE "sso_connections"
+DROP COLUMN IF EXISTS "require_mfa";
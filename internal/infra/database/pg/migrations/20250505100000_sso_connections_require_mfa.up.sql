@@ -0,0 +1,5 @@
+-- Whether members enrolled in two-factor authentication must still complete it after
+-- signing in with the provider. Only connections whose provider enforces its own second
+-- factor should turn it off
+ALTER TABLE "sso_connections"
+ADD COLUMN "require_mfa" BOOLEAN NOT NULL DEFAULT true;
@@ -0,0 +1,8 @@
+-- Fails once users share an unverified number, they must be removed first
+DROP INDEX IF EXISTS idx_phones_verified_phone_number;
+
+ALTER TABLE "phones"
+DROP CONSTRAINT IF EXISTS "phones_user_id_phone_number_key";
+
+ALTER TABLE "phones"
+ADD CONSTRAINT "phones_phone_number_key" UNIQUE ("phone_number");
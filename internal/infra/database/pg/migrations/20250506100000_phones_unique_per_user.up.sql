@@ -0,0 +1,11 @@
+-- Unverified numbers only claim a number for their user, anyone could add someone else's.
+-- A number may be added by several users, but verified by a single one
+ALTER TABLE "phones"
+DROP CONSTRAINT IF EXISTS "phones_phone_number_key";
+
+ALTER TABLE "phones"
+ADD CONSTRAINT "phones_user_id_phone_number_key" UNIQUE ("user_id", "phone_number");
+
+CREATE UNIQUE INDEX idx_phones_verified_phone_number ON "phones" ("phone_number")
+WHERE
+	"is_verified";
@@ -0,0 +1,8 @@
+-- Fails once two organizations define the same key or name
+ALTER TABLE "permissions"
+DROP CONSTRAINT IF EXISTS "permissions_org_id_key_key",
+DROP CONSTRAINT IF EXISTS "permissions_org_id_name_key";
+
+ALTER TABLE "permissions"
+ADD CONSTRAINT "permissions_key_key" UNIQUE ("key"),
+ADD CONSTRAINT "permissions_name_key" UNIQUE ("name");
@@ -0,0 +1,9 @@
+-- Permissions belong to an organization, every organization defines its own keys
+-- such as teams:read, so they are only unique within it
+ALTER TABLE "permissions"
+DROP CONSTRAINT IF EXISTS "permissions_key_key",
+DROP CONSTRAINT IF EXISTS "permissions_name_key";
+
+ALTER TABLE "permissions"
+ADD CONSTRAINT "permissions_org_id_key_key" UNIQUE ("org_id", "key"),
+ADD CONSTRAINT "permissions_org_id_name_key" UNIQUE ("org_id", "name");
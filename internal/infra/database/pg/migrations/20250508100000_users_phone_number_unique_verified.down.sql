@@ -0,0 +1,6 @@
+-- Fails once users share an unverified number, they must be changed first
+DROP INDEX IF EXISTS "users_phone_number_key";
+
+CREATE UNIQUE INDEX "users_phone_number_key" ON "users" ("phone_number")
+WHERE
+	"phone_number" <> '';
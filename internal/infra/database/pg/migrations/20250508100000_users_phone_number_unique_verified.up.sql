@@ -0,0 +1,9 @@
+-- users.phone_number mirrors the primary phone, verified or not, so like the phones
+-- themselves only a verified number is unique. Otherwise adding someone else's number
+-- would keep them from registering with it
+DROP INDEX IF EXISTS "users_phone_number_key";
+
+CREATE UNIQUE INDEX "users_phone_number_key" ON "users" ("phone_number")
+WHERE
+	"phone_verified"
+	AND "phone_number" <> '';
@@ -2,48 +2,271 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	. "github.com/bernardinorafael/internal/_shared/errors"
 	"github.com/bernardinorafael/internal/infra/token"
 	"github.com/bernardinorafael/pkg/logger"
+	"github.com/go-chi/chi"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 type AuthKey struct{}
 
-type middleware struct {
-	log       logger.Logger
-	secretKey string
+// RecentAuthMaxAge is how long a sign-in or re-authentication is fresh enough for sensitive operations
+const RecentAuthMaxAge = 10 * time.Minute
+
+// SessionValidator reports whether the session an access token is bound to is still usable
+type SessionValidator interface {
+	IsActive(ctx context.Context, sessionId string) (bool, error)
+}
+
+// TokenAuthenticator resolves personal access tokens into the claims of their owner
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, token string) (*token.AccountClaims, error)
+}
+
+// ServiceAccountValidator reports whether the service account a token identifies still exists
+type ServiceAccountValidator interface {
+	IsActive(ctx context.Context, serviceAccountId string) (bool, error)
+}
+
+// ImpersonationAuditor records the requests made while impersonating a user
+type ImpersonationAuditor interface {
+	RecordImpersonatedRequest(ctx context.Context, claims *token.AccountClaims, method, path, ip, agent string) error
+}
+
+type Auth struct {
+	log      logger.Logger
+	keys     *token.KeySet
+	sessions SessionValidator
+	tokens   TokenAuthenticator
+	services ServiceAccountValidator
+	auditor  ImpersonationAuditor
 }
 
-func NewWithAuth(log logger.Logger, secretKey string) *middleware {
-	return &middleware{
-		log:       log,
-		secretKey: secretKey,
+func NewWithAuth(
+	log logger.Logger,
+	keys *token.KeySet,
+	sessions SessionValidator,
+	tokens TokenAuthenticator,
+	services ServiceAccountValidator,
+	auditor ImpersonationAuditor,
+) *Auth {
+	return &Auth{
+		log:      log,
+		keys:     keys,
+		sessions: sessions,
+		tokens:   tokens,
+		services: services,
+		auditor:  auditor,
 	}
 }
 
-func (m *middleware) WithAuth(next http.Handler) http.Handler {
+// WithAuth authenticates the request, rejecting tokens issued to OAuth clients
+// They only carry the scope the user granted and are limited to the OAuth endpoints
+func (m *Auth) WithAuth(next http.Handler) http.Handler {
+	return m.authenticate(next, false)
+}
+
+// WithClientAuth authenticates the request like WithAuth, also accepting tokens issued to OAuth clients
+// Handlers must only serve what claims.Scope grants to those
+func (m *Auth) WithClientAuth(next http.Handler) http.Handler {
+	return m.authenticate(next, true)
+}
+
+func (m *Auth) authenticate(next http.Handler, allowClients bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		accessToken := r.Header.Get("Authorization")
+		// Browsers in cookie mode send it as a cookie, WithCSRF guards these requests
+		if cookie, err := r.Cookie(AccessTokenCookie); len(accessToken) == 0 && err == nil {
+			accessToken = cookie.Value
+		}
 
 		if len(accessToken) == 0 {
 			NewHttpError(w, NewUnauthorizedError("access token not provided", nil))
 			return
 		}
 
-		claims, err := token.Verify(m.secretKey, accessToken)
+		// Personal access tokens are usually sent by scripts, which expect the bearer scheme
+		if raw := strings.TrimPrefix(accessToken, "Bearer "); strings.HasPrefix(raw, token.PersonalTokenPrefix) {
+			claims, err := m.tokens.AuthenticateToken(r.Context(), raw)
+			if err != nil {
+				NewHttpError(w, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), AuthKey{}, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		claims, err := token.Verify(m.keys, accessToken)
 		if err != nil {
-			if strings.Contains(err.Error(), "token has expired") {
+			if errors.Is(err, jwt.ErrTokenExpired) {
 				NewHttpError(w, NewUnauthorizedError("token has expired", err))
 				return
 			}
 			NewHttpError(w, NewUnauthorizedError("invalid access token", err))
 			return
 		}
+
+		// Service accounts have no session, their tokens live as long as the account does
+		if claims.IsService() {
+			active, err := m.services.IsActive(r.Context(), claims.Subject)
+			if err != nil {
+				m.log.Errorw(r.Context(), "error on check service account", logger.Err(err))
+				NewHttpError(w, NewUnauthorizedError("invalid access token", err))
+				return
+			}
+			if !active {
+				NewHttpError(w, NewUnauthorizedError("service account no longer exists", nil))
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), AuthKey{}, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		// Refresh tokens are not bound to a session, so they can't be used as access tokens
+		if claims.SessionID == "" {
+			NewHttpError(w, NewUnauthorizedError("invalid access token", nil))
+			return
+		}
+
+		active, err := m.sessions.IsActive(r.Context(), claims.SessionID)
+		if err != nil {
+			m.log.Errorw(r.Context(), "error on check session", logger.Err(err))
+			NewHttpError(w, NewUnauthorizedError("invalid access token", err))
+			return
+		}
+		if !active {
+			NewHttpError(w, NewUnauthorizedError("session is no longer active", nil))
+			return
+		}
+
+		if claims.IsDelegated() && !allowClients {
+			NewHttpError(w, NewForbiddenError("token issued to an oauth client can't be used here", MissingPermission, nil))
+			return
+		}
+
+		// Nothing is done as someone else without leaving a trace, so the request fails when it can't be recorded
+		if claims.IsImpersonated() {
+			err := m.auditor.RecordImpersonatedRequest(r.Context(), claims, r.Method, r.URL.Path, r.RemoteAddr, r.UserAgent())
+			if err != nil {
+				m.log.Errorw(r.Context(), "error on record impersonated request", logger.Err(err))
+				NewHttpError(w, err)
+				return
+			}
+		}
+
 		ctx := context.WithValue(r.Context(), AuthKey{}, claims)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// WithPermission rejects requests made with a token restricted to other permissions
+// Must be used after WithAuth
+func (m *Auth) WithPermission(key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(AuthKey{}).(*token.AccountClaims)
+			if !ok {
+				NewHttpError(w, NewUnauthorizedError("access token not provided", nil))
+				return
+			}
+
+			if !claims.Allows(key) {
+				NewHttpError(w, NewForbiddenError("token is not allowed to use "+key, MissingPermission, nil))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DenyService rejects requests made by service accounts, for routes acting on the user
+// signed in or on users outside of any organization. Must be used after WithAuth
+func (m *Auth) DenyService(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value(AuthKey{}).(*token.AccountClaims)
+		if !ok {
+			NewHttpError(w, NewUnauthorizedError("access token not provided", nil))
+			return
+		}
+
+		if claims.IsService() {
+			NewHttpError(w, NewForbiddenError("not allowed for service accounts", MissingPermission, nil))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WithOrgScope rejects service accounts acting on another organization than their own,
+// read from the URL parameter. Must be used after WithAuth
+func (m *Auth) WithOrgScope(param string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(AuthKey{}).(*token.AccountClaims)
+			if !ok {
+				NewHttpError(w, NewUnauthorizedError("access token not provided", nil))
+				return
+			}
+
+			if !claims.ActsOn(chi.URLParam(r, param)) {
+				NewHttpError(w, NewForbiddenError("service accounts can't act on other organizations", MissingPermission, nil))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DenyImpersonation rejects requests made while impersonating the user, for operations
+// only the user may perform such as changing credentials. Must be used after WithAuth
+func (m *Auth) DenyImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value(AuthKey{}).(*token.AccountClaims)
+		if !ok {
+			NewHttpError(w, NewUnauthorizedError("access token not provided", nil))
+			return
+		}
+
+		if claims.IsImpersonated() {
+			NewHttpError(w, NewForbiddenError("not allowed while impersonating the user", ImpersonationForbidden, nil))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireRecentAuth rejects requests from users who did not sign in or re-authenticate within maxAge,
+// for sensitive operations a stolen session must not be enough for. Must be used after WithAuth
+func (m *Auth) RequireRecentAuth(maxAge time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(AuthKey{}).(*token.AccountClaims)
+			if !ok {
+				NewHttpError(w, NewUnauthorizedError("access token not provided", nil))
+				return
+			}
+
+			if !claims.AuthenticatedWithin(maxAge) {
+				NewHttpError(w, NewForbiddenError("recent authentication required", ReauthenticationNeeded, nil))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
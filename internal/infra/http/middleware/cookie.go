@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/dto"
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/pkg/crypto"
+)
+
+// Browser clients opt in to cookie mode with this header set to "cookie"
+const AuthModeHeader = "X-Auth-Mode"
+
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	// CSRFCookie is readable by scripts, they must echo it in CSRFHeader
+	CSRFCookie = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+	// The refresh token is only ever sent to the endpoint renewing it
+	refreshTokenPath = "/api/v1/auth/refresh"
+)
+
+// IsCookieMode reports whether the client asked for the tokens as cookies
+func IsCookieMode(r *http.Request) bool {
+	return r.Header.Get(AuthModeHeader) == "cookie"
+}
+
+// SetAuthCookies sets the tokens of a session as HttpOnly cookies along with a new CSRF token,
+// which is also sent in the CSRFHeader response header for clients on another subdomain
+func SetAuthCookies(w http.ResponseWriter, accessToken string, accessExpires int64, refreshToken string, refreshExpires int64) error {
+	csrfToken, err := crypto.GenerateToken(32)
+	if err != nil {
+		return NewBadRequestError("error on generate csrf token", err)
+	}
+
+	SetAccessCookie(w, accessToken, accessExpires)
+	http.SetCookie(w, &http.Cookie{
+		Name:     RefreshTokenCookie,
+		Value:    refreshToken,
+		Path:     refreshTokenPath,
+		Expires:  time.Unix(refreshExpires, 0),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookie,
+		Value:    csrfToken,
+		Path:     "/",
+		Expires:  time.Unix(refreshExpires, 0),
+		HttpOnly: false,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	w.Header().Set(CSRFHeader, csrfToken)
+
+	return nil
+}
+
+// SetAccessCookie replaces the access token cookie alone, keeping the refresh and CSRF tokens
+func SetAccessCookie(w http.ResponseWriter, accessToken string, accessExpires int64) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     AccessTokenCookie,
+		Value:    accessToken,
+		Path:     "/",
+		Expires:  time.Unix(accessExpires, 0),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// WriteSession responds with the tokens of a new session. In cookie mode they are set as
+// cookies too and the refresh token is left out of the body, out of the reach of scripts
+func WriteSession(w http.ResponseWriter, r *http.Request, payload *dto.AccountResponse) {
+	if IsCookieMode(r) {
+		err := SetAuthCookies(
+			w,
+			payload.AccessToken,
+			payload.AccessTokenExpires,
+			payload.RefreshToken,
+			payload.RefreshTokenExpires,
+		)
+		if err != nil {
+			NewHttpError(w, err)
+			return
+		}
+		payload.RefreshToken = ""
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, payload)
+}
+
+// ClearAuthCookies expires the cookies set by SetAuthCookies
+func ClearAuthCookies(w http.ResponseWriter) {
+	for name, path := range map[string]string{
+		AccessTokenCookie:  "/",
+		RefreshTokenCookie: refreshTokenPath,
+		CSRFCookie:         "/",
+	} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     path,
+			MaxAge:   -1,
+			HttpOnly: name != CSRFCookie,
+			Secure:   true,
+			SameSite: http.SameSiteStrictMode,
+		})
+	}
+}
+
+// WithCSRF checks the double-submit CSRF token of state changing requests authenticated
+// by cookies, which browsers attach on their own. Requests sending the token in the
+// Authorization header or in the body can't be forged by another site and are let through
+func WithCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !usesAuthCookie(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cookie, err := r.Cookie(CSRFCookie)
+		header := r.Header.Get(CSRFHeader)
+		if err != nil || cookie.Value == "" || header == "" ||
+			subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+			NewHttpError(w, NewForbiddenError("invalid csrf token", InvalidCSRFToken, nil))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// usesAuthCookie reports whether the request will be authenticated by a cookie
+func usesAuthCookie(r *http.Request) bool {
+	if r.Header.Get("Authorization") != "" {
+		return false
+	}
+	if _, err := r.Cookie(AccessTokenCookie); err == nil {
+		return true
+	}
+	_, err := r.Cookie(RefreshTokenCookie)
+	return err == nil
+}
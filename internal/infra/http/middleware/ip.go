@@ -4,6 +4,7 @@ package middleware
 // https://github.com/zenazn/goji/tree/master/web/middleware
 
 import (
+	"fmt"
 	"net"
 	"net/http"
 	"strings"
@@ -13,7 +14,7 @@ var trueClientIP = http.CanonicalHeaderKey("True-Client-IP")
 var xForwardedFor = http.CanonicalHeaderKey("X-Forwarded-For")
 var xRealIP = http.CanonicalHeaderKey("X-Real-IP")
 
-// WithIP is a middleware that sets a http.Request's RemoteAddr to the results
+// NewWithIP returns a middleware that sets a http.Request's RemoteAddr to the results
 // of parsing either the True-Client-IP, X-Real-IP or the X-Forwarded-For headers
 // (in that order).
 //
@@ -21,25 +22,62 @@ var xRealIP = http.CanonicalHeaderKey("X-Real-IP")
 // ensure that subsequent layers (e.g., request loggers) which examine the
 // RemoteAddr will see the intended value.
 //
-// You should only use this middleware if you can trust the headers passed to
-// you (in particular, the three headers this middleware uses), for example
-// because you have placed a reverse proxy like HAProxy or nginx in front of
-// chi. If your reverse proxies are configured to pass along arbitrary header
-// values from the client, or if you use this middleware without a reverse
-// proxy, malicious clients will be able to make you very sad (or, depending on
-// how you're using RemoteAddr, vulnerable to an attack of some sort).
-func WithIP(h http.Handler) http.Handler {
-	fn := func(w http.ResponseWriter, r *http.Request) {
-		if rip := withIP(r); rip != "" {
-			r.RemoteAddr = rip
-		}
-		h.ServeHTTP(w, r)
+// Clients can send these headers themselves, so they are only read from requests
+// made by one of the trusted proxies, given as IPs or CIDRs. The address of whoever
+// connected is kept otherwise, and with no trusted proxies the headers are ignored
+func NewWithIP(trustedProxies []string) (func(http.Handler) http.Handler, error) {
+	proxies := make([]*net.IPNet, 0, len(trustedProxies))
+	for _, v := range trustedProxies {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			if ip := net.ParseIP(v); ip != nil && ip.To4() != nil {
+				v += "/32"
+			} else {
+				v += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+		}
+		proxies = append(proxies, network)
+	}
+
+	trusted := func(addr string) bool {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		ip := net.ParseIP(strings.TrimSpace(host))
+		if ip == nil {
+			return false
+		}
+		for _, network := range proxies {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
 	}
 
-	return http.HandlerFunc(fn)
+	return func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if trusted(r.RemoteAddr) {
+				if rip := withIP(r, trusted); rip != "" {
+					r.RemoteAddr = rip
+				}
+			}
+			h.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}, nil
 }
 
-func withIP(r *http.Request) string {
+func withIP(r *http.Request, trusted func(addr string) bool) string {
 	var ip string
 
 	if tcip := r.Header.Get(trueClientIP); tcip != "" {
@@ -47,11 +85,15 @@ func withIP(r *http.Request) string {
 	} else if xrip := r.Header.Get(xRealIP); xrip != "" {
 		ip = xrip
 	} else if xff := r.Header.Get(xForwardedFor); xff != "" {
-		i := strings.Index(xff, ",")
-		if i == -1 {
-			i = len(xff)
+		// Each proxy appends the address it got the request from, anything left of
+		// the last trusted proxy may have been sent by the client
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip = strings.TrimSpace(hops[i])
+			if !trusted(ip) {
+				break
+			}
 		}
-		ip = xff[:i]
 	}
 	if ip == "" || net.ParseIP(ip) == nil {
 		return ""
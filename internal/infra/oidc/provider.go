@@ -0,0 +1,272 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// How long discovery metadata and signing keys of a provider are trusted before fetching them again
+const metadataTTL = time.Hour
+
+// ErrIssuerNotAllowed is returned for issuers that are not public https URLs,
+// organizations must not get the server to call its own network
+var ErrIssuerNotAllowed = errors.New("issuer must be a public https url")
+
+// Client talks to external OpenID Connect providers. The issuer is all it needs,
+// everything else comes from discovery, so any compliant server works
+type Client struct {
+	http *http.Client
+	// allowPrivate lets issuers be served over http or from private addresses, for local development
+	allowPrivate bool
+	mu           sync.Mutex
+	providers    map[string]*Provider
+}
+
+// NewClient returns a client only reaching public https issuers unless allowPrivate is set
+// The default http client refuses to connect to private addresses, redirects and
+// endpoints from discovery included. A given client is used as is
+func NewClient(httpClient *http.Client, allowPrivate bool) *Client {
+	if httpClient == nil {
+		dialer := &net.Dialer{Timeout: 5 * time.Second}
+		if !allowPrivate {
+			dialer.Control = denyPrivate
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil
+		transport.DialContext = dialer.DialContext
+		httpClient = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	}
+	return &Client{
+		http:         httpClient,
+		allowPrivate: allowPrivate,
+		providers:    make(map[string]*Provider),
+	}
+}
+
+// checkIssuer rejects issuers the client must not reach
+func (c *Client) checkIssuer(issuer string) error {
+	parsed, err := url.Parse(issuer)
+	if err != nil || parsed.Host == "" || parsed.User != nil {
+		return ErrIssuerNotAllowed
+	}
+	if c.allowPrivate {
+		if parsed.Scheme != "https" && parsed.Scheme != "http" {
+			return ErrIssuerNotAllowed
+		}
+		return nil
+	}
+	if parsed.Scheme != "https" {
+		return ErrIssuerNotAllowed
+	}
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil && !isPublic(ip) {
+		return ErrIssuerNotAllowed
+	}
+	if strings.EqualFold(parsed.Hostname(), "localhost") {
+		return ErrIssuerNotAllowed
+	}
+	return nil
+}
+
+// denyPrivate refuses connections to non public addresses, checked once the host
+// is resolved so a name pointing to the internal network is caught as well
+func denyPrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublic(ip) {
+		return fmt.Errorf("%w: %s resolves to a private address", ErrIssuerNotAllowed, address)
+	}
+	return nil
+}
+
+func isPublic(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified()
+}
+
+// Provider returns the provider of the issuer, discovering it when unknown or stale
+func (c *Client) Provider(ctx context.Context, issuer string) (*Provider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.checkIssuer(issuer); err != nil {
+		return nil, err
+	}
+
+	if p, ok := c.providers[issuer]; ok && time.Since(p.discovered) < metadataTTL {
+		return p, nil
+	}
+
+	p, err := discover(ctx, c.http, issuer)
+	if err != nil {
+		return nil, err
+	}
+	c.providers[issuer] = p
+
+	return p, nil
+}
+
+// Provider is an OpenID Connect provider, as described by its discovery metadata
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+
+	http       *http.Client
+	discovered time.Time
+
+	mu          sync.Mutex
+	keys        map[string]any
+	keysFetched time.Time
+}
+
+func discover(ctx context.Context, client *http.Client, issuer string) (*Provider, error) {
+	endpoint := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+
+	var p Provider
+	if err := getJSON(ctx, client, endpoint, &p); err != nil {
+		return nil, fmt.Errorf("failed to discover provider: %w", err)
+	}
+
+	// OpenID Connect Discovery 1.0, section 4.3
+	if p.Issuer != issuer {
+		return nil, fmt.Errorf("issuer mismatch, expected %q got %q", issuer, p.Issuer)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JWKSURI == "" {
+		return nil, errors.New("provider metadata is missing required endpoints")
+	}
+
+	p.http = client
+	p.discovered = time.Now()
+
+	return &p, nil
+}
+
+// AuthRequest holds the parameters of an authorization request
+type AuthRequest struct {
+	ClientID      string
+	RedirectURI   string
+	Scopes        []string
+	State         string
+	Nonce         string
+	CodeChallenge string
+}
+
+// AuthCodeURL returns where the user must be sent to sign in with the provider
+func (p *Provider) AuthCodeURL(req AuthRequest) string {
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {req.ClientID},
+		"redirect_uri":          {req.RedirectURI},
+		"scope":                 {strings.Join(req.Scopes, " ")},
+		"state":                 {req.State},
+		"nonce":                 {req.Nonce},
+		"code_challenge":        {req.CodeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return p.AuthorizationEndpoint + sep + params.Encode()
+}
+
+// Tokens are returned by the token endpoint of the provider
+type Tokens struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Exchange trades an authorization code for the tokens of the user
+func (p *Provider) Exchange(
+	ctx context.Context,
+	clientId, clientSecret, code, redirectURI, codeVerifier string,
+) (*Tokens, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(clientId), url.QueryEscape(clientSecret))
+
+	res, err := p.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request tokens: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Code        string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		_ = json.Unmarshal(body, &oauthErr)
+		return nil, fmt.Errorf("token request failed with status %d: %s %s", res.StatusCode, oauthErr.Code, oauthErr.Description)
+	}
+
+	var tokens Tokens
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response has no id token")
+	}
+
+	return &tokens, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, endpoint string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", res.StatusCode, endpoint)
+	}
+
+	return json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(dst)
+}
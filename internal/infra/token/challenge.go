@@ -0,0 +1,60 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// PurposeMFA identifies the challenge handed out while a login waits for its second factor
+const PurposeMFA = "mfa"
+
+// ChallengeClaims are carried by short-lived tokens proving that a step of a
+// multi-step flow succeeded. They grant no access on their own
+type ChallengeClaims struct {
+	AccountID string `json:"account_id"`
+	Purpose   string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+func GenerateChallenge(keys *KeySet, accId, purpose string, duration time.Duration) (string, *ChallengeClaims, error) {
+	claims := &ChallengeClaims{
+		AccountID: accId,
+		Purpose:   purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        util.GenID("chl"),
+			Subject:   accId,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+		},
+	}
+
+	token, err := keys.sign(claims)
+	if err != nil {
+		return "", claims, err
+	}
+
+	return token, claims, nil
+}
+
+// VerifyChallenge parses a challenge token, refusing tokens issued for another purpose
+func VerifyChallenge(keys *KeySet, v, purpose string) (*ChallengeClaims, error) {
+	if strings.TrimSpace(v) == "" {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	token, err := keys.parse(v, &ChallengeClaims{}, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*ChallengeClaims)
+	if !ok || claims.Purpose != purpose {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+
+	return claims, nil
+}
@@ -1,25 +1,64 @@
 package token
 
 import (
-	"errors"
+	"slices"
 	"time"
 
+	"github.com/bernardinorafael/internal/_shared/util"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// PersonalTokenPrefix tells personal access tokens apart from JWTs
+const PersonalTokenPrefix = "pat_"
+
+// Kinds of principal a token identifies
+const (
+	PrincipalUser    = "user"
+	PrincipalService = "service"
+)
+
 type AccountClaims struct {
 	AccountID string `json:"account_id"`
 	UserID    string `json:"user_id"`
 	Username  string `json:"username"`
+	// SessionID binds the token to the session family it was issued for
+	SessionID string `json:"sid,omitempty"`
+	// TokenID is set instead of SessionID when authenticated with a personal access token
+	TokenID string `json:"-"`
+	// Principal is PrincipalService for service accounts, which have no account nor user,
+	// the subject is their ID. Tokens issued before it existed identify users
+	Principal string `json:"principal,omitempty"`
+	// OrgID is the organization owning a service account
+	OrgID string `json:"org_id,omitempty"`
+	// Permissions restricts the request to these permission keys, nil means unrestricted
+	// Service accounts are only granted the permissions listed
+	Permissions []string `json:"permissions,omitempty"`
+	// Actor is the one really making the requests when the user is impersonated, RFC 8693 section 4.1
+	Actor *Actor `json:"act,omitempty"`
+	// ClientID is the OAuth client the token was issued to, empty for the first-party apps
+	ClientID string `json:"client_id,omitempty"`
+	// Scope is what the user granted the OAuth client, RFC 8693 section 4.2
+	Scope string `json:"scope,omitempty"`
+	// AuthTime is when the user last authenticated on the session, OpenID Connect Core section 2
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewAccountClaims(accId, userId, username string, duration time.Duration) (*AccountClaims, error) {
+type Actor struct {
+	UserID   string `json:"sub"`
+	Username string `json:"username"`
+}
+
+func NewAccountClaims(accId, userId, username, sessionId string, duration time.Duration) (*AccountClaims, error) {
 	claims := &AccountClaims{
 		AccountID: accId,
 		UserID:    userId,
 		Username:  username,
+		SessionID: sessionId,
+		Principal: PrincipalUser,
 		RegisteredClaims: jwt.RegisteredClaims{
+			// Unique ID, so tokens issued in the same second never collide
+			ID:        util.GenID("tok"),
 			Subject:   username,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
@@ -29,9 +68,80 @@ func NewAccountClaims(accId, userId, username string, duration time.Duration) (*
 	return claims, nil
 }
 
-func (a *AccountClaims) Valid() error {
-	if time.Now().After(a.ExpiresAt.Time) {
-		return errors.New("token has expired")
+// NewPersonalTokenClaims builds the claims of a request authenticated with a personal access token
+// They are never signed, the token itself is checked against the database on every request
+func NewPersonalTokenClaims(accId, userId, username, tokenId string, permissions []string, expires *time.Time) *AccountClaims {
+	claims := &AccountClaims{
+		AccountID:   accId,
+		UserID:      userId,
+		Username:    username,
+		TokenID:     tokenId,
+		Principal:   PrincipalUser,
+		Permissions: permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:      tokenId,
+			Subject: username,
+		},
+	}
+	if expires != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*expires)
+	}
+
+	return claims
+}
+
+// NewServiceClaims builds the claims of a service account, holding the permission keys of its role
+func NewServiceClaims(serviceAccountId, orgId, name string, permissions []string, duration time.Duration) *AccountClaims {
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	return &AccountClaims{
+		Username:    name,
+		Principal:   PrincipalService,
+		OrgID:       orgId,
+		Permissions: permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        util.GenID("tok"),
+			Subject:   serviceAccountId,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+		},
+	}
+}
+
+// IsImpersonated reports whether someone else is acting as the user
+func (a *AccountClaims) IsImpersonated() bool {
+	return a.Actor != nil
+}
+
+// IsDelegated reports whether the token was issued to an OAuth client acting for the user
+func (a *AccountClaims) IsDelegated() bool {
+	return a.ClientID != ""
+}
+
+// IsService reports whether the token identifies a service account instead of a user
+func (a *AccountClaims) IsService() bool {
+	return a.Principal == PrincipalService
+}
+
+// ActsOn reports whether the token may act on the organization
+// Service accounts only ever act on the organization owning them
+func (a *AccountClaims) ActsOn(orgId string) bool {
+	return !a.IsService() || a.OrgID == orgId
+}
+
+// Allows reports whether the token grants the permission. Users are only limited when
+// their token was restricted, service accounts only have the permissions of their role
+func (a *AccountClaims) Allows(key string) bool {
+	if a.IsService() {
+		return slices.Contains(a.Permissions, key)
 	}
-	return nil
+	return a.Permissions == nil || slices.Contains(a.Permissions, key)
+}
+
+// AuthenticatedWithin reports whether the user authenticated on the session no longer than maxAge ago
+// Tokens without an auth time, such as personal access tokens, never are
+func (a *AccountClaims) AuthenticatedWithin(maxAge time.Duration) bool {
+	return a.AuthTime != nil && time.Since(a.AuthTime.Time) <= maxAge
 }
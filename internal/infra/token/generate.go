@@ -4,25 +4,88 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/aead/chacha20poly1305"
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-func Generate(secretKey, accId, userId, username string, duration time.Duration) (string, *AccountClaims, error) {
-	if len(secretKey) != chacha20poly1305.KeySize {
-		return "", nil, fmt.Errorf("invalid secret key")
+func Generate(keys *KeySet, accId, userId, username, sessionId string, duration time.Duration) (string, *AccountClaims, error) {
+	claims, err := NewAccountClaims(accId, userId, username, sessionId, duration)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create account claims: %w", err)
+	}
+
+	token, err := keys.sign(claims)
+	if err != nil {
+		return "", claims, err
+	}
+
+	return token, claims, nil
+}
+
+// GenerateAccess signs an access token of a session, carrying when the user last authenticated on it
+func GenerateAccess(keys *KeySet, accId, userId, username, sessionId string, authTime time.Time, duration time.Duration) (string, *AccountClaims, error) {
+	claims, err := NewAccountClaims(accId, userId, username, sessionId, duration)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create account claims: %w", err)
+	}
+	claims.AuthTime = jwt.NewNumericDate(authTime)
+
+	token, err := keys.sign(claims)
+	if err != nil {
+		return "", claims, err
+	}
+
+	return token, claims, nil
+}
+
+// GenerateClientAccess signs an access token of a session opened for an OAuth client,
+// carrying the client and the scope the user granted it
+func GenerateClientAccess(
+	keys *KeySet,
+	accId, userId, username, sessionId, clientId, scope string,
+	authTime time.Time,
+	duration time.Duration,
+) (string, *AccountClaims, error) {
+	claims, err := NewAccountClaims(accId, userId, username, sessionId, duration)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create account claims: %w", err)
+	}
+	claims.ClientID = clientId
+	claims.Scope = scope
+	claims.AuthTime = jwt.NewNumericDate(authTime)
+
+	token, err := keys.sign(claims)
+	if err != nil {
+		return "", claims, err
 	}
 
-	claims, err := NewAccountClaims(accId, userId, username, duration)
+	return token, claims, nil
+}
+
+// GenerateImpersonation signs an access token of the user carrying the actor impersonating them
+func GenerateImpersonation(
+	keys *KeySet,
+	accId, userId, username, sessionId string,
+	actor Actor,
+	duration time.Duration,
+) (string, *AccountClaims, error) {
+	claims, err := NewAccountClaims(accId, userId, username, sessionId, duration)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to create account claims: %w", err)
 	}
+	claims.Actor = &actor
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	token, err := jwtToken.SignedString([]byte(secretKey))
+	token, err := keys.sign(claims)
 	if err != nil {
-		return "", claims, fmt.Errorf("failed to sign token: %w", err)
+		return "", claims, err
 	}
 
 	return token, claims, nil
 }
+
+func GenerateServiceToken(keys *KeySet, claims *AccountClaims) (string, error) {
+	token, err := keys.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate service token: %w", err)
+	}
+	return token, nil
+}
@@ -0,0 +1,43 @@
+package token
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// IDClaims are the claims of an OpenID Connect ID token. The subject is the user ID,
+// profile claims are only present when the matching scope was granted
+type IDClaims struct {
+	Nonce             string `json:"nonce,omitempty"`
+	Name              string `json:"name,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+	Email             string `json:"email,omitempty"`
+	PhoneNumber       string `json:"phone_number,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func NewIDClaims(issuer, clientId, userId, nonce string, duration time.Duration) *IDClaims {
+	return &IDClaims{
+		Nonce: nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        util.GenID("idt"),
+			Issuer:    issuer,
+			Subject:   userId,
+			Audience:  jwt.ClaimStrings{clientId},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+		},
+	}
+}
+
+func GenerateIDToken(keys *KeySet, claims *IDClaims) (string, error) {
+	token, err := keys.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate id token: %w", err)
+	}
+	return token, nil
+}
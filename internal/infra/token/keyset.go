@@ -0,0 +1,256 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	privateKeyExt = ".pem"
+	publicKeyExt  = ".pub.pem"
+)
+
+// KeySet signs tokens with the current Ed25519 key and verifies them with any
+// key still in the set, so keys can be rotated without invalidating tokens
+// issued before the rotation
+type KeySet struct {
+	signingKid string
+	signingKey ed25519.PrivateKey
+	keys       map[string]ed25519.PublicKey
+	// legacySecret verifies HS256 refresh tokens issued before asymmetric signing until legacyUntil,
+	// they are never signed with it
+	legacySecret []byte
+	legacyUntil  time.Time
+}
+
+func NewKeySet(signingKid string, signingKey ed25519.PrivateKey) (*KeySet, error) {
+	if signingKid == "" {
+		return nil, errors.New("signing key id cannot be empty")
+	}
+	if len(signingKey) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid signing key")
+	}
+
+	return &KeySet{
+		signingKid: signingKid,
+		signingKey: signingKey,
+		keys: map[string]ed25519.PublicKey{
+			signingKid: signingKey.Public().(ed25519.PublicKey),
+		},
+	}, nil
+}
+
+// LoadKeySet reads the keys from dir. Private keys are stored as <kid>.pem and
+// retired keys may keep only their public part as <kid>.pub.pem, both PKCS8/PKIX
+// PEM encoded as created by `openssl genpkey -algorithm ed25519`.
+// The key named signingKid signs new tokens, every other key only verifies them.
+// The legacy secret is dropped once legacyUntil has passed
+func LoadKeySet(dir, signingKid, legacySecret string, legacyUntil time.Time) (*KeySet, error) {
+	signing, err := readPrivateKey(filepath.Join(dir, signingKid+privateKeyExt))
+	if err != nil {
+		return nil, err
+	}
+
+	ks, err := NewKeySet(signingKid, signing)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys dir: %w", err)
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		path := filepath.Join(dir, name)
+
+		switch {
+		case e.IsDir():
+			continue
+		case strings.HasSuffix(name, publicKeyExt):
+			key, err := readPublicKey(path)
+			if err != nil {
+				return nil, err
+			}
+			ks.AddVerificationKey(strings.TrimSuffix(name, publicKeyExt), key)
+		case strings.HasSuffix(name, privateKeyExt):
+			kid := strings.TrimSuffix(name, privateKeyExt)
+			if kid == signingKid {
+				continue
+			}
+			key, err := readPrivateKey(path)
+			if err != nil {
+				return nil, err
+			}
+			ks.AddVerificationKey(kid, key.Public().(ed25519.PublicKey))
+		}
+	}
+
+	if legacySecret != "" && time.Now().Before(legacyUntil) {
+		ks.AllowLegacySecret(legacySecret, legacyUntil)
+	}
+
+	return ks, nil
+}
+
+// AddVerificationKey accepts tokens signed by the key, it never signs new ones
+func (k *KeySet) AddVerificationKey(kid string, key ed25519.PublicKey) {
+	// The signing key always wins over a public file left behind with the same kid
+	if kid == k.signingKid {
+		return
+	}
+	k.keys[kid] = key
+}
+
+// AllowLegacySecret accepts HS256 refresh tokens signed with the shared secret and issued before until,
+// so the sessions started before the signing keys migrate on their next refresh. Other services
+// may hold the secret, so it never verifies any other kind of token
+func (k *KeySet) AllowLegacySecret(secret string, until time.Time) {
+	k.legacySecret = []byte(secret)
+	k.legacyUntil = until
+}
+
+func (k *KeySet) sign(claims jwt.Claims) (string, error) {
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	jwtToken.Header["kid"] = k.signingKid
+
+	token, err := jwtToken.SignedString(k.signingKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return token, nil
+}
+
+// parse verifies the token with the keys of the set, and with the legacy secret only when allowLegacy
+func (k *KeySet) parse(v string, claims jwt.Claims, allowLegacy bool) (*jwt.Token, error) {
+	keyFunc := func(token *jwt.Token) (any, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodEd25519:
+			kid, _ := token.Header["kid"].(string)
+			key, ok := k.keys[kid]
+			if !ok {
+				return nil, fmt.Errorf("unknown token key id")
+			}
+			return key, nil
+		case *jwt.SigningMethodHMAC:
+			if !allowLegacy || len(k.legacySecret) == 0 || token.Method != jwt.SigningMethodHS256 {
+				return nil, fmt.Errorf("invalid token signing method")
+			}
+			if !time.Now().Before(k.legacyUntil) {
+				return nil, fmt.Errorf("legacy tokens are no longer accepted")
+			}
+			issued, err := token.Claims.GetIssuedAt()
+			if err != nil || issued == nil || !issued.Before(k.legacyUntil) {
+				return nil, fmt.Errorf("legacy token issued after the cutoff")
+			}
+			return k.legacySecret, nil
+		default:
+			return nil, fmt.Errorf("invalid token signing method")
+		}
+	}
+
+	// Every token we issue expires, one without expiry was not issued by us
+	return jwt.ParseWithClaims(v, claims, keyFunc, jwt.WithExpirationRequired())
+}
+
+// JWK is the public part of a key, as described in RFC 8037
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, so other services can verify tokens on their own
+func (k *KeySet) JWKS() JWKS {
+	kids := make([]string, 0, len(k.keys))
+	for kid := range k.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JWKS{Keys: make([]JWK, 0, len(kids))}
+	for _, kid := range kids {
+		set.Keys = append(set.Keys, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(k.keys[kid]),
+			KeyID:     kid,
+			Use:       "sig",
+			Algorithm: jwt.SigningMethodEdDSA.Alg(),
+		})
+	}
+
+	return set
+}
+
+func readPrivateKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+	}
+
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key %s is not an ed25519 key", path)
+	}
+
+	return private, nil
+}
+
+func readPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an ed25519 key", path)
+	}
+
+	return public, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+
+	return block, nil
+}
@@ -3,23 +3,34 @@ package token
 import (
 	"fmt"
 	"strings"
-
-	"github.com/golang-jwt/jwt"
 )
 
-func Verify(secretKey string, v string) (*AccountClaims, error) {
+func Verify(keys *KeySet, v string) (*AccountClaims, error) {
 	if strings.TrimSpace(v) == "" {
 		return nil, fmt.Errorf("invalid token")
 	}
 
-	keyFunc := func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("invalid token signing method")
-		}
-		return []byte(secretKey), nil
+	token, err := keys.parse(v, &AccountClaims{}, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*AccountClaims)
+	if !ok {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+
+	return claims, nil
+}
+
+// VerifyRefresh parses a refresh token. Unlike Verify, it also accepts the HS256 tokens signed
+// with the legacy secret, a forged one is useless as it must match the refresh token of a session
+func VerifyRefresh(keys *KeySet, v string) (*AccountClaims, error) {
+	if strings.TrimSpace(v) == "" {
+		return nil, fmt.Errorf("invalid token")
 	}
 
-	token, err := jwt.ParseWithClaims(v, &AccountClaims{}, keyFunc)
+	token, err := keys.parse(v, &AccountClaims{}, true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
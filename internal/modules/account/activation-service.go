@@ -0,0 +1,116 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/mailer"
+	"github.com/bernardinorafael/internal/modules/account/onetime"
+	"github.com/bernardinorafael/pkg/crypto"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+func (s svc) Activate(ctx context.Context, token string) error {
+	errInvalidLink := NewForbiddenError("activation link is invalid or expired", ExpiredLink, nil)
+
+	record, err := s.activationRepo.FindByTokenHash(ctx, crypto.HashToken(token))
+	if err != nil {
+		return NewBadRequestError("error on find activation", err)
+	}
+	if record == nil {
+		return errInvalidLink
+	}
+
+	act := onetime.NewFromDatabase(onetime.Activation, *record)
+	if !act.IsUsable() {
+		return errInvalidLink
+	}
+
+	found, err := s.repo.FindByUserID(ctx, act.UserID())
+	if err != nil {
+		return NewBadRequestError("error on get account by user id", err)
+	}
+	if found == nil {
+		return NewNotFoundError("account not found", nil)
+	}
+
+	acc, err := NewFromDatabase(*found)
+	if err != nil {
+		return NewBadRequestError("error on create account entity", err)
+	}
+	acc.Activate()
+
+	// Consumed before activating, so two requests racing with the same link can't both use it
+	if err := s.activationRepo.Consume(ctx, act.ID()); err != nil {
+		if errors.Is(err, onetime.ErrAlreadyConsumed) {
+			return errInvalidLink
+		}
+		return NewBadRequestError("error on consume activation", err)
+	}
+
+	if err := s.repo.Activate(ctx, acc.Store()); err != nil {
+		return NewBadRequestError("error on activate account", err)
+	}
+
+	s.log.Infow(ctx, "account activated", logger.String("account_id", acc.ID()))
+
+	return nil
+}
+
+func (s svc) ResendActivation(ctx context.Context, username string) error {
+	acc, err := s.repo.FindByUsername(ctx, username)
+	if err != nil {
+		return NewBadRequestError("error on find account by username", err)
+	}
+	// Unknown and already active accounts are ignored to not disclose them
+	if acc == nil || acc.ID == "" || acc.IsActive {
+		s.log.Info(ctx, "activation resend ignored")
+		return nil
+	}
+
+	latest, err := s.activationRepo.FindLatestByAccountID(ctx, acc.ID)
+	if err != nil {
+		return NewBadRequestError("error on find latest activation", err)
+	}
+	if latest != nil && onetime.NewFromDatabase(onetime.Activation, *latest).InCooldown() {
+		return NewForbiddenError("activation email sent recently, try again later", MaxLimitResourceReached, nil)
+	}
+
+	return s.sendActivation(ctx, acc.ID, acc.User.ID, acc.User.EmailAddress)
+}
+
+// sendActivation invalidates any pending activation of the account and emails a new one
+func (s svc) sendActivation(ctx context.Context, accountId, userId, email string) error {
+	if err := s.activationRepo.InvalidateAllByAccountID(ctx, accountId); err != nil {
+		return NewBadRequestError("error on invalidate previous activations", err)
+	}
+
+	act, token, err := onetime.New(onetime.Activation, accountId, userId)
+	if err != nil {
+		return NewBadRequestError("error on generate activation token", err)
+	}
+
+	if err := s.activationRepo.Insert(ctx, act.Store()); err != nil {
+		s.log.Errorw(ctx, "error on insert activation", logger.Err(err))
+		return NewBadRequestError("error on insert activation", err)
+	}
+
+	go func() {
+		params := mailer.SendParams{
+			From:    mailer.NotificationSender,
+			To:      email,
+			Subject: "Ative sua conta",
+			File:    "activate_account.html",
+			Data: map[string]any{
+				"Link": fmt.Sprintf("%s/activate?token=%s", s.frontEndURL, token),
+			},
+		}
+		if err := s.mailer.Send(params); err != nil {
+			s.log.Errorw(ctx, "error on send activation email", logger.Err(err))
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/mailer"
+	"github.com/bernardinorafael/internal/modules/account/alert"
+	"github.com/bernardinorafael/internal/modules/account/session"
+	"github.com/bernardinorafael/pkg/crypto"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+// Devices and networks of sessions older than this are forgotten, signing in from them alerts again
+const knownDeviceWindow = time.Hour * 24 * 90
+
+// isKnownDevice reports whether a recent session of the user came from the same device
+// on the same network. With no sessions to compare to, such as on the first sign-in,
+// every device is known. Failures are logged and treated as known, so they don't alert
+func (s svc) isKnownDevice(ctx context.Context, username string, device session.Device, ip string) bool {
+	records, err := s.sessionRepo.FindOwnSinceByUsername(ctx, username, time.Now().Add(-knownDeviceWindow))
+	if err != nil {
+		s.log.Errorw(ctx, "error on find known devices", logger.Err(err))
+		return true
+	}
+	if len(records) == 0 {
+		return true
+	}
+
+	for _, r := range records {
+		known := session.NewFromDatabase(r).Device()
+		if known.SameAs(device) && network(r.IP) == network(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sendSignInAlert emails the user about a sign-in from a new device, with a link to deny it
+// Signing in must not fail because of it, so errors are only logged
+func (s svc) sendSignInAlert(ctx context.Context, account *EntityWithUser, sessionId string, device session.Device, ip string) {
+	newAlert, token, err := alert.New(account.ID, sessionId)
+	if err != nil {
+		s.log.Errorw(ctx, "error on generate sign-in alert token", logger.Err(err))
+		return
+	}
+
+	if err := s.alertRepo.Insert(ctx, newAlert.Store()); err != nil {
+		s.log.Errorw(ctx, "error on insert sign-in alert", logger.Err(err))
+		return
+	}
+
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+
+	email := account.User.EmailAddress
+	go func() {
+		params := mailer.SendParams{
+			From:    mailer.NotificationSender,
+			To:      email,
+			Subject: "Novo acesso à sua conta",
+			File:    "new_sign_in.html",
+			Data: map[string]any{
+				"Browser": strings.TrimSpace(device.Browser + " " + device.BrowserVersion),
+				"OS":      device.OS,
+				"IP":      ip,
+				"Time":    newAlert.Created().UTC().Format("02/01/2006 15:04 (MST)"),
+				"Link":    fmt.Sprintf("%s/sign-in-alert?token=%s", s.frontEndURL, token),
+			},
+		}
+		if err := s.mailer.Send(params); err != nil {
+			s.log.Errorw(ctx, "error on send sign-in alert email", logger.Err(err))
+		}
+	}()
+}
+
+// DenySignIn revokes every session of the user a sign-in alert was sent to and forces a password reset,
+// the password is replaced by a random one and a reset link is emailed to the user
+func (s svc) DenySignIn(ctx context.Context, token string) error {
+	errInvalidLink := NewForbiddenError("link is invalid or expired", ExpiredLink, nil)
+
+	record, err := s.alertRepo.FindByTokenHash(ctx, crypto.HashToken(token))
+	if err != nil {
+		return NewBadRequestError("error on find sign-in alert", err)
+	}
+	if record == nil {
+		return errInvalidLink
+	}
+
+	signIn := alert.NewFromDatabase(*record)
+	if !signIn.IsUsable() {
+		return errInvalidLink
+	}
+
+	err = s.alertRepo.Consume(ctx, signIn.ID())
+	if err != nil {
+		if errors.Is(err, alert.ErrAlreadyConsumed) {
+			return errInvalidLink
+		}
+		return NewBadRequestError("error on consume sign-in alert", err)
+	}
+
+	account, err := s.repo.FindByID(ctx, signIn.AccountID())
+	if err != nil {
+		return NewBadRequestError("error on find account by id", err)
+	}
+	if account == nil || account.ID == "" {
+		return NewNotFoundError("account not found", nil)
+	}
+
+	// Whoever signed in may have opened other sessions since, none of them can be told apart
+	if err := s.sessionRepo.RevokeAll(ctx, account.User.Username); err != nil {
+		return NewBadRequestError("error on revoke sessions", err)
+	}
+
+	found, err := s.repo.FindByUserID(ctx, account.User.ID)
+	if err != nil {
+		return NewBadRequestError("error on get account by user id", err)
+	}
+	if found == nil {
+		return NewNotFoundError("account not found", nil)
+	}
+
+	acc, err := NewFromDatabase(*found)
+	if err != nil {
+		return NewBadRequestError("error on create account entity", err)
+	}
+
+	policy, err := s.passwordPolicy(ctx, account.User.ID)
+	if err != nil {
+		return err
+	}
+
+	// The password may be known to whoever signed in, it must not work anymore
+	replaced := acc.password
+	if err := acc.ScramblePassword(); err != nil {
+		return NewBadRequestError("error on replace account password", err)
+	}
+
+	if err := s.repo.Update(ctx, acc.Store()); err != nil {
+		return NewBadRequestError("error on updating account password", err)
+	}
+	s.recordPassword(ctx, acc.ID(), replaced, policy)
+
+	s.log.Warnw(
+		ctx,
+		"sign-in denied by the user",
+		logger.String("account_id", acc.ID()),
+		logger.String("session_id", signIn.SessionID()),
+	)
+
+	// Sent right away, the user is locked out until they set a new password
+	// so neither the cooldown nor the channel availability may hold it back
+	return s.sendPasswordReset(ctx, account, RecoveryChannelEmail)
+}
+
+// network returns the network an address belongs to, so another address handed
+// out by the same provider isn't taken as a new location. Ports are ignored
+func network(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(64, 128)).String()
+}
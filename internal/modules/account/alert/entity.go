@@ -0,0 +1,85 @@
+package alert
+
+import (
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/pkg/crypto"
+)
+
+const (
+	TokenSize = 32
+	// TokenTTL is how long the sign-in can be denied from the email, the link is one-click
+	// and must work whenever the user gets to read it
+	TokenTTL = time.Hour * 24 * 7
+)
+
+type alert struct {
+	id         string
+	accountId  string
+	sessionId  string
+	tokenHash  string
+	isConsumed bool
+	created    time.Time
+	expires    time.Time
+}
+
+func NewFromDatabase(entity Entity) *alert {
+	return &alert{
+		id:         entity.ID,
+		accountId:  entity.AccountID,
+		sessionId:  entity.SessionID,
+		tokenHash:  entity.TokenHash,
+		isConsumed: entity.IsConsumed,
+		created:    entity.Created,
+		expires:    entity.Expires,
+	}
+}
+
+// New creates the alert of a sign-in from an unknown device and returns it along
+// with the plain token, which denies the sign-in. Only the token hash is kept
+func New(accountId, sessionId string) (*alert, string, error) {
+	token, err := crypto.GenerateToken(TokenSize)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &alert{
+		id:         util.GenID("sia"),
+		accountId:  accountId,
+		sessionId:  sessionId,
+		tokenHash:  crypto.HashToken(token),
+		isConsumed: false,
+		created:    time.Now(),
+		expires:    time.Now().Add(TokenTTL),
+	}, token, nil
+}
+
+func (a *alert) IsExpired() bool {
+	return time.Now().After(a.expires)
+}
+
+// IsUsable reports whether the sign-in can still be denied with the alert
+func (a *alert) IsUsable() bool {
+	return !a.isConsumed && !a.IsExpired()
+}
+
+func (a *alert) Store() Entity {
+	return Entity{
+		ID:         a.ID(),
+		AccountID:  a.AccountID(),
+		SessionID:  a.SessionID(),
+		TokenHash:  a.TokenHash(),
+		IsConsumed: a.IsConsumed(),
+		Created:    a.Created(),
+		Expires:    a.Expires(),
+	}
+}
+
+func (a *alert) ID() string         { return a.id }
+func (a *alert) AccountID() string  { return a.accountId }
+func (a *alert) SessionID() string  { return a.sessionId }
+func (a *alert) TokenHash() string  { return a.tokenHash }
+func (a *alert) IsConsumed() bool   { return a.isConsumed }
+func (a *alert) Created() time.Time { return a.created }
+func (a *alert) Expires() time.Time { return a.expires }
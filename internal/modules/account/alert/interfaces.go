@@ -0,0 +1,16 @@
+package alert
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAlreadyConsumed is returned when a concurrent request consumed the alert first
+var ErrAlreadyConsumed = errors.New("sign-in alert already consumed")
+
+type RepositoryInterface interface {
+	Insert(ctx context.Context, entity Entity) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*Entity, error)
+	// Consume marks the alert as consumed, failing with ErrAlreadyConsumed if it already was
+	Consume(ctx context.Context, alertId string) error
+}
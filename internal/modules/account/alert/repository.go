@@ -0,0 +1,98 @@
+package alert
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) RepositoryInterface {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			INSERT INTO sign_in_alerts (
+				id,
+				account_id,
+				session_id,
+				token_hash,
+				is_consumed,
+				created,
+				expires
+			) VALUES (
+				:id,
+				:account_id,
+				:session_id,
+				:token_hash,
+				:is_consumed,
+				:created,
+				:expires
+			)
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on insert sign-in alert: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) FindByTokenHash(ctx context.Context, tokenHash string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(
+		ctx,
+		&entity,
+		"SELECT * FROM sign_in_alerts WHERE token_hash = $1",
+		tokenHash,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find sign-in alert by token: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) Consume(ctx context.Context, alertId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE sign_in_alerts SET is_consumed = true WHERE id = $1 AND is_consumed = false",
+		alertId,
+	)
+	if err != nil {
+		return fmt.Errorf("error on consume sign-in alert: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error on consume sign-in alert: %w", err)
+	}
+	if affected == 0 {
+		return ErrAlreadyConsumed
+	}
+
+	return nil
+}
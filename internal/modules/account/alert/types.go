@@ -0,0 +1,13 @@
+package alert
+
+import "time"
+
+type Entity struct {
+	ID         string    `json:"id" db:"id"`
+	AccountID  string    `json:"account_id" db:"account_id"`
+	SessionID  string    `json:"session_id" db:"session_id"`
+	TokenHash  string    `json:"-" db:"token_hash"`
+	IsConsumed bool      `json:"is_consumed" db:"is_consumed"`
+	Created    time.Time `json:"created" db:"created"`
+	Expires    time.Time `json:"expires" db:"expires"`
+}
@@ -0,0 +1,147 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/modules/account/attempt"
+	"github.com/bernardinorafael/internal/modules/user"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+var (
+	errLockedAccount = NewForbiddenError("account is temporarily locked", LockedResource, nil)
+)
+
+// checkThrottle refuses the attempt while the username or IP must wait
+// after their recent failures
+func (s svc) checkThrottle(ctx context.Context, username, ip string) error {
+	since := time.Now().Add(-attempt.Window)
+
+	byIP, err := s.attemptRepo.SummaryByIP(ctx, attempt.Host(ip), since)
+	if err != nil {
+		return NewBadRequestError("error on check login attempts", err)
+	}
+	if byIP.Failures >= attempt.MaxFailuresPerIP {
+		s.log.Warnw(ctx, "login blocked for ip", logger.String("ip", ip))
+		return NewTooManyRequestsError("too many failed attempts, try again later", time.Until(byIP.Last.Add(attempt.Window)), nil)
+	}
+
+	byUsername, err := s.attemptRepo.SummaryByUsername(ctx, username, since)
+	if err != nil {
+		return NewBadRequestError("error on check login attempts", err)
+	}
+
+	wait := max(byIP.RetryAfter(), byUsername.RetryAfter())
+	if wait > 0 {
+		return NewTooManyRequestsError("too many failed attempts, try again later", wait, nil)
+	}
+
+	return nil
+}
+
+// registerFailure records a failed attempt and locks the user once it reaches
+// the limit. It returns the error to be reported to the client
+func (s svc) registerFailure(ctx context.Context, account *EntityWithUser, username, ip string, cause error) error {
+	err := s.attemptRepo.Insert(ctx, attempt.New(username, ip).Store())
+	if err != nil {
+		s.log.Errorw(ctx, "error on insert login attempt", logger.Err(err))
+		return cause
+	}
+
+	// Attempts older than the window no longer count, prune them along the way
+	err = s.attemptRepo.DeleteBefore(ctx, time.Now().Add(-attempt.Window))
+	if err != nil {
+		s.log.Errorw(ctx, "error on prune login attempts", logger.Err(err))
+	}
+
+	// Unknown usernames are only tracked, and a user already locked keeps getting the
+	// same error as anyone else so the lock isn't disclosed
+	if account == nil || account.ID == "" || isLocked(account.User) {
+		return cause
+	}
+
+	summary, err := s.attemptRepo.SummaryByUsername(ctx, username, time.Now().Add(-attempt.Window))
+	if err != nil {
+		s.log.Errorw(ctx, "error on check login attempts", logger.Err(err))
+		return cause
+	}
+	if summary.Failures < attempt.MaxFailuresPerUsername {
+		return cause
+	}
+
+	u, err := user.NewFromEntity(account.User)
+	if err != nil {
+		s.log.Errorw(ctx, "error on init user entity", logger.Err(err))
+		return cause
+	}
+	u.LockUntil(time.Now().Add(attempt.LockDuration))
+
+	err = s.userRepo.Update(ctx, u.Store())
+	if err != nil {
+		s.log.Errorw(ctx, "error on lock user", logger.Err(err))
+		return cause
+	}
+
+	s.log.Warnw(
+		ctx,
+		"user temporarily locked after failed login attempts",
+		logger.String("user_id", u.ID()),
+		logger.String("ip", ip),
+	)
+
+	return errLockedAccount
+}
+
+// clearFailures resets the failures of a user that signed in, lifting an expired temporary lock
+func (s svc) clearFailures(ctx context.Context, account *EntityWithUser) {
+	err := s.attemptRepo.DeleteByUsername(ctx, account.User.Username)
+	if err != nil {
+		s.log.Errorw(ctx, "error on delete login attempts", logger.Err(err))
+	}
+
+	if !account.User.Locked {
+		return
+	}
+
+	u, err := user.NewFromEntity(account.User)
+	if err != nil {
+		s.log.Errorw(ctx, "error on init user entity", logger.Err(err))
+		return
+	}
+	if err = u.Unlock(); err != nil {
+		return
+	}
+
+	err = s.userRepo.Update(ctx, u.Store())
+	if err != nil {
+		s.log.Errorw(ctx, "error on unlock user", logger.Err(err))
+	}
+}
+
+// checkBan refuses banned users, telling them why and until when so they can appeal
+// Temporary bans are ignored once expired
+func checkBan(entity user.Entity) error {
+	if !entity.Banned || (entity.BannedUntil != nil && !entity.BannedUntil.After(time.Now())) {
+		return nil
+	}
+
+	err := NewForbiddenError("account is banned", DisabledAccount, nil)
+	if entity.BanReason != nil {
+		err.AddField("reason", *entity.BanReason)
+	}
+	if entity.BannedUntil != nil {
+		err.AddField("banned_until", entity.BannedUntil.Format(time.RFC3339))
+	}
+
+	return err
+}
+
+func isLocked(entity user.Entity) bool {
+	u, err := user.NewFromEntity(entity)
+	if err != nil {
+		return entity.Locked
+	}
+	return u.IsLocked()
+}
@@ -0,0 +1,106 @@
+package attempt
+
+import (
+	"net"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+)
+
+const (
+	// Window is how long a failed attempt counts towards the limits below
+	Window = time.Minute * 15
+	// MaxFailuresPerUsername failures within the window lock the account
+	MaxFailuresPerUsername = 5
+	// MaxFailuresPerIP failures within the window block the IP until they expire
+	MaxFailuresPerIP = 50
+	// LockDuration is how long an account stays locked once the limit is reached
+	LockDuration = time.Minute * 15
+
+	// Failures allowed before delays kick in
+	freeFailures = 2
+	baseDelay    = time.Second
+	maxDelay     = time.Second * 30
+)
+
+type attempt struct {
+	id       string
+	username string
+	ip       string
+	created  time.Time
+}
+
+func NewFromDatabase(entity Entity) *attempt {
+	return &attempt{
+		id:       entity.ID,
+		username: entity.Username,
+		ip:       entity.IP,
+		created:  entity.Created,
+	}
+}
+
+// New records a failed sign in attempt
+func New(username, ip string) *attempt {
+	return &attempt{
+		id:       util.GenID("att"),
+		username: username,
+		ip:       Host(ip),
+		created:  time.Now(),
+	}
+}
+
+func (a *attempt) Store() Entity {
+	return Entity{
+		ID:       a.ID(),
+		Username: a.Username(),
+		IP:       a.IP(),
+		Created:  a.Created(),
+	}
+}
+
+func (a *attempt) ID() string         { return a.id }
+func (a *attempt) Username() string   { return a.username }
+func (a *attempt) IP() string         { return a.ip }
+func (a *attempt) Created() time.Time { return a.created }
+
+// Delay returns how long a client must wait after its last failure before
+// trying again, doubling with every failure past the free ones
+func Delay(failures int) time.Duration {
+	if failures <= freeFailures {
+		return 0
+	}
+
+	delay := baseDelay
+	for i := freeFailures + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+
+	return delay
+}
+
+// RetryAfter returns how long the client must still wait, zero if it may try now
+func (s Summary) RetryAfter() time.Duration {
+	if s.Last == nil {
+		return 0
+	}
+
+	wait := time.Until(s.Last.Add(Delay(s.Failures)))
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
+
+// Host strips the port from a remote address, so that every connection
+// of a client counts towards the same IP
+func Host(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,15 @@
+package attempt
+
+import (
+	"context"
+	"time"
+)
+
+type RepositoryInterface interface {
+	Insert(ctx context.Context, entity Entity) error
+	SummaryByUsername(ctx context.Context, username string, since time.Time) (*Summary, error)
+	SummaryByIP(ctx context.Context, ip string, since time.Time) (*Summary, error)
+	DeleteByUsername(ctx context.Context, username string) error
+	// DeleteBefore prunes attempts that no longer count towards any window
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
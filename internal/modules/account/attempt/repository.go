@@ -0,0 +1,95 @@
+package attempt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) RepositoryInterface {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		"INSERT INTO login_attempts (id, username, ip, created) VALUES (:id, :username, :ip, :created)",
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on insert login attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) SummaryByUsername(ctx context.Context, username string, since time.Time) (*Summary, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var summary Summary
+	err := r.db.GetContext(
+		ctx,
+		&summary,
+		"SELECT COUNT(*) AS failures, MAX(created) AS last FROM login_attempts WHERE username = $1 AND created > $2",
+		username,
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error on summarize login attempts by username: %w", err)
+	}
+
+	return &summary, nil
+}
+
+func (r repo) SummaryByIP(ctx context.Context, ip string, since time.Time) (*Summary, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var summary Summary
+	err := r.db.GetContext(
+		ctx,
+		&summary,
+		"SELECT COUNT(*) AS failures, MAX(created) AS last FROM login_attempts WHERE ip = $1 AND created > $2",
+		ip,
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error on summarize login attempts by ip: %w", err)
+	}
+
+	return &summary, nil
+}
+
+func (r repo) DeleteByUsername(ctx context.Context, username string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE username = $1", username)
+	if err != nil {
+		return fmt.Errorf("error on delete login attempts: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) DeleteBefore(ctx context.Context, before time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE created < $1", before)
+	if err != nil {
+		return fmt.Errorf("error on prune login attempts: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package attempt
+
+import "time"
+
+type Entity struct {
+	ID       string    `json:"id" db:"id"`
+	Username string    `json:"username" db:"username"`
+	IP       string    `json:"ip" db:"ip"`
+	Created  time.Time `json:"created" db:"created"`
+}
+
+// Summary aggregates the failed attempts of a username or IP within a window
+type Summary struct {
+	Failures int        `json:"failures" db:"failures"`
+	Last     *time.Time `json:"last" db:"last"`
+}
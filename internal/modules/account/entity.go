@@ -3,14 +3,10 @@ package account
 import (
 	"errors"
 	"time"
-	"unicode"
 
 	"github.com/bernardinorafael/internal/_shared/util"
 	"github.com/bernardinorafael/internal/modules/user"
-)
-
-const (
-	minPasswordLength = 6
+	"github.com/bernardinorafael/pkg/crypto"
 )
 
 var (
@@ -18,23 +14,25 @@ var (
 )
 
 type account struct {
-	id       string
-	userId   string
-	password string
-	isActive bool
-	created  time.Time
-	updated  time.Time
+	id              string
+	userId          string
+	password        string
+	passwordChanged time.Time
+	isActive        bool
+	created         time.Time
+	updated         time.Time
 }
 
 // NewFromDatabase creates a new account from an entity
 func NewFromDatabase(acc Entity) (*account, error) {
 	account := &account{
-		id:       acc.ID,
-		userId:   acc.UserID,
-		password: acc.Password,
-		created:  acc.Created,
-		isActive: acc.IsActive,
-		updated:  acc.Updated,
+		id:              acc.ID,
+		userId:          acc.UserID,
+		password:        acc.Password,
+		passwordChanged: acc.PasswordChanged,
+		created:         acc.Created,
+		isActive:        acc.IsActive,
+		updated:         acc.Updated,
 	}
 
 	if err := account.validate(); err != nil {
@@ -44,15 +42,26 @@ func NewFromDatabase(acc Entity) (*account, error) {
 	return account, nil
 }
 
-// NewAccount creates a new account from scratch
-func NewAccount(userId, password string) (*account, error) {
+// NewAccount creates a new inactive account from scratch
+// The password must be in plain text, it is validated against the policy and encrypted here
+func NewAccount(userId, password string, policy PasswordPolicy) (*account, error) {
+	if err := policy.Validate(password); err != nil {
+		return nil, err
+	}
+
+	hashed, err := crypto.HashPassword(password)
+	if err != nil {
+		return nil, ErrInvalidPassword
+	}
+
 	account := &account{
-		id:       util.GenID("acc"),
-		userId:   userId,
-		password: password,
-		isActive: false,
-		created:  time.Now(),
-		updated:  time.Now(),
+		id:              util.GenID("acc"),
+		userId:          userId,
+		password:        hashed,
+		passwordChanged: time.Now(),
+		isActive:        false,
+		created:         time.Now(),
+		updated:         time.Now(),
 	}
 
 	if err := account.validate(); err != nil {
@@ -62,97 +71,143 @@ func NewAccount(userId, password string) (*account, error) {
 	return account, nil
 }
 
-func (a *account) ChangePassword(password string, ignorePasswordPolicy bool) error {
-	// Validate password if ignorePasswordPolicy is false
+// NewExternalAccount creates an active account for a user signing in through an
+// external identity provider. Its password is random and never disclosed, the
+// user can still set one through the password reset
+func NewExternalAccount(userId string) (*account, error) {
+	hashed, err := randomPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	account := &account{
+		id:              util.GenID("acc"),
+		userId:          userId,
+		password:        hashed,
+		passwordChanged: time.Now(),
+		isActive:        true,
+		created:         time.Now(),
+		updated:         time.Now(),
+	}
+
+	if err := account.validate(); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// ChangePassword validates the plain text password against the password policy,
+// unless ignorePasswordPolicy is true, and stores its encrypted version
+func (a *account) ChangePassword(password string, policy PasswordPolicy, ignorePasswordPolicy bool) error {
 	if !ignorePasswordPolicy {
-		err := a.validatePassword()
+		err := policy.Validate(password)
 		if err != nil {
 			return err
 		}
 	}
 
-	a.password = password
+	hashed, err := crypto.HashPassword(password)
+	if err != nil {
+		return ErrInvalidPassword
+	}
+
+	a.password = hashed
+	a.passwordChanged = time.Now()
 	a.updated = time.Now()
 
 	return nil
 }
 
-func (a *account) validate() error {
-	if err := a.validatePassword(); err != nil {
-		return err
+// RehashPassword encrypts the plain text password again with the current hashing
+// parameters. The password itself is the same, so it doesn't count as a change
+func (a *account) RehashPassword(password string) error {
+	hashed, err := crypto.HashPassword(password)
+	if err != nil {
+		return ErrInvalidPassword
 	}
 
+	a.password = hashed
+	a.updated = time.Now()
+
 	return nil
 }
 
-func (a *account) Activate() {
-	a.isActive = true
+// ScramblePassword replaces the password with a random one nobody knows, signing in
+// with a password is only possible again after a password reset
+func (a *account) ScramblePassword() error {
+	hashed, err := randomPassword()
+	if err != nil {
+		return err
+	}
+
+	a.password = hashed
+	a.passwordChanged = time.Now()
 	a.updated = time.Now()
+
+	return nil
 }
 
-func (a *account) validatePassword() error {
-	if a.password == "" {
-		return errors.New("password cannot be empty")
+// randomPassword returns the encrypted version of a random password that is never disclosed
+func randomPassword() (string, error) {
+	random, err := crypto.GenerateToken(32)
+	if err != nil {
+		return "", ErrInvalidPassword
 	}
 
-	if len(a.password) < minPasswordLength {
-		return errors.New("password must be at least 6 characters long")
+	hashed, err := crypto.HashPassword(random)
+	if err != nil {
+		return "", ErrInvalidPassword
 	}
 
-	var hasUpper, hasLower, hasNumber, hasSpecial bool
-	for _, char := range a.password {
-		switch {
-		case unicode.IsUpper(char):
-			hasUpper = true
-		case unicode.IsLower(char):
-			hasLower = true
-		case unicode.IsDigit(char):
-			hasNumber = true
-		case unicode.IsPunct(char) || unicode.IsSymbol(char):
-			hasSpecial = true
-		}
-	}
+	return hashed, nil
+}
 
-	if !hasUpper {
-		return errors.New("password must contain at least one uppercase letter")
-	}
-	if !hasLower {
-		return errors.New("password must contain at least one lowercase letter")
-	}
-	if !hasNumber {
-		return errors.New("password must contain at least one number")
+func (a *account) validate() error {
+	if a.userId == "" {
+		return errors.New("user id cannot be empty")
 	}
-	if !hasSpecial {
-		return errors.New("password must contain at least one special character")
+
+	if a.password == "" {
+		return errors.New("password cannot be empty")
 	}
 
 	return nil
 }
 
+func (a *account) Activate() {
+	a.isActive = true
+	a.updated = time.Now()
+}
+
 func (a *account) StoreWithUser(user user.Entity) EntityWithUser {
 	return EntityWithUser{
-		ID:       a.ID(),
-		Password: a.Password(),
-		User:     user,
-		Created:  a.Created(),
-		Updated:  a.Updated(),
+		ID:              a.ID(),
+		Password:        a.Password(),
+		PasswordChanged: a.PasswordChanged(),
+		IsActive:        a.IsActive(),
+		User:            user,
+		Created:         a.Created(),
+		Updated:         a.Updated(),
 	}
 }
 
 func (a *account) Store() Entity {
 	return Entity{
-		ID:       a.ID(),
-		UserID:   a.UserID(),
-		Password: a.Password(),
-		IsActive: a.IsActive(),
-		Created:  a.Created(),
-		Updated:  a.Updated(),
+		ID:              a.ID(),
+		UserID:          a.UserID(),
+		Password:        a.Password(),
+		PasswordChanged: a.PasswordChanged(),
+		IsActive:        a.IsActive(),
+		Created:         a.Created(),
+		Updated:         a.Updated(),
 	}
 }
 
-func (a *account) ID() string         { return a.id }
-func (a *account) UserID() string     { return a.userId }
-func (a *account) IsActive() bool     { return a.isActive }
-func (a *account) Password() string   { return a.password }
-func (a *account) Created() time.Time { return a.created }
-func (a *account) Updated() time.Time { return a.updated }
+func (a *account) ID() string                 { return a.id }
+func (a *account) UserID() string             { return a.userId }
+func (a *account) IsActive() bool             { return a.isActive }
+func (a *account) Password() string           { return a.password }
+func (a *account) PasswordChanged() time.Time { return a.passwordChanged }
+func (a *account) Created() time.Time         { return a.created }
+func (a *account) Updated() time.Time         { return a.updated }
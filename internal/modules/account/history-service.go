@@ -0,0 +1,53 @@
+package account
+
+import (
+	"context"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/modules/account/history"
+	"github.com/bernardinorafael/pkg/crypto"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+// checkPasswordHistory rejects a plain text password that matches the current
+// password of the account or any of the previous ones the policy keeps
+func (s svc) checkPasswordHistory(ctx context.Context, acc *account, password string, policy PasswordPolicy) error {
+	if policy.HistoryDepth <= 0 {
+		return nil
+	}
+
+	errReused := NewConflictError("password was used recently", InvalidPassword, nil, []Field{
+		{Field: "password.history", Msg: "password must not be one of the last used passwords"},
+	})
+
+	if crypto.PasswordMatches(password, acc.password) {
+		return errReused
+	}
+
+	records, err := s.historyRepo.FindLatestByAccountID(ctx, acc.ID(), policy.HistoryDepth)
+	if err != nil {
+		return NewBadRequestError("error on find password history", err)
+	}
+
+	for _, r := range records {
+		if crypto.PasswordMatches(password, r.PasswordHash) {
+			return errReused
+		}
+	}
+
+	return nil
+}
+
+// recordPassword keeps a replaced password in the history, pruning the entries
+// the policy no longer needs. The new password is already stored when it runs,
+// so a failure here is only logged
+func (s svc) recordPassword(ctx context.Context, accountId, passwordHash string, policy PasswordPolicy) {
+	if policy.HistoryDepth <= 0 {
+		return
+	}
+
+	entry := history.New(accountId, passwordHash)
+	if err := s.historyRepo.Insert(ctx, entry.Store(), policy.HistoryDepth); err != nil {
+		s.log.Errorw(ctx, "error on record password history", logger.Err(err))
+	}
+}
@@ -0,0 +1,47 @@
+package history
+
+import (
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+)
+
+type history struct {
+	id           string
+	accountId    string
+	passwordHash string
+	created      time.Time
+}
+
+func NewFromDatabase(entity Entity) *history {
+	return &history{
+		id:           entity.ID,
+		accountId:    entity.AccountID,
+		passwordHash: entity.PasswordHash,
+		created:      entity.Created,
+	}
+}
+
+// New records a password replaced on the account, it must be already encrypted
+func New(accountId, passwordHash string) *history {
+	return &history{
+		id:           util.GenID("pwh"),
+		accountId:    accountId,
+		passwordHash: passwordHash,
+		created:      time.Now(),
+	}
+}
+
+func (h *history) Store() Entity {
+	return Entity{
+		ID:           h.ID(),
+		AccountID:    h.AccountID(),
+		PasswordHash: h.PasswordHash(),
+		Created:      h.Created(),
+	}
+}
+
+func (h *history) ID() string           { return h.id }
+func (h *history) AccountID() string    { return h.accountId }
+func (h *history) PasswordHash() string { return h.passwordHash }
+func (h *history) Created() time.Time   { return h.created }
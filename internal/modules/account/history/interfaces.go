@@ -0,0 +1,9 @@
+package history
+
+import "context"
+
+type RepositoryInterface interface {
+	// Insert stores the entity and prunes the history of its account down to the keep newest entries
+	Insert(ctx context.Context, entity Entity, keep int) error
+	FindLatestByAccountID(ctx context.Context, accountId string, limit int) ([]Entity, error)
+}
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bernardinorafael/pkg/transaction"
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) RepositoryInterface {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, entity Entity, keep int) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return transaction.ExecTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(
+			ctx,
+			`
+				INSERT INTO password_history (id, account_id, password_hash, created)
+				VALUES (:id, :account_id, :password_hash, :created)
+			`,
+			entity,
+		)
+		if err != nil {
+			return fmt.Errorf("error on insert password history: %w", err)
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`
+				DELETE FROM password_history
+				WHERE account_id = $1
+				AND id NOT IN (
+					SELECT id FROM password_history
+					WHERE account_id = $1
+					ORDER BY created DESC
+					LIMIT $2
+				)
+			`,
+			entity.AccountID,
+			keep,
+		)
+		if err != nil {
+			return fmt.Errorf("error on prune password history: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r repo) FindLatestByAccountID(ctx context.Context, accountId string, limit int) ([]Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entities = []Entity{}
+	err := r.db.SelectContext(
+		ctx,
+		&entities,
+		"SELECT * FROM password_history WHERE account_id = $1 ORDER BY created DESC LIMIT $2",
+		accountId,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error on find password history: %w", err)
+	}
+
+	return entities, nil
+}
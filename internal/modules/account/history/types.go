@@ -0,0 +1,10 @@
+package history
+
+import "time"
+
+type Entity struct {
+	ID           string    `json:"id" db:"id"`
+	AccountID    string    `json:"account_id" db:"account_id"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	Created      time.Time `json:"created" db:"created"`
+}
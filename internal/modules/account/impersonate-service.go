@@ -0,0 +1,198 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/dto"
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/internal/modules/account/session"
+	"github.com/bernardinorafael/internal/modules/audit"
+	"github.com/bernardinorafael/internal/modules/permission"
+	"github.com/bernardinorafael/pkg/crypto"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+// Impersonation sessions can't be renewed, a new one must be started once it expires
+const impersonationDuration = time.Minute * 15
+
+func (s svc) Impersonate(ctx context.Context, input dto.Impersonate) (*dto.ImpersonationResponse, error) {
+	claims, err := s.impersonatorClaims(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return nil, NewValidationFieldError("a reason is required to impersonate a user", nil, nil)
+	}
+	if input.UserID == claims.UserID {
+		return nil, NewBadRequestError("can't impersonate yourself", nil)
+	}
+
+	record, err := s.repo.FindByUserID(ctx, input.UserID)
+	if err != nil {
+		return nil, NewBadRequestError("error on find account by user id", err)
+	}
+	if record == nil {
+		return nil, NewNotFoundError("account not found", nil)
+	}
+
+	account, err := s.repo.FindByID(ctx, record.ID)
+	if err != nil {
+		return nil, NewBadRequestError("error on find account by id", err)
+	}
+	if account == nil || account.ID == "" {
+		return nil, NewNotFoundError("account not found", nil)
+	}
+	if isLocked(account.User) {
+		return nil, errLockedAccount
+	}
+	if err := checkBan(account.User); err != nil {
+		return nil, err
+	}
+	if !account.IsActive {
+		return nil, NewBadRequestError("account is not active", nil)
+	}
+
+	// Impersonating another admin would grant their permissions to whoever impersonates them
+	isAdmin, err := s.permissionRepo.IsPlatformAdmin(ctx, account.User.ID)
+	if err != nil {
+		return nil, NewBadRequestError("error on find platform permissions by user id", err)
+	}
+	if isAdmin {
+		return nil, NewForbiddenError("platform admins can't be impersonated", ImpersonationForbidden, nil)
+	}
+
+	// The session is never renewed, its refresh token only fills the column and is not returned
+	refreshToken, err := crypto.GenerateToken(32)
+	if err != nil {
+		return nil, NewBadRequestError("error on generate refresh token", err)
+	}
+
+	newSession := session.NewImpersonation(
+		account.User.Username,
+		claims.UserID,
+		refreshToken,
+		session.ParseDevice(input.UserAgent),
+		input.IP,
+		time.Now().Add(impersonationDuration),
+	)
+
+	entry, err := audit.New(
+		audit.ActionImpersonationStarted,
+		claims.UserID,
+		token.PrincipalUser,
+		&account.User.ID,
+		fmt.Sprintf("session %s: %s", newSession.ID(), reason),
+		input.IP,
+		input.UserAgent,
+	)
+	if err != nil {
+		return nil, NewBadRequestError("error on create audit log", err)
+	}
+
+	// Recorded first, an impersonation that left no trace must not start
+	if err := s.auditRepo.Insert(ctx, entry.Store()); err != nil {
+		return nil, NewBadRequestError("error on insert audit log", err)
+	}
+
+	if err := s.sessionRepo.Insert(ctx, newSession.Store()); err != nil {
+		return nil, NewBadRequestError("error on insert session", err)
+	}
+
+	accessToken, accessClaims, err := token.GenerateImpersonation(
+		s.keys,
+		account.ID,
+		account.User.ID,
+		account.User.Username,
+		newSession.FamilyID(),
+		token.Actor{UserID: claims.UserID, Username: claims.Username},
+		impersonationDuration,
+	)
+	if err != nil {
+		return nil, NewBadRequestError("error on generate access token", err)
+	}
+
+	s.log.Warnw(
+		ctx,
+		"impersonation started",
+		logger.String("actor_id", claims.UserID),
+		logger.String("user_id", account.User.ID),
+		logger.String("session_id", newSession.ID()),
+	)
+
+	res := &dto.ImpersonationResponse{
+		SessionID:          newSession.ID(),
+		AccessToken:        accessToken,
+		AccessTokenExpires: accessClaims.ExpiresAt.Unix(),
+	}
+
+	return res, nil
+}
+
+func (s svc) GetAuditTrail(ctx context.Context, userId string) ([]audit.Entity, error) {
+	if _, err := s.impersonatorClaims(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.auditRepo.FindAllBySubjectID(ctx, userId)
+	if err != nil {
+		return nil, NewBadRequestError("error on find audit logs by user id", err)
+	}
+
+	return entries, nil
+}
+
+func (s svc) RecordImpersonatedRequest(ctx context.Context, claims *token.AccountClaims, method, path, ip, agent string) error {
+	entry, err := audit.New(
+		audit.ActionImpersonatedRequest,
+		claims.Actor.UserID,
+		token.PrincipalUser,
+		&claims.UserID,
+		method+" "+path,
+		ip,
+		agent,
+	)
+	if err != nil {
+		return NewBadRequestError("error on create audit log", err)
+	}
+
+	if err := s.auditRepo.Insert(ctx, entry.Store()); err != nil {
+		return NewBadRequestError("error on insert audit log", err)
+	}
+
+	return nil
+}
+
+// impersonatorClaims returns the claims of a platform admin signed in as themselves, allowed
+// to impersonate the target user. The permission is checked against their current grants,
+// not the ones they had when signing in
+func (s svc) impersonatorClaims(ctx context.Context, targetUserId string) (*token.AccountClaims, error) {
+	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
+	if !ok {
+		return nil, NewBadRequestError("user ID not found in context", nil)
+	}
+	if claims.IsService() || claims.TokenID != "" {
+		return nil, NewForbiddenError("impersonation requires a user session", MissingPermission, nil)
+	}
+	if claims.IsImpersonated() {
+		return nil, NewForbiddenError("not allowed while impersonating the user", ImpersonationForbidden, nil)
+	}
+
+	// Grants limited to an organization only reach its users, the others are reported as
+	// a missing permission so they can't be told apart
+	granted, err := s.permissionRepo.HasPlatformPermissionOver(ctx, claims.UserID, permission.KeyPlatformImpersonate, targetUserId)
+	if err != nil {
+		return nil, NewBadRequestError("error on find platform permissions by user id", err)
+	}
+	if !granted {
+		return nil, NewForbiddenError("missing permission "+permission.KeyPlatformImpersonate, MissingPermission, nil)
+	}
+
+	return claims, nil
+}
@@ -4,23 +4,81 @@ import (
 	"context"
 
 	"github.com/bernardinorafael/internal/_shared/dto"
+	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/internal/modules/account/pat"
+	"github.com/bernardinorafael/internal/modules/audit"
+	"github.com/bernardinorafael/internal/modules/org"
 )
 
 type RepositoryInterface interface {
 	Insert(ctx context.Context, acc EntityWithUser) error
+	// InsertForUser inserts an account for a user that already exists
+	InsertForUser(ctx context.Context, acc Entity) error
 	FindByID(ctx context.Context, accountId string) (*EntityWithUser, error)
 	FindByUserID(ctx context.Context, userId string) (*Entity, error)
 	FindByUsername(ctx context.Context, username string) (*EntityWithUser, error)
+	// FindByPrimaryEmail returns the account of the user whose verified primary email is the one given
+	FindByPrimaryEmail(ctx context.Context, email string) (*EntityWithUser, error)
+	// FindOrgByUserID returns the organization the user owns or is a member of, with its settings
+	FindOrgByUserID(ctx context.Context, userId string) (*org.EntityWithSettings, error)
 	Update(ctx context.Context, acc Entity) error
+	// Activate stores the activated account and verifies the primary email of its user,
+	// the activation link being sent there
+	Activate(ctx context.Context, acc Entity) error
 }
 
 type ServiceInterface interface {
-	Login(ctx context.Context, username, password, userAgent, ip string) (*dto.AccountResponse, error)
+	Register(ctx context.Context, dto dto.CreateAccount) (userId string, err error)
+	Activate(ctx context.Context, token string) error
+	ResendActivation(ctx context.Context, username string) error
+	// ForgotPassword sends a password reset link by email or, to a verified phone, by text message
+	ForgotPassword(ctx context.Context, input dto.ForgotPassword) error
+	// RequestMagicLink emails a single-use sign-in link to the verified primary email
+	RequestMagicLink(ctx context.Context, email string) error
+	// LoginMagicLink consumes a sign-in link, accounts with two-factor authentication get a challenge
+	LoginMagicLink(ctx context.Context, input dto.LoginMagicLink) (*dto.AccountResponse, *dto.MFAChallenge, error)
+	ResetPassword(ctx context.Context, token, password string) error
+	// DenySignIn revokes every session of the user a sign-in alert was sent to and forces a password reset
+	DenySignIn(ctx context.Context, token string) error
+	Login(ctx context.Context, input dto.Login) (*dto.AccountResponse, *dto.MFAChallenge, error)
+	LoginMFA(ctx context.Context, input dto.LoginMFA) (*dto.AccountResponse, error)
+	// SendMFACode texts a second factor code for the challenge to the verified phone of the user
+	SendMFACode(ctx context.Context, mfaToken string) error
+	EnrollMFA(ctx context.Context) (*dto.MFAEnrollment, error)
+	ConfirmMFA(ctx context.Context, code string) (recoveryCodes []string, err error)
+	DisableMFA(ctx context.Context, code string) error
+	// ProvisionAccount returns the account of the user, creating an active one when the user has none
+	ProvisionAccount(ctx context.Context, userId string) (accountId string, err error)
+	// IssueSession opens a session for an account authenticated elsewhere, such as with an SSO provider
+	// With requireMFA, accounts enrolled in two-factor authentication get a challenge instead, completed on LoginMFA
+	IssueSession(ctx context.Context, accountId, userAgent, ip string, requireMFA bool) (*dto.AccountResponse, *dto.MFAChallenge, error)
+	// IssueClientSession opens a session for an OAuth client, its tokens only carry the scope the user granted
+	IssueClientSession(ctx context.Context, accountId, clientId, scope, userAgent, ip string) (*dto.AccountResponse, error)
+	// Reauthenticate checks the password or second factor again, refreshing the auth time of the session
+	Reauthenticate(ctx context.Context, input dto.Reauthenticate) (*dto.ReauthenticationResponse, error)
 	Logout(ctx context.Context) error
-	RenewAccessToken(ctx context.Context, refreshToken string) (*dto.RenewAccessToken, error)
+	// RenewAccessToken rotates the refresh token of a session opened for the client, empty for the first-party apps
+	RenewAccessToken(ctx context.Context, refreshToken, clientId string) (*dto.RenewAccessToken, error)
 	GetSignedInAccount(ctx context.Context) (*EntityWithUser, error)
 	ChangePassword(ctx context.Context, userId string, old string, new string) error
-	GetAllSessions(ctx context.Context, username string) ([]*dto.SessionResponse, error)
+	// GetAllSessions lists the sessions of the user, marking the ones of the family given as current
+	GetAllSessions(ctx context.Context, username, familyId string) ([]*dto.SessionResponse, error)
 	RevokeSession(ctx context.Context, username, sessionId string) error
+	// RevokeOtherSessions revokes every session of the user but the ones of the family given
+	RevokeOtherSessions(ctx context.Context, username, familyId string) error
 	GetSession(ctx context.Context) (*dto.SessionResponse, error)
+	// CreatePersonalToken returns the stored token along with the plain one, which is never shown again
+	CreatePersonalToken(ctx context.Context, input dto.CreatePersonalToken) (*pat.Entity, string, error)
+	GetAllPersonalTokens(ctx context.Context) ([]pat.Entity, error)
+	RevokePersonalToken(ctx context.Context, tokenId string) error
+	// AuthenticateToken resolves a personal access token into the claims of its owner
+	AuthenticateToken(ctx context.Context, token string) (*token.AccountClaims, error)
+	// Impersonate opens a short-lived session of the user for a platform admin, every request made with it is audited
+	Impersonate(ctx context.Context, input dto.Impersonate) (*dto.ImpersonationResponse, error)
+	// RecordImpersonatedRequest adds a request made while impersonating the user to the audit trail
+	RecordImpersonatedRequest(ctx context.Context, claims *token.AccountClaims, method, path, ip, agent string) error
+	// GetAuditTrail returns the audit trail of the actions performed on behalf of the user
+	GetAuditTrail(ctx context.Context, userId string) ([]audit.Entity, error)
+	// JWKS returns the public keys tokens are verified with
+	JWKS() token.JWKS
 }
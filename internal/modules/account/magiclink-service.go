@@ -0,0 +1,141 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bernardinorafael/internal/_shared/dto"
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/mailer"
+	"github.com/bernardinorafael/internal/modules/account/magiclink"
+	"github.com/bernardinorafael/pkg/crypto"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+// RequestMagicLink emails a sign-in link to the account owning the email
+// Like ForgotPassword, it always succeeds from the caller's point of view, so it does
+// not disclose which emails exist, failures are only logged
+func (s svc) RequestMagicLink(ctx context.Context, email string) error {
+	acc, err := s.repo.FindByPrimaryEmail(ctx, email)
+	if err != nil {
+		s.log.Errorw(ctx, "error on find account by email", logger.Err(err))
+		return nil
+	}
+	if acc == nil || acc.ID == "" {
+		s.log.Info(ctx, "magic link requested for unknown email")
+		return nil
+	}
+	// The link could never be used, sending it would only tell the account exists
+	if !acc.IsActive || isLocked(acc.User) || checkBan(acc.User) != nil {
+		s.log.Infow(ctx, "magic link requested for unavailable account", logger.String("account_id", acc.ID))
+		return nil
+	}
+
+	latest, err := s.magicLinkRepo.FindLatestByAccountID(ctx, acc.ID)
+	if err != nil {
+		s.log.Errorw(ctx, "error on find latest magic link", logger.Err(err))
+		return nil
+	}
+	if latest != nil && magiclink.NewFromDatabase(*latest).InCooldown() {
+		s.log.Info(ctx, "magic link requested during cooldown")
+		return nil
+	}
+
+	// Only the latest link works, the ones sent before are of no use anymore
+	if err := s.magicLinkRepo.InvalidateAllByAccountID(ctx, acc.ID); err != nil {
+		s.log.Errorw(ctx, "error on invalidate previous magic links", logger.Err(err))
+		return nil
+	}
+
+	link, token, err := magiclink.New(acc.ID)
+	if err != nil {
+		s.log.Errorw(ctx, "error on generate magic link token", logger.Err(err))
+		return nil
+	}
+
+	if err := s.magicLinkRepo.Insert(ctx, link.Store()); err != nil {
+		s.log.Errorw(ctx, "error on insert magic link", logger.Err(err))
+		return nil
+	}
+
+	go func() {
+		params := mailer.SendParams{
+			From:    mailer.NotificationSender,
+			To:      email,
+			Subject: "Seu link de acesso",
+			File:    "magic_link.html",
+			Data: map[string]any{
+				"Link": fmt.Sprintf("%s/magic-link?token=%s", s.frontEndURL, token),
+			},
+		}
+		if err := s.mailer.Send(params); err != nil {
+			s.log.Errorw(ctx, "error on send magic link email", logger.Err(err))
+		}
+	}()
+
+	return nil
+}
+
+func (s svc) LoginMagicLink(ctx context.Context, input dto.LoginMagicLink) (*dto.AccountResponse, *dto.MFAChallenge, error) {
+	errInvalidLink := NewForbiddenError("sign-in link is invalid or expired", ExpiredLink, nil)
+
+	record, err := s.magicLinkRepo.FindByTokenHash(ctx, crypto.HashToken(input.Token))
+	if err != nil {
+		return nil, nil, NewBadRequestError("error on find magic link", err)
+	}
+	if record == nil {
+		return nil, nil, errInvalidLink
+	}
+
+	link := magiclink.NewFromDatabase(*record)
+	if !link.IsUsable() {
+		return nil, nil, errInvalidLink
+	}
+
+	account, err := s.repo.FindByID(ctx, link.AccountID())
+	if err != nil {
+		return nil, nil, NewBadRequestError("error on find account by id", err)
+	}
+	if account == nil || account.ID == "" {
+		return nil, nil, errInvalidLink
+	}
+	if isLocked(account.User) {
+		return nil, nil, errLockedAccount
+	}
+	if err := checkBan(account.User); err != nil {
+		return nil, nil, err
+	}
+	if !account.IsActive {
+		return nil, nil, NewBadRequestError("account is not active", nil)
+	}
+
+	// Checked before consuming, so the link survives a request that has to pick a session to evict
+	if err := s.enforceSessionLimit(ctx, account, input.EvictOldest); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.magicLinkRepo.Consume(ctx, link.ID()); err != nil {
+		if errors.Is(err, magiclink.ErrAlreadyConsumed) {
+			return nil, nil, errInvalidLink
+		}
+		return nil, nil, NewBadRequestError("error on consume magic link", err)
+	}
+
+	challenge, err := s.requireMFA(ctx, account.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if challenge != nil {
+		return nil, challenge, nil
+	}
+
+	payload, err := s.createSession(ctx, account, input.UserAgent, input.IP, input.EvictOldest, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.log.Infow(ctx, "signed in with magic link", logger.String("account_id", account.ID))
+
+	return payload, nil, nil
+}
@@ -0,0 +1,89 @@
+package magiclink
+
+import (
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/pkg/crypto"
+)
+
+const (
+	TokenSize       = 32
+	TokenTTL        = time.Minute * 15
+	RequestCooldown = time.Minute
+)
+
+type magicLink struct {
+	id         string
+	accountId  string
+	tokenHash  string
+	isConsumed bool
+	isValid    bool
+	created    time.Time
+	expires    time.Time
+}
+
+func NewFromDatabase(entity Entity) *magicLink {
+	return &magicLink{
+		id:         entity.ID,
+		accountId:  entity.AccountID,
+		tokenHash:  entity.TokenHash,
+		isConsumed: entity.IsConsumed,
+		isValid:    entity.IsValid,
+		created:    entity.Created,
+		expires:    entity.Expires,
+	}
+}
+
+// New creates a sign-in link and returns it along with the plain token
+// Only the token hash is kept, the plain token must be sent to the user
+func New(accountId string) (*magicLink, string, error) {
+	token, err := crypto.GenerateToken(TokenSize)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &magicLink{
+		id:         util.GenID("mlk"),
+		accountId:  accountId,
+		tokenHash:  crypto.HashToken(token),
+		isConsumed: false,
+		isValid:    true,
+		created:    time.Now(),
+		expires:    time.Now().Add(TokenTTL),
+	}, token, nil
+}
+
+func (m *magicLink) IsExpired() bool {
+	return time.Now().After(m.expires)
+}
+
+// IsUsable reports whether the link can still be used to sign in
+func (m *magicLink) IsUsable() bool {
+	return m.isValid && !m.isConsumed && !m.IsExpired()
+}
+
+// InCooldown reports whether the link was requested too recently to be requested again
+func (m *magicLink) InCooldown() bool {
+	return time.Since(m.created) < RequestCooldown
+}
+
+func (m *magicLink) Store() Entity {
+	return Entity{
+		ID:         m.ID(),
+		AccountID:  m.AccountID(),
+		TokenHash:  m.TokenHash(),
+		IsConsumed: m.IsConsumed(),
+		IsValid:    m.IsValid(),
+		Created:    m.Created(),
+		Expires:    m.Expires(),
+	}
+}
+
+func (m *magicLink) ID() string         { return m.id }
+func (m *magicLink) AccountID() string  { return m.accountId }
+func (m *magicLink) TokenHash() string  { return m.tokenHash }
+func (m *magicLink) IsConsumed() bool   { return m.isConsumed }
+func (m *magicLink) IsValid() bool      { return m.isValid }
+func (m *magicLink) Created() time.Time { return m.created }
+func (m *magicLink) Expires() time.Time { return m.expires }
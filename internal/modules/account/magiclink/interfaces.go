@@ -0,0 +1,18 @@
+package magiclink
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAlreadyConsumed is returned when a concurrent request consumed the link first
+var ErrAlreadyConsumed = errors.New("magic link already consumed")
+
+type RepositoryInterface interface {
+	Insert(ctx context.Context, entity Entity) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*Entity, error)
+	FindLatestByAccountID(ctx context.Context, accountId string) (*Entity, error)
+	// Consume marks the link as consumed, failing with ErrAlreadyConsumed if it no longer is usable
+	Consume(ctx context.Context, linkId string) error
+	InvalidateAllByAccountID(ctx context.Context, accountId string) error
+}
@@ -0,0 +1,139 @@
+package magiclink
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) RepositoryInterface {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			INSERT INTO magic_links (
+				id,
+				account_id,
+				token_hash,
+				is_consumed,
+				is_valid,
+				created,
+				expires
+			) VALUES (
+				:id,
+				:account_id,
+				:token_hash,
+				:is_consumed,
+				:is_valid,
+				:created,
+				:expires
+			)
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on insert magic link: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) FindByTokenHash(ctx context.Context, tokenHash string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(
+		ctx,
+		&entity,
+		"SELECT * FROM magic_links WHERE token_hash = $1",
+		tokenHash,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find magic link by token: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) FindLatestByAccountID(ctx context.Context, accountId string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(
+		ctx,
+		&entity,
+		"SELECT * FROM magic_links WHERE account_id = $1 ORDER BY created DESC LIMIT 1",
+		accountId,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find latest magic link: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) Consume(ctx context.Context, linkId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE magic_links
+			SET is_consumed = true, is_valid = false
+			WHERE id = $1 AND is_consumed = false AND is_valid = true
+		`,
+		linkId,
+	)
+	if err != nil {
+		return fmt.Errorf("error on consume magic link: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error on consume magic link: %w", err)
+	}
+	if affected == 0 {
+		return ErrAlreadyConsumed
+	}
+
+	return nil
+}
+
+func (r repo) InvalidateAllByAccountID(ctx context.Context, accountId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE magic_links SET is_valid = false WHERE account_id = $1 AND is_valid = true",
+		accountId,
+	)
+	if err != nil {
+		return fmt.Errorf("error on invalidate magic links: %w", err)
+	}
+
+	return nil
+}
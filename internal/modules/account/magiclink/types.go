@@ -0,0 +1,13 @@
+package magiclink
+
+import "time"
+
+type Entity struct {
+	ID         string    `json:"id" db:"id"`
+	AccountID  string    `json:"account_id" db:"account_id"`
+	TokenHash  string    `json:"-" db:"token_hash"`
+	IsConsumed bool      `json:"is_consumed" db:"is_consumed"`
+	IsValid    bool      `json:"is_valid" db:"is_valid"`
+	Created    time.Time `json:"created" db:"created"`
+	Expires    time.Time `json:"expires" db:"expires"`
+}
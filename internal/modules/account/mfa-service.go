@@ -0,0 +1,240 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/dto"
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/internal/modules/account/mfa"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+const mfaChallengeDuration = time.Minute * 5
+
+var (
+	errInvalidMFACode = NewConflictError("invalid verification code", InvalidCredentials, nil, nil)
+)
+
+func (s svc) EnrollMFA(ctx context.Context) (*dto.MFAEnrollment, error) {
+	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
+	if !ok {
+		return nil, NewBadRequestError("user ID not found in context", nil)
+	}
+
+	record, err := s.mfaRepo.FindByAccountID(ctx, claims.AccountID)
+	if err != nil {
+		return nil, NewBadRequestError("error on find mfa by account id", err)
+	}
+	if record != nil && record.IsEnabled {
+		return nil, NewConflictError("two-factor authentication is already enabled", ResourceAlreadyTaken, nil, nil)
+	}
+
+	// Enrolling again replaces a pending secret that was never confirmed
+	newMFA, err := mfa.New(claims.AccountID)
+	if err != nil {
+		return nil, NewBadRequestError("error on generate mfa secret", err)
+	}
+
+	err = s.mfaRepo.Upsert(ctx, newMFA.Store())
+	if err != nil {
+		// Enabled by a concurrent confirmation since the check above
+		if errors.Is(err, mfa.ErrAlreadyEnabled) {
+			return nil, NewConflictError("two-factor authentication is already enabled", ResourceAlreadyTaken, err, nil)
+		}
+		return nil, NewBadRequestError("error on store mfa", err)
+	}
+
+	return &dto.MFAEnrollment{
+		Secret: newMFA.Secret(),
+		URI:    newMFA.URI(claims.Username),
+	}, nil
+}
+
+// ConfirmMFA enables two-factor authentication once the first code generated
+// by the authenticator app is valid, returning the plain recovery codes
+func (s svc) ConfirmMFA(ctx context.Context, code string) ([]string, error) {
+	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
+	if !ok {
+		return nil, NewBadRequestError("user ID not found in context", nil)
+	}
+
+	record, err := s.mfaRepo.FindByAccountID(ctx, claims.AccountID)
+	if err != nil {
+		return nil, NewBadRequestError("error on find mfa by account id", err)
+	}
+	if record == nil {
+		return nil, NewNotFoundError("two-factor authentication enrollment not found", nil)
+	}
+	if record.IsEnabled {
+		return nil, NewConflictError("two-factor authentication is already enabled", ResourceAlreadyTaken, nil, nil)
+	}
+
+	current := mfa.NewFromDatabase(*record)
+	if !current.Verify(code) {
+		return nil, errInvalidMFACode
+	}
+	current.Enable()
+
+	codes, plainCodes, err := mfa.NewRecoveryCodes(claims.AccountID)
+	if err != nil {
+		return nil, NewBadRequestError("error on generate recovery codes", err)
+	}
+
+	err = s.mfaRepo.Enable(ctx, current.Store(), codes)
+	if err != nil {
+		return nil, NewBadRequestError("error on enable mfa", err)
+	}
+
+	s.log.Infow(ctx, "two-factor authentication enabled", logger.String("account_id", claims.AccountID))
+
+	return plainCodes, nil
+}
+
+// DisableMFA turns two-factor authentication off, requiring a current code or
+// a recovery code so a stolen access token alone can't do it
+func (s svc) DisableMFA(ctx context.Context, code string) error {
+	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
+	if !ok {
+		return NewBadRequestError("user ID not found in context", nil)
+	}
+
+	record, err := s.mfaRepo.FindByAccountID(ctx, claims.AccountID)
+	if err != nil {
+		return NewBadRequestError("error on find mfa by account id", err)
+	}
+	if record == nil || !record.IsEnabled {
+		return NewNotFoundError("two-factor authentication is not enabled", nil)
+	}
+
+	valid, err := s.verifySecondFactor(ctx, *record, claims.UserID, "", code)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errInvalidMFACode
+	}
+
+	err = s.mfaRepo.Disable(ctx, claims.AccountID)
+	if err != nil {
+		return NewBadRequestError("error on disable mfa", err)
+	}
+
+	s.log.Infow(ctx, "two-factor authentication disabled", logger.String("account_id", claims.AccountID))
+
+	return nil
+}
+
+func (s svc) LoginMFA(ctx context.Context, input dto.LoginMFA) (*dto.AccountResponse, error) {
+	challenge, err := token.VerifyChallenge(s.keys, input.MFAToken, token.PurposeMFA)
+	if err != nil {
+		return nil, NewUnauthorizedError("invalid or expired mfa token", err)
+	}
+
+	account, err := s.repo.FindByID(ctx, challenge.AccountID)
+	if err != nil {
+		return nil, NewBadRequestError("error on find account by id", err)
+	}
+	if account == nil || account.ID == "" {
+		return nil, NewNotFoundError("account not found", nil)
+	}
+	if !account.IsActive {
+		return nil, NewBadRequestError("account is not active", nil)
+	}
+	if isLocked(account.User) {
+		return nil, errLockedAccount
+	}
+	if err := checkBan(account.User); err != nil {
+		return nil, err
+	}
+
+	err = s.checkThrottle(ctx, account.User.Username, input.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := s.mfaRepo.FindByAccountID(ctx, account.ID)
+	if err != nil {
+		return nil, NewBadRequestError("error on find mfa by account id", err)
+	}
+	if record == nil || !record.IsEnabled {
+		return nil, NewUnauthorizedError("invalid or expired mfa token", nil)
+	}
+
+	valid, err := s.verifySecondFactor(ctx, *record, account.User.ID, challenge.ID, input.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, s.registerFailure(ctx, account, account.User.Username, input.IP, errInvalidMFACode)
+	}
+
+	s.clearFailures(ctx, account)
+
+	return s.createSession(ctx, account, input.UserAgent, input.IP, input.EvictOldest, nil)
+}
+
+// requireMFA reports whether the account must complete a second step to sign in,
+// returning the challenge to be exchanged on LoginMFA
+func (s svc) requireMFA(ctx context.Context, accountId string) (*dto.MFAChallenge, error) {
+	record, err := s.mfaRepo.FindByAccountID(ctx, accountId)
+	if err != nil {
+		return nil, NewBadRequestError("error on find mfa by account id", err)
+	}
+	if record == nil || !record.IsEnabled {
+		return nil, nil
+	}
+
+	mfaToken, claims, err := token.GenerateChallenge(s.keys, accountId, token.PurposeMFA, mfaChallengeDuration)
+	if err != nil {
+		return nil, NewBadRequestError("error on generate mfa token", err)
+	}
+
+	return &dto.MFAChallenge{
+		MFARequired:     true,
+		MFAToken:        mfaToken,
+		MFATokenExpires: claims.ExpiresAt.Unix(),
+	}, nil
+}
+
+// verifySecondFactor reports whether the code is either a valid TOTP code, a code texted
+// for the login challenge or an unused recovery code. Texted codes are only checked when
+// challengeId is set, outside of a login none was texted
+func (s svc) verifySecondFactor(ctx context.Context, record mfa.Entity, userId, challengeId, code string) (bool, error) {
+	current := mfa.NewFromDatabase(record)
+
+	if current.Verify(code) {
+		// The last used step is persisted so the same code can't be replayed
+		err := s.mfaRepo.Update(ctx, current.Store())
+		if err != nil {
+			if errors.Is(err, mfa.ErrCodeAlreadyUsed) {
+				return false, nil
+			}
+			return false, NewBadRequestError("error on update mfa", err)
+		}
+		return true, nil
+	}
+
+	if challengeId != "" {
+		texted, err := s.verifySMSCode(ctx, userId, challengeId, code)
+		if err != nil {
+			return false, err
+		}
+		if texted {
+			return true, nil
+		}
+	}
+
+	used, err := s.mfaRepo.UseRecoveryCode(ctx, record.AccountID, mfa.HashRecoveryCode(code))
+	if err != nil {
+		return false, NewBadRequestError("error on use recovery code", err)
+	}
+	if used {
+		s.log.Infow(ctx, "recovery code used", logger.String("account_id", record.AccountID))
+	}
+
+	return used, nil
+}
@@ -0,0 +1,141 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/pkg/crypto"
+	"github.com/bernardinorafael/pkg/totp"
+)
+
+const (
+	Issuer            = "Guld"
+	RecoveryCodeCount = 10
+	recoveryCodeSize  = 10
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type mfa struct {
+	id           string
+	accountId    string
+	secret       string
+	isEnabled    bool
+	lastUsedStep int64
+	created      time.Time
+	updated      time.Time
+}
+
+func NewFromDatabase(entity Entity) *mfa {
+	return &mfa{
+		id:           entity.ID,
+		accountId:    entity.AccountID,
+		secret:       entity.Secret,
+		isEnabled:    entity.IsEnabled,
+		lastUsedStep: entity.LastUsedStep,
+		created:      entity.Created,
+		updated:      entity.Updated,
+	}
+}
+
+// New starts a pending enrollment with a fresh secret
+// It only takes effect once confirmed with a first valid code
+func New(accountId string) (*mfa, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return &mfa{
+		id:           util.GenID("mfa"),
+		accountId:    accountId,
+		secret:       secret,
+		isEnabled:    false,
+		lastUsedStep: 0,
+		created:      time.Now(),
+		updated:      time.Now(),
+	}, nil
+}
+
+// URI returns the otpauth URI to be rendered as a QR code by the client
+func (m *mfa) URI(accountName string) string {
+	return totp.URI(Issuer, accountName, m.secret)
+}
+
+// Verify checks a TOTP code, refusing codes of steps already used so that
+// an intercepted code can't be replayed
+func (m *mfa) Verify(code string) bool {
+	step, ok := totp.Validate(m.secret, code, time.Now())
+	if !ok || step <= m.lastUsedStep {
+		return false
+	}
+
+	m.lastUsedStep = step
+	m.updated = time.Now()
+
+	return true
+}
+
+func (m *mfa) Enable() {
+	m.isEnabled = true
+	m.updated = time.Now()
+}
+
+func (m *mfa) Store() Entity {
+	return Entity{
+		ID:           m.ID(),
+		AccountID:    m.AccountID(),
+		Secret:       m.Secret(),
+		IsEnabled:    m.IsEnabled(),
+		LastUsedStep: m.LastUsedStep(),
+		Created:      m.Created(),
+		Updated:      m.Updated(),
+	}
+}
+
+func (m *mfa) ID() string          { return m.id }
+func (m *mfa) AccountID() string   { return m.accountId }
+func (m *mfa) Secret() string      { return m.secret }
+func (m *mfa) IsEnabled() bool     { return m.isEnabled }
+func (m *mfa) LastUsedStep() int64 { return m.lastUsedStep }
+func (m *mfa) Created() time.Time  { return m.created }
+func (m *mfa) Updated() time.Time  { return m.updated }
+
+// NewRecoveryCodes generates a set of one-time recovery codes
+// Only their hashes are kept, the plain codes must be shown to the user once
+func NewRecoveryCodes(accountId string) ([]RecoveryCode, []string, error) {
+	codes := make([]RecoveryCode, 0, RecoveryCodeCount)
+	plain := make([]string, 0, RecoveryCodeCount)
+
+	for range RecoveryCodeCount {
+		b := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))[:recoveryCodeSize]
+		code := raw[:recoveryCodeSize/2] + "-" + raw[recoveryCodeSize/2:]
+
+		codes = append(codes, RecoveryCode{
+			ID:        util.GenID("rec"),
+			AccountID: accountId,
+			CodeHash:  HashRecoveryCode(code),
+			IsUsed:    false,
+			Created:   time.Now(),
+			Updated:   time.Now(),
+		})
+		plain = append(plain, code)
+	}
+
+	return codes, plain, nil
+}
+
+// HashRecoveryCode hashes a recovery code ignoring case, spaces and dashes
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return crypto.HashToken(code)
+}
@@ -0,0 +1,27 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrCodeAlreadyUsed is returned when a concurrent request used the same TOTP step first
+var ErrCodeAlreadyUsed = errors.New("code already used")
+
+// ErrAlreadyEnabled is returned when a pending enrollment would replace one already confirmed
+var ErrAlreadyEnabled = errors.New("mfa already enabled")
+
+type RepositoryInterface interface {
+	// Upsert stores a pending enrollment, replacing a previous pending one of the account
+	// It fails with ErrAlreadyEnabled when the account has MFA enabled
+	Upsert(ctx context.Context, entity Entity) error
+	// Update stores the entity, failing with ErrCodeAlreadyUsed if its last used step is not newer
+	Update(ctx context.Context, entity Entity) error
+	FindByAccountID(ctx context.Context, accountId string) (*Entity, error)
+	// Enable turns MFA on and replaces the recovery codes of the account atomically
+	Enable(ctx context.Context, entity Entity, codes []RecoveryCode) error
+	// Disable removes the MFA settings and recovery codes of the account
+	Disable(ctx context.Context, accountId string) error
+	// UseRecoveryCode marks an unused recovery code as used, reporting whether one matched
+	UseRecoveryCode(ctx context.Context, accountId, codeHash string) (bool, error)
+}
@@ -0,0 +1,260 @@
+package mfa
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bernardinorafael/pkg/crypto"
+	"github.com/bernardinorafael/pkg/transaction"
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+	// cipher encrypts the secrets at rest, they are bound to the ID of their row
+	cipher *crypto.Cipher
+}
+
+func NewRepo(db *sqlx.DB, cipher *crypto.Cipher) RepositoryInterface {
+	return &repo{db: db, cipher: cipher}
+}
+
+// seal returns the entity with its secret encrypted, ready to be written
+// Secrets stored in plain before encryption are encrypted on their next write
+func (r repo) seal(entity Entity) (Entity, error) {
+	secret, err := r.cipher.Encrypt(entity.Secret, entity.ID)
+	if err != nil {
+		return Entity{}, fmt.Errorf("error on encrypt mfa secret: %w", err)
+	}
+	entity.Secret = secret
+
+	return entity, nil
+}
+
+func (r repo) Upsert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	entity, err := r.seal(entity)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.NamedExecContext(
+		ctx,
+		`
+			INSERT INTO account_mfa (
+				id,
+				account_id,
+				secret,
+				is_enabled,
+				last_used_step,
+				created,
+				updated
+			) VALUES (
+				:id,
+				:account_id,
+				:secret,
+				:is_enabled,
+				:last_used_step,
+				:created,
+				:updated
+			)
+			ON CONFLICT (account_id) DO UPDATE SET
+				id = EXCLUDED.id,
+				secret = EXCLUDED.secret,
+				is_enabled = EXCLUDED.is_enabled,
+				last_used_step = EXCLUDED.last_used_step,
+				created = EXCLUDED.created,
+				updated = EXCLUDED.updated
+			WHERE account_mfa.is_enabled = false
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on upsert mfa: %w", err)
+	}
+
+	// A confirmed enrollment is never replaced, it must be disabled first
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error on upsert mfa: %w", err)
+	}
+	if rows == 0 {
+		return ErrAlreadyEnabled
+	}
+
+	return nil
+}
+
+func (r repo) Update(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	entity, err := r.seal(entity)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.NamedExecContext(
+		ctx,
+		`
+			UPDATE account_mfa
+			SET
+				secret = :secret,
+				is_enabled = :is_enabled,
+				last_used_step = :last_used_step,
+				updated = :updated
+			WHERE id = :id AND last_used_step < :last_used_step
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on update mfa: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error on update mfa: %w", err)
+	}
+	if rows == 0 {
+		return ErrCodeAlreadyUsed
+	}
+
+	return nil
+}
+
+func (r repo) FindByAccountID(ctx context.Context, accountId string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(
+		ctx,
+		&entity,
+		"SELECT * FROM account_mfa WHERE account_id = $1",
+		accountId,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find mfa by account id: %w", err)
+	}
+
+	entity.Secret, err = r.cipher.Decrypt(entity.Secret, entity.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error on decrypt mfa secret: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) Enable(ctx context.Context, entity Entity, codes []RecoveryCode) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	entity, err := r.seal(entity)
+	if err != nil {
+		return err
+	}
+
+	return transaction.ExecTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(
+			ctx,
+			`
+				UPDATE account_mfa
+				SET
+					secret = :secret,
+					is_enabled = :is_enabled,
+					last_used_step = :last_used_step,
+					updated = :updated
+				WHERE id = :id
+			`,
+			entity,
+		)
+		if err != nil {
+			return fmt.Errorf("error on enable mfa: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE account_id = $1", entity.AccountID)
+		if err != nil {
+			return fmt.Errorf("error on delete recovery codes: %w", err)
+		}
+
+		_, err = tx.NamedExecContext(
+			ctx,
+			`
+				INSERT INTO mfa_recovery_codes (
+					id,
+					account_id,
+					code_hash,
+					is_used,
+					created,
+					updated
+				) VALUES (
+					:id,
+					:account_id,
+					:code_hash,
+					:is_used,
+					:created,
+					:updated
+				)
+			`,
+			codes,
+		)
+		if err != nil {
+			return fmt.Errorf("error on insert recovery codes: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r repo) Disable(ctx context.Context, accountId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return transaction.ExecTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE account_id = $1", accountId)
+		if err != nil {
+			return fmt.Errorf("error on delete recovery codes: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM account_mfa WHERE account_id = $1", accountId)
+		if err != nil {
+			return fmt.Errorf("error on delete mfa: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r repo) UseRecoveryCode(ctx context.Context, accountId, codeHash string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE mfa_recovery_codes
+			SET is_used = true, updated = now()
+			WHERE account_id = $1 AND code_hash = $2 AND is_used = false
+		`,
+		accountId,
+		codeHash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error on use recovery code: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error on use recovery code: %w", err)
+	}
+
+	return rows == 1, nil
+}
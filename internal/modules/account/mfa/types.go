@@ -0,0 +1,22 @@
+package mfa
+
+import "time"
+
+type Entity struct {
+	ID           string    `json:"id" db:"id"`
+	AccountID    string    `json:"account_id" db:"account_id"`
+	Secret       string    `json:"-" db:"secret"`
+	IsEnabled    bool      `json:"is_enabled" db:"is_enabled"`
+	LastUsedStep int64     `json:"-" db:"last_used_step"`
+	Created      time.Time `json:"created" db:"created"`
+	Updated      time.Time `json:"updated" db:"updated"`
+}
+
+type RecoveryCode struct {
+	ID        string    `json:"id" db:"id"`
+	AccountID string    `json:"account_id" db:"account_id"`
+	CodeHash  string    `json:"-" db:"code_hash"`
+	IsUsed    bool      `json:"is_used" db:"is_used"`
+	Created   time.Time `json:"created" db:"created"`
+	Updated   time.Time `json:"updated" db:"updated"`
+}
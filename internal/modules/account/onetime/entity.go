@@ -0,0 +1,117 @@
+package onetime
+
+import (
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/pkg/crypto"
+)
+
+const TokenSize = 32
+
+// Kind is what single-use tokens are emailed for, each kind is kept in its own table
+type Kind struct {
+	Table    string
+	IDPrefix string
+	TTL      time.Duration
+	// Cooldown is how long a new token can't be requested after the last one
+	Cooldown time.Duration
+}
+
+var (
+	Activation = Kind{
+		Table:    "account_activations",
+		IDPrefix: "act",
+		TTL:      time.Hour * 24,
+		Cooldown: time.Minute * 2,
+	}
+	PasswordReset = Kind{
+		Table:    "password_resets",
+		IDPrefix: "pwr",
+		TTL:      time.Minute * 30,
+		Cooldown: time.Minute,
+	}
+)
+
+type token struct {
+	kind       Kind
+	id         string
+	accountId  string
+	userId     string
+	tokenHash  string
+	isConsumed bool
+	isValid    bool
+	created    time.Time
+	expires    time.Time
+}
+
+func NewFromDatabase(kind Kind, entity Entity) *token {
+	return &token{
+		kind:       kind,
+		id:         entity.ID,
+		accountId:  entity.AccountID,
+		userId:     entity.UserID,
+		tokenHash:  entity.TokenHash,
+		isConsumed: entity.IsConsumed,
+		isValid:    entity.IsValid,
+		created:    entity.Created,
+		expires:    entity.Expires,
+	}
+}
+
+// New creates a new token of the kind and returns it along with the plain token
+// Only the token hash is kept, the plain token must be sent to the user
+func New(kind Kind, accountId, userId string) (*token, string, error) {
+	plain, err := crypto.GenerateToken(TokenSize)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &token{
+		kind:       kind,
+		id:         util.GenID(kind.IDPrefix),
+		accountId:  accountId,
+		userId:     userId,
+		tokenHash:  crypto.HashToken(plain),
+		isConsumed: false,
+		isValid:    true,
+		created:    time.Now(),
+		expires:    time.Now().Add(kind.TTL),
+	}, plain, nil
+}
+
+func (t *token) IsExpired() bool {
+	return time.Now().After(t.expires)
+}
+
+// IsUsable reports whether the token can still be used
+func (t *token) IsUsable() bool {
+	return t.isValid && !t.isConsumed && !t.IsExpired()
+}
+
+// InCooldown reports whether the token was sent too recently for another one to be sent
+func (t *token) InCooldown() bool {
+	return time.Since(t.created) < t.kind.Cooldown
+}
+
+func (t *token) Store() Entity {
+	return Entity{
+		ID:         t.ID(),
+		AccountID:  t.AccountID(),
+		UserID:     t.UserID(),
+		TokenHash:  t.TokenHash(),
+		IsConsumed: t.IsConsumed(),
+		IsValid:    t.IsValid(),
+		Created:    t.Created(),
+		Expires:    t.Expires(),
+	}
+}
+
+func (t *token) ID() string         { return t.id }
+func (t *token) AccountID() string  { return t.accountId }
+func (t *token) UserID() string     { return t.userId }
+func (t *token) TokenHash() string  { return t.tokenHash }
+func (t *token) IsConsumed() bool   { return t.isConsumed }
+func (t *token) IsValid() bool      { return t.isValid }
+func (t *token) Created() time.Time { return t.created }
+func (t *token) Expires() time.Time { return t.expires }
@@ -0,0 +1,18 @@
+package onetime
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAlreadyConsumed is returned when a concurrent request consumed the token first
+var ErrAlreadyConsumed = errors.New("token already consumed")
+
+type RepositoryInterface interface {
+	Insert(ctx context.Context, entity Entity) error
+	// Consume marks the token as consumed, failing with ErrAlreadyConsumed if it no longer is usable
+	Consume(ctx context.Context, tokenId string) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*Entity, error)
+	FindLatestByAccountID(ctx context.Context, accountId string) (*Entity, error)
+	InvalidateAllByAccountID(ctx context.Context, accountId string) error
+}
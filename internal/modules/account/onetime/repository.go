@@ -0,0 +1,143 @@
+package onetime
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+	// table is set from the kind, never from input, so it is safe to format into the queries
+	table string
+}
+
+func NewRepo(db *sqlx.DB, kind Kind) RepositoryInterface {
+	return &repo{db: db, table: kind.Table}
+}
+
+func (r repo) Insert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		fmt.Sprintf(`
+			INSERT INTO %s (
+				id,
+				account_id,
+				user_id,
+				token_hash,
+				is_consumed,
+				is_valid,
+				created,
+				expires
+			) VALUES (
+				:id,
+				:account_id,
+				:user_id,
+				:token_hash,
+				:is_consumed,
+				:is_valid,
+				:created,
+				:expires
+			)
+		`, r.table),
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on insert into %s: %w", r.table, err)
+	}
+
+	return nil
+}
+
+func (r repo) Consume(ctx context.Context, tokenId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		fmt.Sprintf(`
+			UPDATE %s
+			SET is_consumed = true, is_valid = false
+			WHERE id = $1 AND is_consumed = false AND is_valid = true
+		`, r.table),
+		tokenId,
+	)
+	if err != nil {
+		return fmt.Errorf("error on consume %s: %w", r.table, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error on consume %s: %w", r.table, err)
+	}
+	if affected == 0 {
+		return ErrAlreadyConsumed
+	}
+
+	return nil
+}
+
+func (r repo) FindByTokenHash(ctx context.Context, tokenHash string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(
+		ctx,
+		&entity,
+		fmt.Sprintf("SELECT * FROM %s WHERE token_hash = $1", r.table),
+		tokenHash,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find %s by token: %w", r.table, err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) FindLatestByAccountID(ctx context.Context, accountId string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(
+		ctx,
+		&entity,
+		fmt.Sprintf("SELECT * FROM %s WHERE account_id = $1 ORDER BY created DESC LIMIT 1", r.table),
+		accountId,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find latest %s: %w", r.table, err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) InvalidateAllByAccountID(ctx context.Context, accountId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		fmt.Sprintf("UPDATE %s SET is_valid = false WHERE account_id = $1 AND is_valid = true", r.table),
+		accountId,
+	)
+	if err != nil {
+		return fmt.Errorf("error on invalidate %s: %w", r.table, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/modules/org"
+)
+
+// PasswordPolicy holds the rules passwords must follow, configured per organization
+type PasswordPolicy struct {
+	MinLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireNumber  bool
+	RequireSpecial bool
+	// MaxAge is how long a password lasts before it must be changed, zero never expires
+	MaxAge time.Duration
+	// HistoryDepth is how many previous passwords can't be reused, besides the current one
+	HistoryDepth int
+	BannedWords  []string
+}
+
+// DefaultPasswordPolicy applies to users that don't belong to an organization
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:      6,
+	RequireUpper:   true,
+	RequireLower:   true,
+	RequireNumber:  true,
+	RequireSpecial: true,
+	HistoryDepth:   5,
+}
+
+// NewPasswordPolicy returns the policy configured in the organization settings
+func NewPasswordPolicy(organization *org.EntityWithSettings) PasswordPolicy {
+	if organization == nil || organization.Settings.ID == "" {
+		return DefaultPasswordPolicy
+	}
+	settings := organization.Settings
+
+	return PasswordPolicy{
+		MinLength:      settings.PasswordMinLength,
+		RequireUpper:   settings.PasswordRequireUppercase,
+		RequireLower:   settings.PasswordRequireLowercase,
+		RequireNumber:  settings.PasswordRequireNumber,
+		RequireSpecial: settings.PasswordRequireSpecial,
+		MaxAge:         time.Duration(settings.PasswordMaxAgeDays) * 24 * time.Hour,
+		HistoryDepth:   settings.PasswordHistoryDepth,
+		BannedWords:    settings.PasswordBannedWords,
+	}
+}
+
+// PolicyViolationError lists every rule of the policy a password violates
+type PolicyViolationError struct {
+	Violations []Field
+}
+
+func (e *PolicyViolationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Msg)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks the plain text password against every rule, returning a
+// *PolicyViolationError with one field per violated rule
+func (p PasswordPolicy) Validate(password string) error {
+	if password == "" {
+		return &PolicyViolationError{
+			Violations: []Field{{Field: "password.required", Msg: "password cannot be empty"}},
+		}
+	}
+
+	var violations []Field
+	violate := func(rule, msg string) {
+		violations = append(violations, Field{Field: "password." + rule, Msg: msg})
+	}
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violate("min_length", fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+
+	var hasUpper, hasLower, hasNumber, hasSpecial bool
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasNumber = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char):
+			hasSpecial = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		violate("uppercase", "password must contain at least one uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		violate("lowercase", "password must contain at least one lowercase letter")
+	}
+	if p.RequireNumber && !hasNumber {
+		violate("number", "password must contain at least one number")
+	}
+	if p.RequireSpecial && !hasSpecial {
+		violate("special", "password must contain at least one special character")
+	}
+
+	lower := strings.ToLower(password)
+	for _, word := range p.BannedWords {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" && strings.Contains(lower, word) {
+			violate("banned_word", "password must not contain commonly used or forbidden words")
+			break
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyViolationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// IsExpired reports whether a password changed at the given time must be changed again
+func (p PasswordPolicy) IsExpired(changed time.Time) bool {
+	if p.MaxAge <= 0 {
+		return false
+	}
+	return time.Since(changed) > p.MaxAge
+}
+
+// passwordError maps an error of NewAccount or ChangePassword to the error reported to the client
+func passwordError(err error) error {
+	var violation *PolicyViolationError
+	if errors.As(err, &violation) {
+		return NewValidationFieldError("password does not meet the password policy", err, violation.Violations)
+	}
+	if errors.Is(err, ErrInvalidPassword) {
+		return NewBadRequestError("failed to encrypt password", err)
+	}
+	return NewBadRequestError("invalid password", err)
+}
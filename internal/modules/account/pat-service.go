@@ -0,0 +1,160 @@
+package account
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/dto"
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/internal/modules/account/pat"
+	"github.com/bernardinorafael/pkg/crypto"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+var (
+	errInvalidPersonalToken = NewUnauthorizedError("invalid access token", nil)
+)
+
+func (s svc) CreatePersonalToken(ctx context.Context, input dto.CreatePersonalToken) (*pat.Entity, string, error) {
+	claims, err := sessionClaims(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// An empty list is a token restricted to nothing, only an omitted one grants everything
+	var permissions []string
+	if input.Permissions != nil {
+		permissions = slices.Clone(input.Permissions)
+		slices.Sort(permissions)
+		permissions = slices.Compact(permissions)
+	}
+
+	newToken, plain, err := pat.New(claims.AccountID, input.Name, permissions, input.Expires)
+	if err != nil {
+		msg := "failed to validate personal access token"
+		s.log.Errorw(ctx, msg, logger.Err(err))
+		return nil, "", NewValidationFieldError(msg, err, nil)
+	}
+
+	entity := newToken.Store()
+	if err := s.patRepo.Insert(ctx, entity); err != nil {
+		return nil, "", NewBadRequestError("error on insert personal access token", err)
+	}
+
+	s.log.Infow(
+		ctx,
+		"personal access token created",
+		logger.String("account_id", claims.AccountID),
+		logger.String("token_id", newToken.ID()),
+	)
+
+	return &entity, plain, nil
+}
+
+func (s svc) GetAllPersonalTokens(ctx context.Context) ([]pat.Entity, error) {
+	claims, err := sessionClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.patRepo.FindAllByAccountID(ctx, claims.AccountID)
+	if err != nil {
+		return nil, NewBadRequestError("error on find personal access tokens", err)
+	}
+
+	return tokens, nil
+}
+
+func (s svc) RevokePersonalToken(ctx context.Context, tokenId string) error {
+	claims, err := sessionClaims(ctx)
+	if err != nil {
+		return err
+	}
+
+	record, err := s.patRepo.FindByID(ctx, tokenId)
+	if err != nil {
+		return NewBadRequestError("error on find personal access token", err)
+	}
+	// Tokens of other accounts are reported as missing, so their IDs can't be probed
+	if record == nil || record.AccountID != claims.AccountID {
+		return NewNotFoundError("personal access token not found", nil)
+	}
+
+	existing := pat.NewFromDatabase(*record)
+	existing.Revoke()
+
+	if err := s.patRepo.Update(ctx, existing.Store()); err != nil {
+		return NewBadRequestError("error on revoke personal access token", err)
+	}
+
+	s.log.Infow(ctx, "personal access token revoked", logger.String("token_id", tokenId))
+
+	return nil
+}
+
+func (s svc) AuthenticateToken(ctx context.Context, raw string) (*token.AccountClaims, error) {
+	record, err := s.patRepo.FindByTokenHash(ctx, crypto.HashToken(raw))
+	if err != nil {
+		s.log.Errorw(ctx, "error on find personal access token", logger.Err(err))
+		return nil, errInvalidPersonalToken
+	}
+	if record == nil {
+		return nil, errInvalidPersonalToken
+	}
+
+	existing := pat.NewFromDatabase(*record)
+	if !existing.IsValid() {
+		return nil, NewUnauthorizedError("token has expired or was revoked", nil)
+	}
+
+	acc, err := s.repo.FindByID(ctx, existing.AccountID())
+	if err != nil || acc == nil || acc.ID == "" {
+		return nil, errInvalidPersonalToken
+	}
+	if isLocked(acc.User) {
+		return nil, errLockedAccount
+	}
+	if err := checkBan(acc.User); err != nil {
+		return nil, err
+	}
+	if !acc.IsActive {
+		return nil, NewUnauthorizedError("account is not active", nil)
+	}
+
+	if existing.ShouldTouch() {
+		if err := s.patRepo.Touch(ctx, existing.ID(), time.Now()); err != nil {
+			s.log.Errorw(ctx, "error on touch personal access token", logger.Err(err))
+		}
+	}
+
+	return token.NewPersonalTokenClaims(
+		acc.ID,
+		acc.User.ID,
+		acc.User.Username,
+		existing.ID(),
+		existing.Permissions(),
+		existing.Expires(),
+	), nil
+}
+
+// sessionClaims returns the claims of a request authenticated with a session
+// Personal access tokens must not be able to mint more of themselves
+func sessionClaims(ctx context.Context) (*token.AccountClaims, error) {
+	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
+	if !ok {
+		return nil, NewBadRequestError("user ID not found in context", nil)
+	}
+	if claims.IsService() {
+		return nil, NewForbiddenError("service accounts can't have personal access tokens", MissingPermission, nil)
+	}
+	if claims.TokenID != "" {
+		return nil, NewForbiddenError("personal access tokens can't manage tokens", MissingPermission, nil)
+	}
+	if claims.IsImpersonated() {
+		return nil, NewForbiddenError("not allowed while impersonating the user", ImpersonationForbidden, nil)
+	}
+	return claims, nil
+}
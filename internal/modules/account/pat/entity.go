@@ -0,0 +1,144 @@
+package pat
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/pkg/crypto"
+)
+
+const (
+	tokenSize = 32
+	hintSize  = 8
+	// Writing the last use on every request is wasteful, once in a while is precise enough
+	touchInterval = time.Minute
+)
+
+var (
+	ErrEmptyName       = errors.New("name is a required field")
+	ErrExpiresInPast   = errors.New("expiration must be in the future")
+	ErrEmptyPermission = errors.New("permission keys must not be empty")
+)
+
+type pat struct {
+	id          string
+	accountId   string
+	name        string
+	tokenHash   string
+	hint        string
+	permissions []string
+	revoked     bool
+	expires     *time.Time
+	lastUsed    *time.Time
+	created     time.Time
+	updated     time.Time
+}
+
+func NewFromDatabase(entity Entity) *pat {
+	return &pat{
+		id:          entity.ID,
+		accountId:   entity.AccountID,
+		name:        entity.Name,
+		tokenHash:   entity.TokenHash,
+		hint:        entity.Hint,
+		permissions: entity.Permissions,
+		revoked:     entity.Revoked,
+		expires:     entity.Expires,
+		lastUsed:    entity.LastUsed,
+		created:     entity.Created,
+		updated:     entity.Updated,
+	}
+}
+
+// New creates a token of the account, restricted to the permission keys unless they are nil
+// Only the hash is kept, the plain token must be shown to the user once
+func New(accountId, name string, permissions []string, expires *time.Time) (*pat, string, error) {
+	raw, err := crypto.GenerateToken(tokenSize)
+	if err != nil {
+		return nil, "", err
+	}
+	plain := token.PersonalTokenPrefix + raw
+
+	t := pat{
+		id:          util.GenID("pat"),
+		accountId:   accountId,
+		name:        strings.TrimSpace(name),
+		tokenHash:   crypto.HashToken(plain),
+		hint:        plain[:len(token.PersonalTokenPrefix)+hintSize],
+		permissions: permissions,
+		revoked:     false,
+		expires:     expires,
+		lastUsed:    nil,
+		created:     time.Now(),
+		updated:     time.Now(),
+	}
+
+	if err := t.validate(); err != nil {
+		return nil, "", err
+	}
+
+	return &t, plain, nil
+}
+
+func (t *pat) validate() error {
+	if t.name == "" {
+		return ErrEmptyName
+	}
+	if t.expires != nil && !t.expires.After(time.Now()) {
+		return ErrExpiresInPast
+	}
+	if slices.Contains(t.permissions, "") {
+		return ErrEmptyPermission
+	}
+	return nil
+}
+
+func (t *pat) Revoke() {
+	t.revoked = true
+	t.updated = time.Now()
+}
+
+func (t *pat) IsExpired() bool {
+	return t.expires != nil && time.Now().After(*t.expires)
+}
+
+func (t *pat) IsValid() bool {
+	return !t.revoked && !t.IsExpired()
+}
+
+// ShouldTouch reports whether the last use is stale enough to be recorded again
+func (t *pat) ShouldTouch() bool {
+	return t.lastUsed == nil || time.Since(*t.lastUsed) > touchInterval
+}
+
+func (t *pat) Store() Entity {
+	return Entity{
+		ID:          t.ID(),
+		AccountID:   t.AccountID(),
+		Name:        t.Name(),
+		TokenHash:   t.TokenHash(),
+		Hint:        t.Hint(),
+		Permissions: t.Permissions(),
+		Revoked:     t.Revoked(),
+		Expires:     t.Expires(),
+		LastUsed:    t.LastUsed(),
+		Created:     t.Created(),
+		Updated:     t.Updated(),
+	}
+}
+
+func (t *pat) ID() string            { return t.id }
+func (t *pat) AccountID() string     { return t.accountId }
+func (t *pat) Name() string          { return t.name }
+func (t *pat) TokenHash() string     { return t.tokenHash }
+func (t *pat) Hint() string          { return t.hint }
+func (t *pat) Permissions() []string { return t.permissions }
+func (t *pat) Revoked() bool         { return t.revoked }
+func (t *pat) Expires() *time.Time   { return t.expires }
+func (t *pat) LastUsed() *time.Time  { return t.lastUsed }
+func (t *pat) Created() time.Time    { return t.created }
+func (t *pat) Updated() time.Time    { return t.updated }
@@ -0,0 +1,16 @@
+package pat
+
+import (
+	"context"
+	"time"
+)
+
+type RepositoryInterface interface {
+	Insert(ctx context.Context, entity Entity) error
+	Update(ctx context.Context, entity Entity) error
+	FindByID(ctx context.Context, tokenId string) (*Entity, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*Entity, error)
+	FindAllByAccountID(ctx context.Context, accountId string) ([]Entity, error)
+	// Touch records the token was used, without changing anything else
+	Touch(ctx context.Context, tokenId string, at time.Time) error
+}
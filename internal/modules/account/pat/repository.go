@@ -0,0 +1,147 @@
+package pat
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) RepositoryInterface {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			INSERT INTO personal_access_tokens (
+				id,
+				account_id,
+				name,
+				token_hash,
+				hint,
+				permissions,
+				revoked,
+				expires,
+				last_used,
+				created,
+				updated
+			) VALUES (
+				:id,
+				:account_id,
+				:name,
+				:token_hash,
+				:hint,
+				:permissions,
+				:revoked,
+				:expires,
+				:last_used,
+				:created,
+				:updated
+			)
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on insert personal access token: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) Update(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			UPDATE personal_access_tokens SET
+				name = :name,
+				permissions = :permissions,
+				revoked = :revoked,
+				expires = :expires,
+				updated = :updated
+			WHERE id = :id
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on update personal access token: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) FindByID(ctx context.Context, tokenId string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(ctx, &entity, "SELECT * FROM personal_access_tokens WHERE id = $1", tokenId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find personal access token: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) FindByTokenHash(ctx context.Context, tokenHash string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(ctx, &entity, "SELECT * FROM personal_access_tokens WHERE token_hash = $1", tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find personal access token by hash: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) FindAllByAccountID(ctx context.Context, accountId string) ([]Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entities = []Entity{}
+	err := r.db.SelectContext(
+		ctx,
+		&entities,
+		"SELECT * FROM personal_access_tokens WHERE account_id = $1 ORDER BY created DESC",
+		accountId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error on find personal access tokens: %w", err)
+	}
+
+	return entities, nil
+}
+
+func (r repo) Touch(ctx context.Context, tokenId string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "UPDATE personal_access_tokens SET last_used = $1 WHERE id = $2", at, tokenId)
+	if err != nil {
+		return fmt.Errorf("error on touch personal access token: %w", err)
+	}
+
+	return nil
+}
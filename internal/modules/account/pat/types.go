@@ -0,0 +1,21 @@
+package pat
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type Entity struct {
+	ID          string         `json:"id" db:"id"`
+	AccountID   string         `json:"account_id" db:"account_id"`
+	Name        string         `json:"name" db:"name"`
+	TokenHash   string         `json:"-" db:"token_hash"`
+	Hint        string         `json:"hint" db:"hint"`
+	Permissions pq.StringArray `json:"permissions" db:"permissions"`
+	Revoked     bool           `json:"revoked" db:"revoked"`
+	Expires     *time.Time     `json:"expires" db:"expires"`
+	LastUsed    *time.Time     `json:"last_used" db:"last_used"`
+	Created     time.Time      `json:"created" db:"created"`
+	Updated     time.Time      `json:"updated" db:"updated"`
+}
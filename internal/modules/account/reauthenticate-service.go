@@ -0,0 +1,112 @@
+package account
+
+import (
+	"context"
+
+	"github.com/bernardinorafael/internal/_shared/dto"
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/internal/modules/account/session"
+	"github.com/bernardinorafael/pkg/crypto"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+func (s svc) Reauthenticate(ctx context.Context, input dto.Reauthenticate) (*dto.ReauthenticationResponse, error) {
+	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
+	if !ok {
+		return nil, NewBadRequestError("user ID not found in context", nil)
+	}
+	// Only sessions have an auth time to refresh
+	if claims.IsService() || claims.SessionID == "" {
+		return nil, NewBadRequestError("re-authentication requires a user session", nil)
+	}
+	if claims.IsImpersonated() {
+		return nil, NewForbiddenError("not allowed while impersonating the user", ImpersonationForbidden, nil)
+	}
+
+	account, err := s.repo.FindByID(ctx, claims.AccountID)
+	if err != nil {
+		return nil, NewBadRequestError("error on find account by id", err)
+	}
+	if account == nil || account.ID == "" {
+		return nil, NewNotFoundError("account not found", nil)
+	}
+	if isLocked(account.User) {
+		return nil, errLockedAccount
+	}
+	if err := checkBan(account.User); err != nil {
+		return nil, err
+	}
+
+	// Counted as sign-in failures, a stolen session must not be a way to guess the password
+	err = s.checkThrottle(ctx, account.User.Username, input.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Code != "" {
+		record, err := s.mfaRepo.FindByAccountID(ctx, account.ID)
+		if err != nil {
+			return nil, NewBadRequestError("error on find mfa by account id", err)
+		}
+		if record == nil || !record.IsEnabled {
+			return nil, NewBadRequestError("two-factor authentication is not enabled", nil)
+		}
+
+		valid, err := s.verifySecondFactor(ctx, *record, account.User.ID, "", input.Code)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			return nil, s.registerFailure(ctx, account, account.User.Username, input.IP, errInvalidMFACode)
+		}
+	} else if !crypto.PasswordMatches(input.Password, account.Password) {
+		return nil, s.registerFailure(ctx, account, account.User.Username, input.IP, errInvalidCredential)
+	}
+
+	s.clearFailures(ctx, account)
+
+	record, err := s.sessionRepo.FindCurrentByFamilyID(ctx, claims.SessionID)
+	if err != nil {
+		return nil, NewBadRequestError("error on find session by family id", err)
+	}
+	if record == nil {
+		return nil, NewUnauthorizedError("session is no longer active", nil)
+	}
+
+	current := session.NewFromDatabase(*record)
+	if !current.IsValid() {
+		return nil, NewUnauthorizedError("session is no longer active", nil)
+	}
+
+	current.Reauthenticate()
+
+	if err := s.sessionRepo.Update(ctx, current.Store()); err != nil {
+		return nil, NewBadRequestError("error on update session", err)
+	}
+
+	accessToken, accessClaims, err := token.GenerateAccess(
+		s.keys,
+		account.ID,
+		account.User.ID,
+		account.User.Username,
+		current.FamilyID(),
+		current.AuthTime(),
+		accessTokenDuration,
+	)
+	if err != nil {
+		return nil, NewBadRequestError("error on generate access token", err)
+	}
+
+	s.log.Infow(ctx, "session re-authenticated", logger.String("session_id", current.ID()))
+
+	res := &dto.ReauthenticationResponse{
+		SessionID:          current.ID(),
+		AccessToken:        accessToken,
+		AccessTokenExpires: accessClaims.ExpiresAt.Unix(),
+		AuthTime:           current.AuthTime().Unix(),
+	}
+
+	return res, nil
+}
@@ -0,0 +1,202 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bernardinorafael/internal/_shared/dto"
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/mailer"
+	"github.com/bernardinorafael/internal/modules/account/onetime"
+	"github.com/bernardinorafael/pkg/crypto"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+// Channels a password reset link can be sent through
+const (
+	RecoveryChannelEmail = "email"
+	RecoveryChannelSMS   = "sms"
+)
+
+// ForgotPassword sends a password reset link to the account owner, by email or
+// text message. It always succeeds from the caller's point of view, so it does not
+// disclose which usernames exist, failures are only logged
+func (s svc) ForgotPassword(ctx context.Context, input dto.ForgotPassword) error {
+	channel := input.Channel
+	if channel == "" {
+		channel = RecoveryChannelEmail
+	}
+	if channel != RecoveryChannelEmail && channel != RecoveryChannelSMS {
+		return NewBadRequestError("unknown recovery channel", nil)
+	}
+	if channel == RecoveryChannelSMS && !s.sms.Delivers() {
+		return NewBadRequestError("sms recovery is not available", nil)
+	}
+
+	acc, err := s.repo.FindByUsername(ctx, input.Username)
+	if err != nil {
+		s.log.Errorw(ctx, "error on find account by username", logger.Err(err))
+		return nil
+	}
+	if acc == nil || acc.ID == "" {
+		s.log.Info(ctx, "password reset requested for unknown username")
+		return nil
+	}
+	// An unverified number may not be the user's, the link would reach someone else
+	if channel == RecoveryChannelSMS && !acc.User.PhoneVerified {
+		s.log.Infow(ctx, "password reset by sms requested without verified phone", logger.String("account_id", acc.ID))
+		return nil
+	}
+
+	latest, err := s.recoveryRepo.FindLatestByAccountID(ctx, acc.ID)
+	if err != nil {
+		s.log.Errorw(ctx, "error on find latest password reset", logger.Err(err))
+		return nil
+	}
+	if latest != nil && onetime.NewFromDatabase(onetime.PasswordReset, *latest).InCooldown() {
+		s.log.Info(ctx, "password reset requested during cooldown")
+		return nil
+	}
+
+	if err := s.sendPasswordReset(ctx, acc, channel); err != nil {
+		s.log.Errorw(ctx, "error on send password reset", logger.Err(err))
+	}
+
+	return nil
+}
+
+// sendPasswordReset invalidates any pending reset of the account and sends a new link through the channel
+// It skips the cooldown, callers deciding whether the user may ask for another one
+func (s svc) sendPasswordReset(ctx context.Context, acc *EntityWithUser, channel string) error {
+	if err := s.recoveryRepo.InvalidateAllByAccountID(ctx, acc.ID); err != nil {
+		return NewBadRequestError("error on invalidate previous password resets", err)
+	}
+
+	reset, token, err := onetime.New(onetime.PasswordReset, acc.ID, acc.User.ID)
+	if err != nil {
+		return NewBadRequestError("error on generate password reset token", err)
+	}
+
+	if err := s.recoveryRepo.Insert(ctx, reset.Store()); err != nil {
+		return NewBadRequestError("error on insert password reset", err)
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.frontEndURL, token)
+
+	if channel == RecoveryChannelSMS {
+		if err := s.sms.Send(ctx, acc.User.PhoneNumber, "Redefina sua senha pelo link: "+link); err != nil {
+			return NewBadRequestError("error on send password reset sms", err)
+		}
+		return nil
+	}
+
+	email := acc.User.EmailAddress
+	go func() {
+		params := mailer.SendParams{
+			From:    mailer.NotificationSender,
+			To:      email,
+			Subject: "Redefinição de senha",
+			File:    "reset_password.html",
+			Data: map[string]any{
+				"Link": link,
+			},
+		}
+		if err := s.mailer.Send(params); err != nil {
+			s.log.Errorw(ctx, "error on send password reset email", logger.Err(err))
+		}
+	}()
+
+	return nil
+}
+
+func (s svc) ResetPassword(ctx context.Context, token, password string) error {
+	errInvalidLink := NewForbiddenError("reset link is invalid or expired", ExpiredLink, nil)
+
+	record, err := s.recoveryRepo.FindByTokenHash(ctx, crypto.HashToken(token))
+	if err != nil {
+		return NewBadRequestError("error on find password reset", err)
+	}
+	if record == nil {
+		return errInvalidLink
+	}
+
+	reset := onetime.NewFromDatabase(onetime.PasswordReset, *record)
+	if !reset.IsUsable() {
+		return errInvalidLink
+	}
+
+	user, err := s.userRepo.FindByID(ctx, reset.UserID())
+	if err != nil {
+		return NewBadRequestError("error on get user by id", err)
+	}
+	if user == nil {
+		return NewNotFoundError("user not found", nil)
+	}
+
+	found, err := s.repo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return NewBadRequestError("error on get account by user id", err)
+	}
+	if found == nil {
+		return NewNotFoundError("account not found", nil)
+	}
+
+	acc, err := NewFromDatabase(*found)
+	if err != nil {
+		return NewBadRequestError("error on create account entity", err)
+	}
+
+	policy, err := s.passwordPolicy(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IgnorePasswordPolicy {
+		err = s.checkPasswordHistory(ctx, acc, password, policy)
+		if err != nil {
+			return err
+		}
+	}
+
+	replaced := acc.password
+	err = acc.ChangePassword(password, policy, user.IgnorePasswordPolicy)
+	if err != nil {
+		return passwordError(err)
+	}
+
+	// Consumed only once the password was accepted, but before it is stored,
+	// so two requests racing with the same link can't both change it
+	if err := s.recoveryRepo.Consume(ctx, reset.ID()); err != nil {
+		if errors.Is(err, onetime.ErrAlreadyConsumed) {
+			return errInvalidLink
+		}
+		return NewBadRequestError("error on consume password reset", err)
+	}
+
+	if err := s.repo.Update(ctx, acc.Store()); err != nil {
+		return NewBadRequestError("error on updating account password", err)
+	}
+	s.recordPassword(ctx, acc.ID(), replaced, policy)
+
+	// Whoever held the old password must not keep a session
+	if err := s.sessionRepo.DeleteAll(ctx, user.Username); err != nil {
+		return NewBadRequestError("error on revoke sessions", err)
+	}
+
+	s.log.Infow(ctx, "password reset", logger.String("account_id", acc.ID()))
+
+	go func() {
+		params := mailer.SendParams{
+			From:    mailer.NotificationSender,
+			To:      user.EmailAddress,
+			Subject: "Sua senha foi alterada",
+			File:    "change_password.html",
+		}
+		if err := s.mailer.Send(params); err != nil {
+			s.log.Errorw(ctx, "error on send email", logger.Err(err))
+		}
+	}()
+
+	return nil
+}
@@ -7,19 +7,48 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/bernardinorafael/internal/_shared/util"
 	"github.com/bernardinorafael/internal/modules/org"
 	"github.com/bernardinorafael/internal/modules/user"
 	"github.com/bernardinorafael/pkg/transaction"
 	"github.com/jmoiron/sqlx"
 )
 
+// findOrgWithSettingsQuery loads the organization a user owns or is a member of
+const findOrgWithSettingsQuery = `
+	SELECT
+		o.*,
+		os.id as "settings.id",
+		os.org_id as "settings.org_id",
+		os.is_active as "settings.is_active",
+		os.default_membership_password as "settings.default_membership_password",
+		os.max_allowed_memberships as "settings.max_allowed_memberships",
+		os.max_allowed_roles as "settings.max_allowed_roles",
+		os.use_master_password as "settings.use_master_password",
+		os.max_sessions_per_user as "settings.max_sessions_per_user",
+		os.password_min_length as "settings.password_min_length",
+		os.password_require_uppercase as "settings.password_require_uppercase",
+		os.password_require_lowercase as "settings.password_require_lowercase",
+		os.password_require_number as "settings.password_require_number",
+		os.password_require_special as "settings.password_require_special",
+		os.password_max_age_days as "settings.password_max_age_days",
+		os.password_history_depth as "settings.password_history_depth",
+		os.password_banned_words as "settings.password_banned_words",
+		os.created as "settings.created",
+		os.updated as "settings.updated"
+	FROM organizations o
+	INNER JOIN organization_settings os ON os.org_id = o.id
+	WHERE o.owner_id = $1
+	OR o.id = (SELECT tm.org_id FROM team_members tm WHERE tm.user_id = $1)
+	LIMIT 1
+`
+
 type repo struct {
-	db *sqlx.DB
+	db       *sqlx.DB
+	userRepo user.RepositoryInterface
 }
 
-func NewRepository(db *sqlx.DB) RepositoryInterface {
-	return &repo{db}
+func NewRepository(db *sqlx.DB, userRepo user.RepositoryInterface) RepositoryInterface {
+	return &repo{db, userRepo}
 }
 
 func (r repo) FindByUsername(ctx context.Context, username string) (*EntityWithUser, error) {
@@ -56,12 +85,7 @@ func (r repo) FindByUsername(ctx context.Context, username string) (*EntityWithU
 			return fmt.Errorf("error on find user: %w", err)
 		}
 
-		err = tx.GetContext(
-			ctx,
-			&organization,
-			`SELECT * FROM organizations WHERE owner_id = $1`,
-			acc.UserID,
-		)
+		err = tx.GetContext(ctx, &organization, findOrgWithSettingsQuery, acc.UserID)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				return nil
@@ -76,16 +100,43 @@ func (r repo) FindByUsername(ctx context.Context, username string) (*EntityWithU
 	}
 
 	return &EntityWithUser{
-		ID:       acc.ID,
-		Password: acc.Password,
-		Org:      &organization,
-		IsActive: acc.IsActive,
-		Created:  acc.Created,
-		Updated:  acc.Updated,
-		User:     user,
+		ID:              acc.ID,
+		Password:        acc.Password,
+		Org:             &organization,
+		IsActive:        acc.IsActive,
+		PasswordChanged: acc.PasswordChanged,
+		Created:         acc.Created,
+		Updated:         acc.Updated,
+		User:            user,
 	}, nil
 }
 
+func (r repo) FindByPrimaryEmail(ctx context.Context, email string) (*EntityWithUser, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var username string
+	err := r.db.GetContext(
+		ctx,
+		&username,
+		`
+			SELECT u.username FROM emails e
+			INNER JOIN users u ON u.id = e.user_id
+			INNER JOIN accounts a ON a.user_id = u.id
+			WHERE LOWER(e.email) = LOWER($1) AND e.is_primary = true AND e.is_verified = true
+		`,
+		email,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find account by email: %w", err)
+	}
+
+	return r.FindByUsername(ctx, username)
+}
+
 func (r repo) FindByUserID(ctx context.Context, userId string) (*Entity, error) {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
@@ -117,8 +168,10 @@ func (r repo) Update(ctx context.Context, acc Entity) error {
 		UPDATE accounts
 		SET
 			password = :password,
+			password_changed = :password_changed,
 			is_active = :is_active,
 			updated = :updated
+		WHERE id = :id
 		`,
 		acc,
 	)
@@ -129,6 +182,39 @@ func (r repo) Update(ctx context.Context, acc Entity) error {
 	return nil
 }
 
+func (r repo) Activate(ctx context.Context, acc Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return transaction.ExecTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(
+			ctx,
+			`
+				UPDATE accounts
+				SET
+					is_active = :is_active,
+					updated = :updated
+				WHERE id = :id
+			`,
+			acc,
+		)
+		if err != nil {
+			return fmt.Errorf("error on activate account: %w", err)
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			"UPDATE emails SET is_verified = true, updated = now() WHERE user_id = $1 AND is_primary = true",
+			acc.UserID,
+		)
+		if err != nil {
+			return fmt.Errorf("error on verify primary email: %w", err)
+		}
+
+		return nil
+	})
+}
+
 func (r repo) FindByID(ctx context.Context, accountId string) (*EntityWithUser, error) {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
@@ -141,7 +227,7 @@ func (r repo) FindByID(ctx context.Context, accountId string) (*EntityWithUser,
 		err := tx.GetContext(
 			ctx,
 			&acc,
-			`SELECT id, user_id, is_active, created, updated FROM accounts WHERE id = $1`,
+			`SELECT id, user_id, is_active, password_changed, created, updated FROM accounts WHERE id = $1`,
 			accountId,
 		)
 		if err != nil {
@@ -159,27 +245,7 @@ func (r repo) FindByID(ctx context.Context, accountId string) (*EntityWithUser,
 			return fmt.Errorf("error on find user: %w", err)
 		}
 
-		err = tx.GetContext(
-			ctx,
-			&organization,
-			`
-			SELECT
-				o.*,
-				os.id as "settings.id",
-				os.org_id as "settings.org_id",
-				os.is_active as "settings.is_active",
-				os.default_membership_password as "settings.default_membership_password",
-				os.max_allowed_memberships as "settings.max_allowed_memberships",
-				os.max_allowed_roles as "settings.max_allowed_roles",
-				os.use_master_password as "settings.use_master_password",
-				os.created as "settings.created",
-				os.updated as "settings.updated"
-			FROM organizations o
-			INNER JOIN organization_settings os ON os.org_id = o.id
-			WHERE o.owner_id = $1
-		`,
-			acc.UserID,
-		)
+		err = tx.GetContext(ctx, &organization, findOrgWithSettingsQuery, acc.UserID)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				return nil
@@ -194,74 +260,58 @@ func (r repo) FindByID(ctx context.Context, accountId string) (*EntityWithUser,
 	}
 
 	return &EntityWithUser{
-		ID:       acc.ID,
-		Password: acc.Password,
-		Org:      &organization,
-		IsActive: acc.IsActive,
-		Created:  acc.Created,
-		Updated:  acc.Updated,
-		User:     user,
+		ID:              acc.ID,
+		Password:        acc.Password,
+		Org:             &organization,
+		IsActive:        acc.IsActive,
+		PasswordChanged: acc.PasswordChanged,
+		Created:         acc.Created,
+		Updated:         acc.Updated,
+		User:            user,
 	}, nil
 }
 
+func (r repo) FindOrgByUserID(ctx context.Context, userId string) (*org.EntityWithSettings, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var organization org.EntityWithSettings
+	err := r.db.GetContext(ctx, &organization, findOrgWithSettingsQuery, userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find organization: %w", err)
+	}
+
+	return &organization, nil
+}
+
 func (r repo) Insert(ctx context.Context, acc EntityWithUser) error {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
 	err := transaction.ExecTx(ctx, r.db, func(tx *sqlx.Tx) error {
-		var query = `
-			INSERT INTO users (
-				id,
-				full_name,
-				username,
-				phone_number,
-				email_address,
-				avatar_url,
-				banned,
-				locked,
-				username_last_updated,
-				username_lockout_end,
-				created,
-				updated
-			) VALUES (
-				:id,
-				:full_name,
-				:username,
-				:phone_number,
-				:email_address,
-				:avatar_url,
-				:banned,
-				:locked,
-				:username_last_updated,
-				:username_lockout_end,
-				:created,
-				:updated
-			)
-		`
-
-		_, err := tx.NamedExecContext(ctx, query, acc.User)
+		// The user repository joins this transaction, creating the user and its primary email
+		// Self-registered emails are only verified once the account is activated through them
+		err := r.userRepo.Create(transaction.WithTx(ctx, tx), acc.User, false)
 		if err != nil {
 			return fmt.Errorf("error on insert user: %w", err)
 		}
 
-		emailId := util.GenID("email")
 		_, err = tx.ExecContext(
 			ctx,
-			"INSERT INTO emails (id, user_id, email, is_primary, is_verified) VALUES ($1, $2, $3, true, true)",
-			emailId,
-			acc.User.ID,
-			acc.User.EmailAddress,
-		)
-		if err != nil {
-			return fmt.Errorf("error on insert email: %w", err)
-		}
-
-		_, err = tx.ExecContext(
-			ctx,
-			`INSERT INTO accounts (id, user_id, password) VALUES ($1, $2, $3)`,
+			`
+				INSERT INTO accounts (id, user_id, password, password_changed, is_active, created, updated)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+			`,
 			acc.ID,
 			acc.User.ID,
 			acc.Password,
+			acc.PasswordChanged,
+			acc.IsActive,
+			acc.Created,
+			acc.Updated,
 		)
 		if err != nil {
 			return fmt.Errorf("error on insert account: %w", err)
@@ -275,3 +325,22 @@ func (r repo) Insert(ctx context.Context, acc EntityWithUser) error {
 
 	return nil
 }
+
+func (r repo) InsertForUser(ctx context.Context, acc Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			INSERT INTO accounts (id, user_id, password, password_changed, is_active, created, updated)
+			VALUES (:id, :user_id, :password, :password_changed, :is_active, :created, :updated)
+		`,
+		acc,
+	)
+	if err != nil {
+		return fmt.Errorf("error on insert account: %w", err)
+	}
+
+	return nil
+}
@@ -4,19 +4,21 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/bernardinorafael/internal/_shared/dto"
 	. "github.com/bernardinorafael/internal/_shared/errors"
 	"github.com/bernardinorafael/internal/_shared/util"
 	"github.com/bernardinorafael/internal/infra/http/middleware"
 	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/internal/modules/permission"
 	"github.com/bernardinorafael/pkg/logger"
 	"github.com/go-chi/chi"
 )
 
 type controller struct {
-	ctx       context.Context
-	log       logger.Logger
-	svc       ServiceInterface
-	secretKey string
+	ctx  context.Context
+	log  logger.Logger
+	svc  ServiceInterface
+	auth *middleware.Auth
 }
 
 const basePath = "/api/v1"
@@ -25,43 +27,83 @@ func NewController(
 	ctx context.Context,
 	log logger.Logger,
 	svc ServiceInterface,
-	secretKey string,
+	auth *middleware.Auth,
 ) *controller {
-	return &controller{ctx, log, svc, secretKey}
+	return &controller{ctx, log, svc, auth}
 }
 
 func (c controller) RegisterRoute(r *chi.Mux) {
-	m := middleware.NewWithAuth(c.log, c.secretKey)
+	m := c.auth
+
+	r.Get("/.well-known/jwks.json", c.getJWKS)
 
 	r.Route(basePath+"/auth", func(r chi.Router) {
 		// Public
 		r.Post("/login", c.login)
+		r.Post("/login/mfa", c.loginMFA)
+		r.Post("/login/mfa/sms", c.sendMFACode)
+		r.Post("/register", c.register)
+		r.Post("/activate", c.activate)
+		r.Post("/activate/resend", c.resendActivation)
+		r.Post("/forgot-password", c.forgotPassword)
+		r.Post("/reset-password", c.resetPassword)
+		r.Post("/magic-link", c.requestMagicLink)
+		r.Post("/magic-link/verify", c.loginMagicLink)
+		r.Post("/sign-in-alerts/deny", c.denySignIn)
 		r.Post("/refresh", c.renewRefreshToken)
 
 		// Private
-		r.With(m.WithAuth).Delete("/logout", c.logOut)
-
-		// register
-		// activate account
-		// forgot password
-		// reset password
+		r.With(m.WithAuth, m.DenyService).Delete("/logout", c.logOut)
+		r.With(m.WithAuth, m.DenyService, m.DenyImpersonation).Post("/reauthenticate", c.reauthenticate)
 	})
 
 	r.Route(basePath+"/accounts", func(r chi.Router) {
-		r.Use(m.WithAuth)
-		r.Get("/me", c.getSigned)
-		r.Post("/{userId}/change-password", c.changePassword)
+		r.Use(m.WithAuth, m.DenyService)
+		r.With(m.WithPermission(permission.KeyProfileRead)).Get("/me", c.getSigned)
+
+		// Credentials are only ever managed by the user themselves
+		r.Group(func(r chi.Router) {
+			r.Use(m.DenyImpersonation)
+
+			// A stolen session must not be enough to take over or remove the second factor
+			r.Group(func(r chi.Router) {
+				r.Use(m.RequireRecentAuth(middleware.RecentAuthMaxAge))
+
+				r.Post("/{userId}/change-password", c.changePassword)
+				r.Post("/mfa/enroll", c.enrollMFA)
+				r.Post("/mfa/confirm", c.confirmMFA)
+				r.Delete("/mfa", c.disableMFA)
+			})
+
+			r.Post("/tokens", c.createPersonalToken)
+			r.Get("/tokens", c.getAllPersonalTokens)
+			r.Patch("/tokens/{tokenId}/revoke", c.revokePersonalToken)
+		})
 	})
 
 	r.Route(basePath+"/sessions", func(r chi.Router) {
-		r.Use(m.WithAuth)
+		r.Use(m.WithAuth, m.DenyService)
+
+		r.With(m.WithPermission(permission.KeyProfileRead)).Get("/", c.getAllSessions)
+		r.With(m.WithPermission(permission.KeyProfileRead)).Get("/active", c.getSession)
+		r.With(m.WithPermission(permission.KeyProfileWrite), m.DenyImpersonation).Patch("/{sessionId}/revoke", c.revokeSession)
+		r.With(m.WithPermission(permission.KeyProfileWrite), m.DenyImpersonation).Patch("/revoke-others", c.revokeOtherSessions)
+	})
+
+	r.Route(basePath+"/admin", func(r chi.Router) {
+		r.Use(m.WithAuth, m.DenyService, m.DenyImpersonation)
 
-		r.Get("/", c.getAllSessions)
-		r.Get("/active", c.getSession)
-		r.Patch("/{sessionId}/revoke", c.revokeSession)
+		r.Post("/impersonate", c.impersonate)
+		r.Get("/users/{userId}/audit-logs", c.getAuditTrail)
 	})
 }
 
+func (c controller) getJWKS(w http.ResponseWriter, r *http.Request) {
+	// Keys change only on rotation, a short cache spares the downstream services
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	util.WriteJSONResponse(w, http.StatusOK, c.svc.JWKS())
+}
+
 func (c controller) revokeSession(w http.ResponseWriter, r *http.Request) {
 	var sessionId = chi.URLParam(r, "sessionId")
 	claims := r.Context().Value(middleware.AuthKey{}).(*token.AccountClaims)
@@ -75,10 +117,22 @@ func (c controller) revokeSession(w http.ResponseWriter, r *http.Request) {
 	util.WriteSuccessResponse(w, http.StatusOK)
 }
 
+func (c controller) revokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.AuthKey{}).(*token.AccountClaims)
+
+	err := c.svc.RevokeOtherSessions(c.ctx, claims.Username, claims.SessionID)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
 func (c controller) getAllSessions(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(middleware.AuthKey{}).(*token.AccountClaims)
 
-	sessions, err := c.svc.GetAllSessions(c.ctx, claims.Username)
+	sessions, err := c.svc.GetAllSessions(c.ctx, claims.Username, claims.SessionID)
 	if err != nil {
 		NewHttpError(w, err)
 		return
@@ -92,18 +146,40 @@ func (c controller) renewRefreshToken(w http.ResponseWriter, r *http.Request) {
 		RefreshToken string `json:"refresh_token"`
 	}
 
-	err := util.ReadRequestBody(w, r, &body)
-	if err != nil {
-		NewHttpError(w, err)
-		return
+	// In cookie mode the refresh token never reaches scripts, the browser sends it
+	if middleware.IsCookieMode(r) {
+		if cookie, err := r.Cookie(middleware.RefreshTokenCookie); err == nil {
+			body.RefreshToken = cookie.Value
+		}
+	} else {
+		err := util.ReadRequestBody(w, r, &body)
+		if err != nil {
+			NewHttpError(w, err)
+			return
+		}
 	}
 
-	payload, err := c.svc.RenewAccessToken(c.ctx, body.RefreshToken)
+	payload, err := c.svc.RenewAccessToken(c.ctx, body.RefreshToken, "")
 	if err != nil {
 		NewHttpError(w, err)
 		return
 	}
 
+	if middleware.IsCookieMode(r) {
+		err = middleware.SetAuthCookies(
+			w,
+			payload.AccessToken,
+			payload.AccessTokenExpires,
+			payload.RefreshToken,
+			payload.RefreshTokenExpires,
+		)
+		if err != nil {
+			NewHttpError(w, err)
+			return
+		}
+		payload.RefreshToken = ""
+	}
+
 	util.WriteJSONResponse(w, http.StatusOK, payload)
 }
 
@@ -112,10 +188,34 @@ func (c controller) logOut(w http.ResponseWriter, r *http.Request) {
 		NewHttpError(w, err)
 		return
 	}
+	middleware.ClearAuthCookies(w)
 
 	util.WriteSuccessResponse(w, http.StatusOK)
 }
 
+func (c controller) reauthenticate(w http.ResponseWriter, r *http.Request) {
+	var body dto.Reauthenticate
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	body.IP = r.RemoteAddr
+
+	payload, err := c.svc.Reauthenticate(r.Context(), body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	if middleware.IsCookieMode(r) {
+		middleware.SetAccessCookie(w, payload.AccessToken, payload.AccessTokenExpires)
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, payload)
+}
+
 func (c controller) changePassword(w http.ResponseWriter, r *http.Request) {
 	var userId = chi.URLParam(r, "userId")
 	var body struct {
@@ -151,8 +251,320 @@ func (c controller) getSigned(w http.ResponseWriter, r *http.Request) {
 }
 
 func (c controller) login(w http.ResponseWriter, r *http.Request) {
+	var body dto.Login
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	body.UserAgent = r.UserAgent()
+	body.IP = r.RemoteAddr
+
+	payload, challenge, err := c.svc.Login(r.Context(), body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	if challenge != nil {
+		util.WriteJSONResponse(w, http.StatusOK, challenge)
+		return
+	}
+
+	middleware.WriteSession(w, r, payload)
+}
+
+func (c controller) sendMFACode(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		MFAToken string `json:"mfa_token"`
+	}
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	if err := c.svc.SendMFACode(r.Context(), body.MFAToken); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) requestMagicLink(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Email string `json:"email"`
+	}
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	if err := c.svc.RequestMagicLink(r.Context(), body.Email); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) loginMagicLink(w http.ResponseWriter, r *http.Request) {
+	var body dto.LoginMagicLink
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	body.UserAgent = r.UserAgent()
+	body.IP = r.RemoteAddr
+
+	payload, challenge, err := c.svc.LoginMagicLink(r.Context(), body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	if challenge != nil {
+		util.WriteJSONResponse(w, http.StatusOK, challenge)
+		return
+	}
+
+	middleware.WriteSession(w, r, payload)
+}
+
+func (c controller) denySignIn(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Token string `json:"token"`
+	}
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	if err := c.svc.DenySignIn(r.Context(), body.Token); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) loginMFA(w http.ResponseWriter, r *http.Request) {
+	var body dto.LoginMFA
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	body.UserAgent = r.UserAgent()
+	body.IP = r.RemoteAddr
+
+	payload, err := c.svc.LoginMFA(r.Context(), body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	middleware.WriteSession(w, r, payload)
+}
+
+func (c controller) enrollMFA(w http.ResponseWriter, r *http.Request) {
+	enrollment, err := c.svc.EnrollMFA(r.Context())
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, enrollment)
+}
+
+func (c controller) confirmMFA(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Code string `json:"code"`
+	}
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	codes, err := c.svc.ConfirmMFA(r.Context(), body.Code)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, map[string]any{
+		"recovery_codes": codes,
+	})
+}
+
+func (c controller) disableMFA(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Code string `json:"code"`
+	}
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	if err := c.svc.DisableMFA(r.Context(), body.Code); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) createPersonalToken(w http.ResponseWriter, r *http.Request) {
+	var body dto.CreatePersonalToken
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	created, plain, err := c.svc.CreatePersonalToken(r.Context(), body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusCreated, map[string]any{
+		"token":          plain,
+		"personal_token": created,
+	})
+}
+
+func (c controller) getAllPersonalTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := c.svc.GetAllPersonalTokens(r.Context())
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, map[string]any{"personal_tokens": tokens})
+}
+
+func (c controller) revokePersonalToken(w http.ResponseWriter, r *http.Request) {
+	if err := c.svc.RevokePersonalToken(r.Context(), chi.URLParam(r, "tokenId")); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) impersonate(w http.ResponseWriter, r *http.Request) {
+	var body dto.Impersonate
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+	body.UserAgent = r.UserAgent()
+	body.IP = r.RemoteAddr
+
+	res, err := c.svc.Impersonate(r.Context(), body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusCreated, res)
+}
+
+func (c controller) getAuditTrail(w http.ResponseWriter, r *http.Request) {
+	entries, err := c.svc.GetAuditTrail(r.Context(), chi.URLParam(r, "userId"))
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, map[string]any{"audit_logs": entries})
+}
+
+func (c controller) register(w http.ResponseWriter, r *http.Request) {
+	var body dto.CreateAccount
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	userId, err := c.svc.Register(r.Context(), body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusCreated, map[string]any{
+		"user_id": userId,
+	})
+}
+
+func (c controller) activate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Token string `json:"token"`
+	}
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	if err := c.svc.Activate(r.Context(), body.Token); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) resendActivation(w http.ResponseWriter, r *http.Request) {
 	var body struct {
 		Username string `json:"username"`
+	}
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	if err := c.svc.ResendActivation(r.Context(), body.Username); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	var body dto.ForgotPassword
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	if err := c.svc.ForgotPassword(r.Context(), body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) resetPassword(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Token    string `json:"token"`
 		Password string `json:"password"`
 	}
 
@@ -161,19 +573,12 @@ func (c controller) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	payload, err := c.svc.Login(
-		r.Context(),
-		body.Username,
-		body.Password,
-		r.UserAgent(),
-		r.RemoteAddr,
-	)
-	if err != nil {
+	if err := c.svc.ResetPassword(r.Context(), body.Token, body.Password); err != nil {
 		NewHttpError(w, err)
 		return
 	}
 
-	util.WriteJSONResponse(w, http.StatusOK, payload)
+	util.WriteSuccessResponse(w, http.StatusOK)
 }
 
 func (c controller) getSession(w http.ResponseWriter, r *http.Request) {
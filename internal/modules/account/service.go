@@ -2,19 +2,37 @@ package account
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/bernardinorafael/internal/_shared/dto"
 	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/_shared/util"
 	"github.com/bernardinorafael/internal/infra/http/middleware"
 	"github.com/bernardinorafael/internal/infra/token"
 	"github.com/bernardinorafael/internal/mailer"
+	"github.com/bernardinorafael/internal/modules/account/alert"
+	"github.com/bernardinorafael/internal/modules/account/attempt"
+	"github.com/bernardinorafael/internal/modules/account/history"
+	"github.com/bernardinorafael/internal/modules/account/magiclink"
+	"github.com/bernardinorafael/internal/modules/account/mfa"
+	"github.com/bernardinorafael/internal/modules/account/onetime"
+	"github.com/bernardinorafael/internal/modules/account/pat"
 	"github.com/bernardinorafael/internal/modules/account/session"
+	"github.com/bernardinorafael/internal/modules/audit"
+	"github.com/bernardinorafael/internal/modules/permission"
+	"github.com/bernardinorafael/internal/modules/phone"
 	"github.com/bernardinorafael/internal/modules/user"
+	"github.com/bernardinorafael/internal/sms"
 	"github.com/bernardinorafael/pkg/crypto"
 	"github.com/bernardinorafael/pkg/logger"
-	"github.com/medama-io/go-useragent"
+	"github.com/lib/pq"
+)
+
+const (
+	accessTokenDuration  = time.Minute * 15
+	refreshTokenDuration = time.Hour * 24 * 30
 )
 
 var (
@@ -22,13 +40,27 @@ var (
 )
 
 type svc struct {
-	ctx         context.Context
-	log         logger.Logger
-	repo        RepositoryInterface
-	userRepo    user.RepositoryInterface
-	sessionRepo session.RepositoryInterface
-	mailer      mailer.Mailer
-	secretKey   string
+	ctx            context.Context
+	log            logger.Logger
+	repo           RepositoryInterface
+	userRepo       user.RepositoryInterface
+	sessionRepo    session.RepositoryInterface
+	activationRepo onetime.RepositoryInterface
+	recoveryRepo   onetime.RepositoryInterface
+	attemptRepo    attempt.RepositoryInterface
+	mfaRepo        mfa.RepositoryInterface
+	historyRepo    history.RepositoryInterface
+	patRepo        pat.RepositoryInterface
+	magicLinkRepo  magiclink.RepositoryInterface
+	alertRepo      alert.RepositoryInterface
+	permissionRepo permission.RepositoryInterface
+	auditRepo      audit.RepositoryInterface
+	phoneRepo      phone.RepositoryInterface
+	mailer         mailer.Mailer
+	sms            sms.Sender
+	keys           *token.KeySet
+	frontEndURL    string
+	maxSessions    int
 }
 
 func NewService(
@@ -37,59 +69,67 @@ func NewService(
 	repo RepositoryInterface,
 	userRepo user.RepositoryInterface,
 	sessionRepo session.RepositoryInterface,
+	activationRepo onetime.RepositoryInterface,
+	recoveryRepo onetime.RepositoryInterface,
+	attemptRepo attempt.RepositoryInterface,
+	mfaRepo mfa.RepositoryInterface,
+	historyRepo history.RepositoryInterface,
+	patRepo pat.RepositoryInterface,
+	magicLinkRepo magiclink.RepositoryInterface,
+	alertRepo alert.RepositoryInterface,
+	permissionRepo permission.RepositoryInterface,
+	auditRepo audit.RepositoryInterface,
+	phoneRepo phone.RepositoryInterface,
 	mailer mailer.Mailer,
-	secretKey string,
+	sms sms.Sender,
+	keys *token.KeySet,
+	frontEndURL string,
+	maxSessions int,
 ) ServiceInterface {
 	return &svc{
-		ctx:         ctx,
-		log:         log,
-		repo:        repo,
-		userRepo:    userRepo,
-		sessionRepo: sessionRepo,
-		mailer:      mailer,
-		secretKey:   secretKey,
+		ctx:            ctx,
+		log:            log,
+		repo:           repo,
+		userRepo:       userRepo,
+		sessionRepo:    sessionRepo,
+		activationRepo: activationRepo,
+		recoveryRepo:   recoveryRepo,
+		attemptRepo:    attemptRepo,
+		mfaRepo:        mfaRepo,
+		historyRepo:    historyRepo,
+		patRepo:        patRepo,
+		magicLinkRepo:  magicLinkRepo,
+		alertRepo:      alertRepo,
+		permissionRepo: permissionRepo,
+		auditRepo:      auditRepo,
+		phoneRepo:      phoneRepo,
+		mailer:         mailer,
+		sms:            sms,
+		keys:           keys,
+		frontEndURL:    frontEndURL,
+		maxSessions:    maxSessions,
 	}
 }
 
+func (s svc) JWKS() token.JWKS {
+	return s.keys.JWKS()
+}
+
 func (s svc) GetSession(ctx context.Context) (*dto.SessionResponse, error) {
 	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
 	if !ok {
 		return nil, NewBadRequestError("user ID not found in context", nil)
 	}
 
-	records, err := s.sessionRepo.FindAllByUsername(ctx, claims.Username)
+	session, err := s.sessionRepo.FindCurrentByFamilyID(ctx, claims.SessionID)
 	if err != nil {
-		return nil, NewBadRequestError("error retrieving sessions by username", err)
-	}
-
-	if len(records) == 0 {
-		s.log.Info(ctx, "no sessions found for user")
-		return nil, nil
+		return nil, NewBadRequestError("error retrieving session by id", err)
 	}
-
-	var session *session.Entity
-	for _, record := range records {
-		if !record.Revoked {
-			session = &record
-			break
-		}
-	}
-
 	if session == nil {
-		s.log.Info(ctx, "no active session found")
-		return nil, nil
-	}
-
-	res := &dto.SessionResponse{
-		ID:      session.ID,
-		Agent:   session.Agent,
-		IP:      session.IP,
-		Revoked: session.Revoked,
-		Expired: time.Now().After(session.Expires),
-		Created: session.Created,
+		return nil, NewNotFoundError("session not found", nil)
 	}
 
-	return res, nil
+	return sessionResponse(*session, claims.SessionID), nil
 }
 
 func (s svc) RevokeSession(ctx context.Context, username, sessionId string) error {
@@ -97,15 +137,13 @@ func (s svc) RevokeSession(ctx context.Context, username, sessionId string) erro
 	if err != nil {
 		return NewBadRequestError("error on get session by id", err)
 	}
-	if record == nil {
+	// Sessions of other users are reported as missing, so their IDs can't be probed
+	if record == nil || record.Username != username {
 		return NewNotFoundError("session not found", nil)
 	}
 
-	session := session.NewFromDatabase(*record)
-	session.Revoke()
-	sessionData := session.Store()
-
-	err = s.sessionRepo.Update(ctx, sessionData)
+	// Revoking the family also retires the refresh tokens rotated out of this session
+	err = s.sessionRepo.RevokeFamily(ctx, record.FamilyID)
 	if err != nil {
 		return NewBadRequestError("error on update session", err)
 	}
@@ -113,7 +151,24 @@ func (s svc) RevokeSession(ctx context.Context, username, sessionId string) erro
 	return nil
 }
 
-func (s svc) GetAllSessions(ctx context.Context, username string) ([]*dto.SessionResponse, error) {
+// RevokeOtherSessions signs the user out everywhere but the session the request was made with
+func (s svc) RevokeOtherSessions(ctx context.Context, username, familyId string) error {
+	// Personal access tokens have no session, every session would be "other"
+	if familyId == "" {
+		return NewBadRequestError("request is not bound to a session", nil)
+	}
+
+	err := s.sessionRepo.RevokeAllExceptFamily(ctx, username, familyId)
+	if err != nil {
+		return NewBadRequestError("error on revoke other sessions", err)
+	}
+
+	s.log.Infow(ctx, "other sessions revoked", logger.String("username", username))
+
+	return nil
+}
+
+func (s svc) GetAllSessions(ctx context.Context, username, familyId string) ([]*dto.SessionResponse, error) {
 	records, err := s.sessionRepo.FindAllByUsername(ctx, username)
 	if err != nil {
 		return nil, NewBadRequestError("error on get all sessions by username", err)
@@ -122,19 +177,34 @@ func (s svc) GetAllSessions(ctx context.Context, username string) ([]*dto.Sessio
 	sessions := make([]*dto.SessionResponse, 0, len(records))
 
 	for _, s := range records {
-		sessions = append(sessions, &dto.SessionResponse{
-			ID:      s.ID,
-			Agent:   s.Agent,
-			IP:      s.IP,
-			Revoked: s.Revoked,
-			Expired: time.Now().After(s.Expires),
-			Created: s.Created,
-		})
+		sessions = append(sessions, sessionResponse(s, familyId))
 	}
 
 	return sessions, nil
 }
 
+// sessionResponse describes the session, current when it belongs to the family given
+func sessionResponse(record session.Entity, familyId string) *dto.SessionResponse {
+	return &dto.SessionResponse{
+		ID:             record.ID,
+		Agent:          record.Agent,
+		Browser:        record.Browser,
+		BrowserVersion: record.BrowserVersion,
+		OS:             record.OS,
+		DeviceType:     record.DeviceType,
+		IsBot:          record.IsBot,
+		IP:             record.IP,
+		Revoked:        record.Revoked,
+		Expired:        time.Now().After(record.Expires),
+		Current:        record.FamilyID == familyId,
+		LastActive:     record.LastActive,
+		Created:        record.Created,
+		// Lets the user see when support staff acted as them
+		Impersonated:   record.ImpersonatorID != nil,
+		ImpersonatorID: record.ImpersonatorID,
+	}
+}
+
 func (s svc) ChangePassword(ctx context.Context, userId string, oldPassword string, newPassword string) error {
 	user, err := s.userRepo.FindByID(ctx, userId)
 	if err != nil {
@@ -161,14 +231,22 @@ func (s svc) ChangePassword(ctx context.Context, userId string, oldPassword stri
 		return NewConflictError("passwords does not matches", InvalidCredentials, nil, nil)
 	}
 
-	hashedPassword, err := crypto.HashPassword(newPassword)
+	policy, err := s.passwordPolicy(ctx, userId)
 	if err != nil {
-		return NewBadRequestError("failed to encrypt password", nil)
+		return err
+	}
+
+	if !user.IgnorePasswordPolicy {
+		err = s.checkPasswordHistory(ctx, newAcc, newPassword, policy)
+		if err != nil {
+			return err
+		}
 	}
 
-	err = newAcc.ChangePassword(hashedPassword, user.IgnorePasswordPolicy)
+	replaced := newAcc.password
+	err = newAcc.ChangePassword(newPassword, policy, user.IgnorePasswordPolicy)
 	if err != nil {
-		return NewBadRequestError("error on change password", err)
+		return passwordError(err)
 	}
 
 	accountData := newAcc.Store()
@@ -178,6 +256,8 @@ func (s svc) ChangePassword(ctx context.Context, userId string, oldPassword stri
 		return NewBadRequestError("error on updating account password", err)
 	}
 
+	s.recordPassword(ctx, newAcc.ID(), replaced, policy)
+
 	go func() {
 		params := mailer.SendParams{
 			From:    mailer.NotificationSender,
@@ -193,47 +273,280 @@ func (s svc) ChangePassword(ctx context.Context, userId string, oldPassword stri
 	return nil
 }
 
-func (s svc) Login(ctx context.Context, username, password, userAgent, ip string) (*dto.AccountResponse, error) {
-	account, err := s.repo.FindByUsername(ctx, username)
+// passwordPolicy returns the password policy of the organization the user belongs to
+func (s svc) passwordPolicy(ctx context.Context, userId string) (PasswordPolicy, error) {
+	organization, err := s.repo.FindOrgByUserID(ctx, userId)
 	if err != nil {
-		return nil, errInvalidCredential
+		return PasswordPolicy{}, NewBadRequestError("error on find organization by user id", err)
+	}
+	return NewPasswordPolicy(organization), nil
+}
+
+func (s svc) Register(ctx context.Context, dto dto.CreateAccount) (string, error) {
+	newUser, err := user.NewUser(dto.FullName, dto.Username, dto.PhoneNumber, dto.EmailAddress)
+	if err != nil {
+		msg := "error on init user entity"
+		s.log.Errorw(ctx, msg, logger.Err(err))
+		return "", NewValidationFieldError(msg, err, nil)
+	}
+
+	// The account stays inactive until it is activated
+	// A new user doesn't belong to any organization yet, so the default policy applies
+	newAcc, err := NewAccount(newUser.ID(), dto.Password, DefaultPasswordPolicy)
+	if err != nil {
+		return "", passwordError(err)
+	}
+
+	err = s.repo.Insert(ctx, newAcc.StoreWithUser(newUser.Store()))
+	if err != nil {
+		msg := "failed to register account"
+		var pqErr *pq.Error
+		// 23505 is the code for unique constraint violation
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			var appErr = NewConflictError(msg, ResourceAlreadyTaken, err, nil)
+
+			field := util.ExtractFieldFromDetail(pqErr.Detail)
+			s.log.Errorw(ctx, msg, logger.Err(err))
+			appErr.AddField(field, field+" already exists")
+			return "", appErr
+		}
+		s.log.Errorw(ctx, msg, logger.Err(err))
+		return "", NewBadRequestError(msg, err)
+	}
+
+	s.log.Infow(ctx, "account registered", logger.String("user_id", newUser.ID()))
+
+	// The account is already stored, a failure here can be recovered by resending the activation
+	err = s.sendActivation(ctx, newAcc.ID(), newUser.ID(), newUser.Email())
+	if err != nil {
+		s.log.Errorw(ctx, "error on send activation", logger.Err(err))
+	}
+
+	return newUser.ID(), nil
+}
+
+// Login authenticates the credentials. Accounts with two-factor authentication
+// get a challenge instead of a session, to be completed on LoginMFA
+func (s svc) Login(ctx context.Context, input dto.Login) (*dto.AccountResponse, *dto.MFAChallenge, error) {
+	err := s.checkThrottle(ctx, input.Username, input.IP)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	account, err := s.repo.FindByUsername(ctx, input.Username)
+	if err != nil {
+		return nil, nil, errInvalidCredential
+	}
+	// Unknown usernames still cost a password check, so they can't be told apart by the response time
+	if account.ID == "" {
+		crypto.DummyPasswordCheck(input.Password)
+		return nil, nil, s.registerFailure(ctx, account, input.Username, input.IP, errInvalidCredential)
 	}
-	user := account.User
 	// Check if password is correct
-	if !crypto.PasswordMatches(password, account.Password) {
-		return nil, errInvalidCredential
+	if !crypto.PasswordMatches(input.Password, account.Password) {
+		return nil, nil, s.registerFailure(ctx, account, input.Username, input.IP, errInvalidCredential)
+	}
+	// Only told once the password matches, so whether the account is locked is not disclosed
+	// to anyone guessing. Temporary locks are ignored once expired
+	if isLocked(account.User) {
+		return nil, nil, errLockedAccount
+	}
+	// Only told once the password matches, so the ban is not disclosed to anyone else
+	if err := checkBan(account.User); err != nil {
+		return nil, nil, err
 	}
 	// Check if account is active
+	if !account.IsActive {
+		return nil, nil, NewBadRequestError("account is not active", nil)
+	}
+
+	s.upgradePassword(ctx, account, input.Password)
+
+	challenge, err := s.requireMFA(ctx, account.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Failures are only cleared once the second factor is verified as well
+	if challenge != nil {
+		return nil, challenge, nil
+	}
+
+	s.clearFailures(ctx, account)
+
+	payload, err := s.createSession(ctx, account, input.UserAgent, input.IP, input.EvictOldest, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return payload, nil, nil
+}
+
+func (s svc) ProvisionAccount(ctx context.Context, userId string) (string, error) {
+	found, err := s.repo.FindByUserID(ctx, userId)
+	if err != nil {
+		return "", NewBadRequestError("error on get account by user id", err)
+	}
+	if found != nil {
+		return found.ID, nil
+	}
+
+	newAcc, err := NewExternalAccount(userId)
+	if err != nil {
+		return "", NewBadRequestError("error on create account entity", err)
+	}
+
+	if err := s.repo.InsertForUser(ctx, newAcc.Store()); err != nil {
+		return "", NewBadRequestError("error on insert account", err)
+	}
+
+	s.log.Infow(ctx, "account provisioned", logger.String("user_id", userId))
+
+	return newAcc.ID(), nil
+}
+
+func (s svc) IssueSession(
+	ctx context.Context,
+	accountId, userAgent, ip string,
+	requireMFA bool,
+) (*dto.AccountResponse, *dto.MFAChallenge, error) {
+	account, err := s.findIssuableAccount(ctx, accountId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if requireMFA {
+		challenge, err := s.requireMFA(ctx, account.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if challenge != nil {
+			return nil, challenge, nil
+		}
+	}
+
+	// There is no one to pick a session to revoke, so the oldest makes room
+	payload, err := s.createSession(ctx, account, userAgent, ip, true, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return payload, nil, nil
+}
+
+func (s svc) IssueClientSession(ctx context.Context, accountId, clientId, scope, userAgent, ip string) (*dto.AccountResponse, error) {
+	account, err := s.findIssuableAccount(ctx, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	// There is no one to pick a session to revoke, so the oldest makes room
+	return s.createSession(ctx, account, userAgent, ip, true, &clientGrant{clientId: clientId, scope: scope})
+}
+
+// findIssuableAccount returns the account a session is issued for without a sign in,
+// refusing it like a sign in would
+func (s svc) findIssuableAccount(ctx context.Context, accountId string) (*EntityWithUser, error) {
+	account, err := s.repo.FindByID(ctx, accountId)
+	if err != nil {
+		return nil, NewBadRequestError("error on find account by id", err)
+	}
+	if account == nil || account.ID == "" {
+		return nil, NewNotFoundError("account not found", nil)
+	}
+	if isLocked(account.User) {
+		return nil, errLockedAccount
+	}
+	if err := checkBan(account.User); err != nil {
+		return nil, err
+	}
 	if !account.IsActive {
 		return nil, NewBadRequestError("account is not active", nil)
 	}
 
-	// sessions, err := s.sessionRepo.FindAllByUsername(ctx, username)
-	// if err != nil {
-	// 	return nil, NewBadRequestError("error on retrieve all sessions by username", err)
-	// }
+	return account, nil
+}
 
-	// TODO: When the maximum number of sessions is reached
-	// it should log out of one session and continue the login
-	// if len(sessions) == 3 {
-	// 	return nil, NewConflictError("max sessions reached", MaxSessionsReached, nil, nil)
-	// }
+// upgradePassword rehashes a verified password stored with a legacy algorithm or
+// weaker parameters. Login must not fail because of it, so errors are only logged
+func (s svc) upgradePassword(ctx context.Context, account *EntityWithUser, password string) {
+	if !crypto.NeedsRehash(account.Password) {
+		return
+	}
+
+	acc, err := NewFromDatabase(Entity{
+		ID:              account.ID,
+		UserID:          account.User.ID,
+		Password:        account.Password,
+		PasswordChanged: account.PasswordChanged,
+		IsActive:        account.IsActive,
+		Created:         account.Created,
+		Updated:         account.Updated,
+	})
+	if err != nil {
+		s.log.Errorw(ctx, "error on create account entity", logger.Err(err))
+		return
+	}
 
-	// Access token with 15 minutes expiration
-	accessToken, accessClaims, err := token.Generate(s.secretKey, account.ID, user.ID, user.Username, time.Minute*15)
+	if err := acc.RehashPassword(password); err != nil {
+		s.log.Errorw(ctx, "error on rehash password", logger.Err(err))
+		return
+	}
+
+	if err := s.repo.Update(ctx, acc.Store()); err != nil {
+		s.log.Errorw(ctx, "error on update rehashed password", logger.Err(err))
+		return
+	}
+
+	account.Password = acc.Password()
+	s.log.Infow(ctx, "password rehashed", logger.String("account_id", acc.ID()))
+}
+
+// clientGrant is the OAuth client a session is opened for and the scope the user granted it
+type clientGrant struct {
+	clientId string
+	scope    string
+}
+
+// createSession opens a new session for the account, enforcing the
+// maximum number of concurrent sessions allowed for the user
+// Sessions opened for an OAuth client are bound to it, nil is a first-party session
+func (s svc) createSession(
+	ctx context.Context,
+	account *EntityWithUser,
+	userAgent, ip string,
+	evictOldest bool,
+	client *clientGrant,
+) (*dto.AccountResponse, error) {
+	user := account.User
+
+	err := s.enforceSessionLimit(ctx, account, evictOldest)
 	if err != nil {
-		return nil, NewBadRequestError("error on generate access token", err)
+		return nil, err
 	}
-	// Refresh token with 30 days expiration
-	refreshToken, refreshClaims, err := token.Generate(s.secretKey, account.ID, user.ID, user.Username, time.Hour*24*30)
+
+	// Refresh token with 30 days expiration, it isn't bound to a session so it can't be used as an access token
+	refreshToken, refreshClaims, err := token.Generate(s.keys, account.ID, user.ID, user.Username, "", refreshTokenDuration)
 	if err != nil {
 		return nil, NewBadRequestError("error on generate refresh token", err)
 	}
 
-	agent := useragent.NewParser().Parse(userAgent)
-	userAgent = fmt.Sprintf("%s em %s", agent.GetBrowser(), agent.GetOS())
+	device := session.ParseDevice(userAgent)
+
+	// Compared before inserting, the new session would otherwise be a known device itself
+	knownDevice := s.isKnownDevice(ctx, user.Username, device, ip)
 
-	newSession := session.New(user.Username, refreshToken, userAgent, ip)
+	newSession := session.New(user.Username, refreshToken, device, ip, refreshClaims.ExpiresAt.Time)
+	if client != nil {
+		newSession = session.NewForClient(
+			user.Username,
+			client.clientId,
+			client.scope,
+			refreshToken,
+			device,
+			ip,
+			refreshClaims.ExpiresAt.Time,
+		)
+	}
 	sessionData := newSession.Store()
 
 	err = s.sessionRepo.Insert(ctx, sessionData)
@@ -241,7 +554,18 @@ func (s svc) Login(ctx context.Context, username, password, userAgent, ip string
 		return nil, NewBadRequestError("error on insert session", err)
 	}
 
+	if !knownDevice {
+		s.sendSignInAlert(ctx, account, newSession.FamilyID(), device, ip)
+	}
+
+	// Access token with 15 minutes expiration
+	accessToken, accessClaims, err := s.generateAccess(account.ID, user, sessionData)
+	if err != nil {
+		return nil, NewBadRequestError("error on generate access token", err)
+	}
+
 	payload := dto.AccountResponse{
+		PasswordExpired:     NewPasswordPolicy(account.Org).IsExpired(account.PasswordChanged),
 		SessionID:           newSession.ID(),
 		AccessToken:         accessToken,
 		RefreshToken:        refreshToken,
@@ -252,30 +576,62 @@ func (s svc) Login(ctx context.Context, username, password, userAgent, ip string
 	return &payload, nil
 }
 
-func (s svc) Logout(ctx context.Context) error {
-	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
-	if !ok {
-		return NewBadRequestError("user ID not found in context", nil)
+// enforceSessionLimit makes room for a new session when the user already holds
+// the maximum allowed. Without evictOldest it returns a conflict listing the
+// sessions that can be revoked instead
+func (s svc) enforceSessionLimit(ctx context.Context, account *EntityWithUser, evictOldest bool) error {
+	limit := s.maxSessions
+	if account.Org != nil && account.Org.Settings.MaxSessionsPerUser != nil {
+		limit = *account.Org.Settings.MaxSessionsPerUser
+	}
+	// A non-positive limit disables the policy
+	if limit <= 0 {
+		return nil
 	}
 
-	sessions, err := s.sessionRepo.FindAllByUsername(ctx, claims.Username)
+	sessions, err := s.sessionRepo.FindAllActiveByUsername(ctx, account.User.Username)
 	if err != nil {
-		return NewBadRequestError("error on find all sessions by username", err)
+		return NewBadRequestError("error on retrieve all sessions by username", err)
 	}
+	if len(sessions) < limit {
+		return nil
+	}
+
+	// Sessions are sorted oldest first
+	exceeding := sessions[:len(sessions)-limit+1]
 
-	var sess *session.Entity
-	for _, v := range sessions {
-		if !v.Revoked {
-			sess = &v
-			break
+	if !evictOldest {
+		fields := make([]Field, 0, len(sessions))
+		for _, v := range sessions {
+			fields = append(fields, Field{Field: v.ID, Msg: fmt.Sprintf("%s, %s (%s)", v.Browser, v.OS, v.IP)})
 		}
+		return NewConflictError("max sessions reached", MaxSessionsReached, nil, fields)
 	}
-	if sess == nil {
-		s.log.Info(ctx, "no revoked session found")
-		return nil
+
+	for _, v := range exceeding {
+		err = s.sessionRepo.RevokeFamily(ctx, v.FamilyID)
+		if err != nil {
+			return NewBadRequestError("error on revoke oldest session", err)
+		}
+		s.log.Infow(
+			ctx,
+			"session evicted by session limit",
+			logger.String("session_id", v.ID),
+			logger.String("username", v.Username),
+		)
 	}
 
-	err = s.RevokeSession(ctx, claims.Username, sess.ID)
+	return nil
+}
+
+func (s svc) Logout(ctx context.Context) error {
+	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
+	if !ok {
+		return NewBadRequestError("user ID not found in context", nil)
+	}
+
+	// Revoking the family also retires the refresh tokens rotated out of this session
+	err := s.sessionRepo.RevokeFamily(ctx, claims.SessionID)
 	if err != nil {
 		return NewBadRequestError("error on revoke session", err)
 	}
@@ -283,8 +639,32 @@ func (s svc) Logout(ctx context.Context) error {
 	return nil
 }
 
-func (s svc) RenewAccessToken(ctx context.Context, refreshToken string) (*dto.RenewAccessToken, error) {
-	refreshTokenClaims, err := token.Verify(s.secretKey, refreshToken)
+// generateAccess signs the access token of the session, carrying the OAuth client
+// and scope when the session was opened for one
+func (s svc) generateAccess(accId string, user user.Entity, sess session.Entity) (string, *token.AccountClaims, error) {
+	if sess.ClientID != nil {
+		var scope string
+		if sess.Scope != nil {
+			scope = *sess.Scope
+		}
+		return token.GenerateClientAccess(
+			s.keys,
+			accId,
+			user.ID,
+			user.Username,
+			sess.FamilyID,
+			*sess.ClientID,
+			scope,
+			sess.AuthTime,
+			accessTokenDuration,
+		)
+	}
+
+	return token.GenerateAccess(s.keys, accId, user.ID, user.Username, sess.FamilyID, sess.AuthTime, accessTokenDuration)
+}
+
+func (s svc) RenewAccessToken(ctx context.Context, refreshToken, clientId string) (*dto.RenewAccessToken, error) {
+	refreshTokenClaims, err := token.VerifyRefresh(s.keys, refreshToken)
 	if err != nil {
 		return nil, NewBadRequestError("error on verify refresh token", err)
 	}
@@ -293,37 +673,102 @@ func (s svc) RenewAccessToken(ctx context.Context, refreshToken string) (*dto.Re
 	if err != nil {
 		return nil, NewBadRequestError("error on find account by id", err)
 	}
+	if acc == nil || acc.ID == "" {
+		return nil, NewNotFoundError("account not found", nil)
+	}
+	// Sessions outlive a lock or a deactivation, so the account is checked again like on sign in
+	if isLocked(acc.User) {
+		return nil, errLockedAccount
+	}
+	if err := checkBan(acc.User); err != nil {
+		return nil, err
+	}
+	if !acc.IsActive {
+		return nil, NewBadRequestError("account is not active", nil)
+	}
 	user := acc.User
 
 	record, err := s.sessionRepo.FindByRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return nil, NewBadRequestError("error on find session by refresh token", err)
 	}
+	if record == nil {
+		return nil, NewBadRequestError("session is invalid", nil)
+	}
 
-	session := session.NewFromDatabase(*record)
+	current := session.NewFromDatabase(*record)
 
-	if !session.IsValid() {
+	// A retired refresh token being presented again means it leaked,
+	// so the whole family is revoked and the legitimate user must sign in again
+	if current.Rotated() {
+		return nil, s.revokeReusedFamily(ctx, current.Store())
+	}
+
+	if !current.IsValid() {
 		return nil, NewBadRequestError("session is invalid", nil)
 	}
 
-	if session.Username() != user.Username {
+	if current.Username() != user.Username {
 		return nil, NewBadRequestError("session username does not match account username", nil)
 	}
 
-	accessToken, claims, err := token.Generate(s.secretKey, acc.ID, user.ID, user.Username, time.Minute*15)
+	// A refresh token only renews access for the client it was issued to
+	if !current.IsForClient(clientId) {
+		return nil, NewBadRequestError("session is invalid", nil)
+	}
+
+	newRefreshToken, refreshClaims, err := token.Generate(s.keys, acc.ID, user.ID, user.Username, "", refreshTokenDuration)
+	if err != nil {
+		s.log.Errorw(ctx, "error on generate refresh token", logger.Err(err))
+		return nil, NewBadRequestError("error on generate refresh token", err)
+	}
+
+	next := current.Rotate(newRefreshToken, refreshClaims.ExpiresAt.Time)
+
+	err = s.sessionRepo.Rotate(ctx, current.Store(), next.Store())
+	if err != nil {
+		if errors.Is(err, session.ErrAlreadyRotated) {
+			return nil, s.revokeReusedFamily(ctx, current.Store())
+		}
+		return nil, NewBadRequestError("error on rotate session", err)
+	}
+
+	accessToken, claims, err := s.generateAccess(acc.ID, user, next.Store())
 	if err != nil {
 		s.log.Errorw(ctx, "error on generate access token", logger.Err(err))
 		return nil, NewBadRequestError("error on generate access token", err)
 	}
 
 	payload := dto.RenewAccessToken{
-		AccessToken:        accessToken,
-		AccessTokenExpires: claims.ExpiresAt.Unix(),
+		SessionID:           next.ID(),
+		AccessToken:         accessToken,
+		RefreshToken:        newRefreshToken,
+		AccessTokenExpires:  claims.ExpiresAt.Unix(),
+		RefreshTokenExpires: refreshClaims.ExpiresAt.Unix(),
+		Scope:               claims.Scope,
 	}
 
 	return &payload, nil
 }
 
+// revokeReusedFamily revokes every session of a family whose refresh token was reused
+func (s svc) revokeReusedFamily(ctx context.Context, reused session.Entity) error {
+	s.log.Criticalw(
+		ctx,
+		"security event: refresh token reuse detected, revoking session family",
+		logger.String("family_id", reused.FamilyID),
+		logger.String("session_id", reused.ID),
+		logger.String("username", reused.Username),
+	)
+
+	if err := s.sessionRepo.RevokeFamily(ctx, reused.FamilyID); err != nil {
+		s.log.Errorw(ctx, "error on revoke session family", logger.Err(err))
+		return NewBadRequestError("error on revoke session family", err)
+	}
+
+	return NewUnauthorizedError("refresh token has already been used", nil)
+}
+
 func (s svc) GetSignedInAccount(ctx context.Context) (*EntityWithUser, error) {
 	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
 	if !ok {
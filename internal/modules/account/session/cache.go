@@ -0,0 +1,133 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxCachedFamilies bounds the cache size before stale entries are swept
+const maxCachedFamilies = 10_000
+
+type cachedFamily struct {
+	username string
+	revoked  bool
+	expires  time.Time
+	cachedAt time.Time
+}
+
+// cachedRepo decorates a session repository with an in-process cache of the
+// session families checked on every authenticated request. Writes that revoke
+// or delete sessions evict the affected families right away, the ttl only
+// bounds how long changes made by other instances may go unnoticed
+type cachedRepo struct {
+	RepositoryInterface
+	ttl time.Duration
+
+	mu       sync.RWMutex
+	families map[string]cachedFamily
+}
+
+func NewCachedRepo(repo RepositoryInterface, ttl time.Duration) *cachedRepo {
+	return &cachedRepo{
+		RepositoryInterface: repo,
+		ttl:                 ttl,
+		families:            make(map[string]cachedFamily),
+	}
+}
+
+// IsActive reports whether the session family is neither revoked nor expired
+func (r *cachedRepo) IsActive(ctx context.Context, familyId string) (bool, error) {
+	r.mu.RLock()
+	cached, ok := r.families[familyId]
+	r.mu.RUnlock()
+
+	if !ok || time.Since(cached.cachedAt) > r.ttl {
+		record, err := r.RepositoryInterface.FindCurrentByFamilyID(ctx, familyId)
+		if err != nil {
+			return false, err
+		}
+		if record == nil {
+			return false, nil
+		}
+
+		cached = cachedFamily{
+			username: record.Username,
+			revoked:  record.Revoked,
+			expires:  record.Expires,
+			cachedAt: time.Now(),
+		}
+		r.store(familyId, cached)
+	}
+
+	return !cached.revoked && cached.expires.After(time.Now()), nil
+}
+
+func (r *cachedRepo) Update(ctx context.Context, entity Entity) error {
+	defer r.evict(entity.FamilyID)
+	return r.RepositoryInterface.Update(ctx, entity)
+}
+
+func (r *cachedRepo) RevokeFamily(ctx context.Context, familyId string) error {
+	defer r.evict(familyId)
+	return r.RepositoryInterface.RevokeFamily(ctx, familyId)
+}
+
+func (r *cachedRepo) RevokeAllExceptFamily(ctx context.Context, username, familyId string) error {
+	defer r.evictUser(username)
+	return r.RepositoryInterface.RevokeAllExceptFamily(ctx, username, familyId)
+}
+
+func (r *cachedRepo) RevokeAll(ctx context.Context, username string) error {
+	defer r.evictUser(username)
+	return r.RepositoryInterface.RevokeAll(ctx, username)
+}
+
+func (r *cachedRepo) Delete(ctx context.Context, sessionId string) error {
+	record, err := r.RepositoryInterface.FindByID(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+	if record != nil {
+		defer r.evict(record.FamilyID)
+	}
+	return r.RepositoryInterface.Delete(ctx, sessionId)
+}
+
+func (r *cachedRepo) DeleteAll(ctx context.Context, username string) error {
+	defer r.evictUser(username)
+	return r.RepositoryInterface.DeleteAll(ctx, username)
+}
+
+func (r *cachedRepo) store(familyId string, family cachedFamily) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.families) >= maxCachedFamilies {
+		for id, v := range r.families {
+			if time.Since(v.cachedAt) > r.ttl {
+				delete(r.families, id)
+			}
+		}
+	}
+
+	r.families[familyId] = family
+}
+
+func (r *cachedRepo) evict(familyId string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.families, familyId)
+}
+
+func (r *cachedRepo) evictUser(username string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, v := range r.families {
+		if v.username == username {
+			delete(r.families, id)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/medama-io/go-useragent"
+)
+
+// maxAgentLength is the size of the agent column, longer headers are cut
+const maxAgentLength = 512
+
+// Device describes what a session was opened from, parsed from its User-Agent header
+type Device struct {
+	Agent          string
+	Browser        string
+	BrowserVersion string
+	OS             string
+	// Type is Desktop, Mobile, Tablet, TV, Bot or Unknown
+	Type  string
+	IsBot bool
+}
+
+func ParseDevice(userAgent string) Device {
+	ua := useragent.NewParser().Parse(userAgent)
+
+	// Headers are not guaranteed to be valid UTF-8, which the database rejects,
+	// and the cut must not split a rune either
+	userAgent = strings.ToValidUTF8(userAgent, "")
+	if len(userAgent) > maxAgentLength {
+		cut := maxAgentLength
+		for cut > 0 && !utf8.RuneStart(userAgent[cut]) {
+			cut--
+		}
+		userAgent = userAgent[:cut]
+	}
+
+	return Device{
+		Agent:          userAgent,
+		Browser:        ua.GetBrowser(),
+		BrowserVersion: ua.GetVersion(),
+		OS:             ua.GetOS(),
+		Type:           ua.GetDevice(),
+		IsBot:          ua.IsBot(),
+	}
+}
+
+// SameAs reports whether both describe the same kind of device, the browser
+// version is left out as it changes on every update. Sessions created before
+// the device type was stored have none, only browser and OS are compared then
+func (d Device) SameAs(other Device) bool {
+	if d.Browser != other.Browser || d.OS != other.OS {
+		return false
+	}
+	return d.Type == "" || other.Type == "" || d.Type == other.Type
+}
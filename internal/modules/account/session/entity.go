@@ -8,11 +8,18 @@ import (
 
 type session struct {
 	id           string
+	familyId     string
 	username     string
 	refreshToken string
-	agent        string
+	device       Device
 	ip           string
 	revoked      bool
+	rotated      bool
+	impersonator *string
+	clientId     *string
+	scope        *string
+	lastActive   time.Time
+	authTime     time.Time
 	expires      time.Time
 	created      time.Time
 	updated      time.Time
@@ -21,60 +28,156 @@ type session struct {
 func NewFromDatabase(sess Entity) *session {
 	return &session{
 		id:           sess.ID,
+		familyId:     sess.FamilyID,
 		username:     sess.Username,
 		refreshToken: sess.RefreshToken,
-		agent:        sess.Agent,
+		device: Device{
+			Agent:          sess.Agent,
+			Browser:        sess.Browser,
+			BrowserVersion: sess.BrowserVersion,
+			OS:             sess.OS,
+			Type:           sess.DeviceType,
+			IsBot:          sess.IsBot,
+		},
 		ip:           sess.IP,
 		revoked:      sess.Revoked,
+		rotated:      sess.Rotated,
+		impersonator: sess.ImpersonatorID,
+		clientId:     sess.ClientID,
+		scope:        sess.Scope,
+		lastActive:   sess.LastActive,
+		authTime:     sess.AuthTime,
 		expires:      sess.Expires,
 		created:      sess.Created,
 		updated:      sess.Updated,
 	}
 }
 
-func New(username, refreshToken, agent, ip string) *session {
+// New creates a session that starts a new refresh token family
+func New(username, refreshToken string, device Device, ip string, expires time.Time) *session {
+	id := util.GenID("sess")
 	return &session{
-		id:           util.GenID("sess"),
+		id:           id,
+		familyId:     id,
 		username:     username,
 		refreshToken: refreshToken,
-		agent:        agent,
+		device:       device,
 		ip:           ip,
 		revoked:      false,
-		expires:      time.Now().Add(24 * time.Hour), // TODO: remove hard-coded expires
+		rotated:      false,
+		lastActive:   time.Now(),
+		authTime:     time.Now(),
+		expires:      expires,
 		created:      time.Now(),
 		updated:      time.Now(),
 	}
 }
 
+// NewImpersonation creates a session of the user held by the impersonator
+func NewImpersonation(username, impersonatorId, refreshToken string, device Device, ip string, expires time.Time) *session {
+	s := New(username, refreshToken, device, ip, expires)
+	s.impersonator = &impersonatorId
+	return s
+}
+
+// NewForClient creates a session of the user opened for an OAuth client, limited to the granted scope
+func NewForClient(username, clientId, scope, refreshToken string, device Device, ip string, expires time.Time) *session {
+	s := New(username, refreshToken, device, ip, expires)
+	s.clientId = &clientId
+	s.scope = &scope
+	return s
+}
+
+// Rotate retires the session and returns its successor in the same family,
+// holding the new refresh token. Using the refresh token marks the session as active.
+// The successor keeps the creation time so the family keeps its place in eviction order
+func (s *session) Rotate(refreshToken string, expires time.Time) *session {
+	s.rotated = true
+	s.updated = time.Now()
+
+	return &session{
+		id:           util.GenID("sess"),
+		familyId:     s.familyId,
+		username:     s.username,
+		refreshToken: refreshToken,
+		device:       s.device,
+		ip:           s.ip,
+		revoked:      false,
+		rotated:      false,
+		impersonator: s.impersonator,
+		clientId:     s.clientId,
+		scope:        s.scope,
+		lastActive:   time.Now(),
+		authTime:     s.authTime,
+		expires:      expires,
+		created:      s.created,
+		updated:      time.Now(),
+	}
+}
+
+// Reauthenticate records that the user just proved who they are again on the session
+func (s *session) Reauthenticate() {
+	s.authTime = time.Now()
+	s.updated = time.Now()
+}
+
 func (s *session) Revoke() {
 	s.revoked = true
 	s.updated = time.Now()
 }
 
 func (s *session) IsValid() bool {
-	return !s.revoked && s.expires.After(time.Now())
+	return !s.revoked && !s.rotated && s.expires.After(time.Now())
+}
+
+// IsForClient reports whether the session was opened for the OAuth client, an empty
+// client standing for the first-party apps
+func (s *session) IsForClient(clientId string) bool {
+	if s.clientId == nil {
+		return clientId == ""
+	}
+	return *s.clientId == clientId
 }
 
 func (s *session) Store() Entity {
 	return Entity{
-		ID:           s.ID(),
-		Username:     s.Username(),
-		RefreshToken: s.RefreshToken(),
-		Agent:        s.Agent(),
-		IP:           s.IP(),
-		Revoked:      s.Revoked(),
-		Expires:      s.Expires(),
-		Created:      s.Created(),
-		Updated:      s.Updated(),
+		ID:             s.ID(),
+		FamilyID:       s.FamilyID(),
+		Username:       s.Username(),
+		RefreshToken:   s.RefreshToken(),
+		Agent:          s.device.Agent,
+		Browser:        s.device.Browser,
+		BrowserVersion: s.device.BrowserVersion,
+		OS:             s.device.OS,
+		DeviceType:     s.device.Type,
+		IsBot:          s.device.IsBot,
+		IP:             s.IP(),
+		Revoked:        s.Revoked(),
+		Rotated:        s.Rotated(),
+		Expires:        s.Expires(),
+		ImpersonatorID: s.ImpersonatorID(),
+		ClientID:       s.ClientID(),
+		Scope:          s.Scope(),
+		LastActive:     s.LastActive(),
+		AuthTime:       s.AuthTime(),
+		Created:        s.Created(),
+		Updated:        s.Updated(),
 	}
 }
 
-func (s *session) ID() string           { return s.id }
-func (s *session) Username() string     { return s.username }
-func (s *session) RefreshToken() string { return s.refreshToken }
-func (s *session) Agent() string        { return s.agent }
-func (s *session) IP() string           { return s.ip }
-func (s *session) Revoked() bool        { return s.revoked }
-func (s *session) Expires() time.Time   { return s.expires }
-func (s *session) Created() time.Time   { return s.created }
-func (s *session) Updated() time.Time   { return s.updated }
+func (s *session) ID() string              { return s.id }
+func (s *session) FamilyID() string        { return s.familyId }
+func (s *session) Username() string        { return s.username }
+func (s *session) RefreshToken() string    { return s.refreshToken }
+func (s *session) Device() Device          { return s.device }
+func (s *session) IP() string              { return s.ip }
+func (s *session) Revoked() bool           { return s.revoked }
+func (s *session) Rotated() bool           { return s.rotated }
+func (s *session) ImpersonatorID() *string { return s.impersonator }
+func (s *session) ClientID() *string       { return s.clientId }
+func (s *session) Scope() *string          { return s.scope }
+func (s *session) LastActive() time.Time   { return s.lastActive }
+func (s *session) AuthTime() time.Time     { return s.authTime }
+func (s *session) Expires() time.Time      { return s.expires }
+func (s *session) Created() time.Time      { return s.created }
+func (s *session) Updated() time.Time      { return s.updated }
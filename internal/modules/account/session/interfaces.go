@@ -1,13 +1,35 @@
 package session
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrAlreadyRotated is returned when a session was rotated or revoked by a concurrent request
+var ErrAlreadyRotated = errors.New("session already rotated")
 
 type RepositoryInterface interface {
 	Insert(ctx context.Context, entity Entity) error
 	FindByID(ctx context.Context, sessionId string) (*Entity, error)
 	FindByRefreshToken(ctx context.Context, refreshToken string) (*Entity, error)
+	// FindCurrentByFamilyID returns the session of a family that was not rotated yet
+	FindCurrentByFamilyID(ctx context.Context, familyId string) (*Entity, error)
 	Update(ctx context.Context, entity Entity) error
+	// Rotate retires the previous session and inserts its successor atomically, failing with
+	// ErrAlreadyRotated when the previous one is no longer live
+	Rotate(ctx context.Context, previous Entity, next Entity) error
+	RevokeFamily(ctx context.Context, familyId string) error
+	// RevokeAllExceptFamily revokes every session of the user but the ones of the family given
+	RevokeAllExceptFamily(ctx context.Context, username, familyId string) error
+	// RevokeAll revokes every session of the user
+	RevokeAll(ctx context.Context, username string) error
 	Delete(ctx context.Context, sessionId string) error
 	DeleteAll(ctx context.Context, username string) error
 	FindAllByUsername(ctx context.Context, username string) ([]Entity, error)
+	// FindOwnSinceByUsername returns the sessions the user opened since the given time,
+	// leaving out the ones opened by impersonating them
+	FindOwnSinceByUsername(ctx context.Context, username string, since time.Time) ([]Entity, error)
+	// FindAllActiveByUsername returns the usable sessions of a user, oldest first
+	FindAllActiveByUsername(ctx context.Context, username string) ([]Entity, error)
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/bernardinorafael/pkg/transaction"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -51,12 +52,36 @@ func (r repo) FindByID(ctx context.Context, sessionId string) (*Entity, error) {
 		sessionId,
 	)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
 		return nil, fmt.Errorf("error on find session by id: %w", err)
 	}
 
 	return &session, nil
 }
 
+func (r repo) FindCurrentByFamilyID(ctx context.Context, familyId string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var session Entity
+	err := r.db.GetContext(
+		ctx,
+		&session,
+		"SELECT * FROM sessions WHERE family_id = $1 AND rotated = false ORDER BY created DESC LIMIT 1",
+		familyId,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find session by family id: %w", err)
+	}
+
+	return &session, nil
+}
+
 func (r repo) Insert(ctx context.Context, entity Entity) error {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
@@ -66,21 +91,45 @@ func (r repo) Insert(ctx context.Context, entity Entity) error {
 		`
 			INSERT INTO sessions (
 				id,
+				family_id,
 				username,
 				refresh_token,
 				agent,
+				browser,
+				browser_version,
+				os,
+				device_type,
+				is_bot,
 				ip,
 				revoked,
+				rotated,
+				impersonator_id,
+				client_id,
+				scope,
+				last_active,
+				auth_time,
 				expires,
 				created,
 				updated
 			)	VALUES (
 				:id,
+				:family_id,
 				:username,
 				:refresh_token,
 				:agent,
+				:browser,
+				:browser_version,
+				:os,
+				:device_type,
+				:is_bot,
 				:ip,
 				:revoked,
+				:rotated,
+				:impersonator_id,
+				:client_id,
+				:scope,
+				:last_active,
+				:auth_time,
 				:expires,
 				:created,
 				:updated
@@ -132,6 +181,8 @@ func (r repo) Update(ctx context.Context, entity Entity) error {
 				agent = :agent,
 				refresh_token = :refresh_token,
 				revoked = :revoked,
+				rotated = :rotated,
+				auth_time = :auth_time,
 				expires = :expires,
 				updated = :updated
 			WHERE id = :id
@@ -145,6 +196,105 @@ func (r repo) Update(ctx context.Context, entity Entity) error {
 	return nil
 }
 
+func (r repo) Rotate(ctx context.Context, previous Entity, next Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return transaction.ExecTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		// Guard against concurrent rotations of the same refresh token, and against a logout or
+		// a family revocation racing the refresh, which must not get a live successor
+		res, err := tx.ExecContext(
+			ctx,
+			"UPDATE sessions SET rotated = true, updated = $2 WHERE id = $1 AND rotated = false AND revoked = false",
+			previous.ID,
+			previous.Updated,
+		)
+		if err != nil {
+			return fmt.Errorf("error on retire session: %w", err)
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error on retire session: %w", err)
+		}
+		if rows == 0 {
+			return ErrAlreadyRotated
+		}
+
+		_, err = tx.NamedExecContext(
+			ctx,
+			`
+				INSERT INTO sessions (
+					id,
+					family_id,
+					username,
+					refresh_token,
+					agent,
+					browser,
+					browser_version,
+					os,
+					device_type,
+					is_bot,
+					ip,
+					revoked,
+					rotated,
+					impersonator_id,
+					client_id,
+					scope,
+					last_active,
+					auth_time,
+					expires,
+					created,
+					updated
+				) VALUES (
+					:id,
+					:family_id,
+					:username,
+					:refresh_token,
+					:agent,
+					:browser,
+					:browser_version,
+					:os,
+					:device_type,
+					:is_bot,
+					:ip,
+					:revoked,
+					:rotated,
+					:impersonator_id,
+					:client_id,
+					:scope,
+					:last_active,
+					:auth_time,
+					:expires,
+					:created,
+					:updated
+				)
+			`,
+			next,
+		)
+		if err != nil {
+			return fmt.Errorf("error on insert rotated session: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r repo) RevokeFamily(ctx context.Context, familyId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE sessions SET revoked = true, updated = now() WHERE family_id = $1",
+		familyId,
+	)
+	if err != nil {
+		return fmt.Errorf("error on revoke session family: %w", err)
+	}
+
+	return nil
+}
+
 func (r repo) FindAllByUsername(ctx context.Context, username string) ([]Entity, error) {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
@@ -153,7 +303,7 @@ func (r repo) FindAllByUsername(ctx context.Context, username string) ([]Entity,
 	err := r.db.SelectContext(
 		ctx,
 		&sessions,
-		"SELECT * FROM sessions WHERE username = $1 ORDER BY created DESC LIMIT 8",
+		"SELECT * FROM sessions WHERE username = $1 AND rotated = false ORDER BY created DESC LIMIT 8",
 		username,
 	)
 	if err != nil {
@@ -162,3 +312,87 @@ func (r repo) FindAllByUsername(ctx context.Context, username string) ([]Entity,
 
 	return sessions, nil
 }
+
+func (r repo) RevokeAllExceptFamily(ctx context.Context, username, familyId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE sessions SET revoked = true, updated = now() WHERE username = $1 AND family_id != $2 AND revoked = false",
+		username,
+		familyId,
+	)
+	if err != nil {
+		return fmt.Errorf("error on revoke other sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) RevokeAll(ctx context.Context, username string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE sessions SET revoked = true, updated = now() WHERE username = $1 AND revoked = false",
+		username,
+	)
+	if err != nil {
+		return fmt.Errorf("error on revoke all sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) FindOwnSinceByUsername(ctx context.Context, username string, since time.Time) ([]Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var sessions = []Entity{}
+	err := r.db.SelectContext(
+		ctx,
+		&sessions,
+		`
+			SELECT * FROM sessions
+			WHERE username = $1
+			AND rotated = false
+			AND impersonator_id IS NULL
+			AND created > $2
+			ORDER BY created DESC
+		`,
+		username,
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error on find sessions by username: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r repo) FindAllActiveByUsername(ctx context.Context, username string) ([]Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var sessions = []Entity{}
+	err := r.db.SelectContext(
+		ctx,
+		&sessions,
+		`
+			SELECT * FROM sessions
+			WHERE username = $1
+			AND revoked = false
+			AND rotated = false
+			AND expires > now()
+			ORDER BY created ASC
+		`,
+		username,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error on find active sessions by username: %w", err)
+	}
+
+	return sessions, nil
+}
@@ -3,13 +3,31 @@ package session
 import "time"
 
 type Entity struct {
-	ID           string    `json:"id" db:"id"`
-	Username     string    `json:"username" db:"username"`
-	RefreshToken string    `json:"refresh_token" db:"refresh_token"`
-	Agent        string    `json:"agent" db:"agent"`
-	IP           string    `json:"ip" db:"ip"`
-	Revoked      bool      `json:"revoked" db:"revoked"`
-	Expires      time.Time `json:"expires" db:"expires"`
-	Created      time.Time `json:"created" db:"created"`
-	Updated      time.Time `json:"updated" db:"updated"`
+	ID           string `json:"id" db:"id"`
+	FamilyID     string `json:"family_id" db:"family_id"`
+	Username     string `json:"username" db:"username"`
+	RefreshToken string `json:"refresh_token" db:"refresh_token"`
+	// Agent is the raw User-Agent header the device fields are parsed from
+	Agent          string `json:"agent" db:"agent"`
+	Browser        string `json:"browser" db:"browser"`
+	BrowserVersion string `json:"browser_version" db:"browser_version"`
+	OS             string `json:"os" db:"os"`
+	DeviceType     string `json:"device_type" db:"device_type"`
+	IsBot          bool   `json:"is_bot" db:"is_bot"`
+	IP             string `json:"ip" db:"ip"`
+	Revoked        bool   `json:"revoked" db:"revoked"`
+	Rotated        bool   `json:"rotated" db:"rotated"`
+	// ImpersonatorID is the user acting as the session owner, if any
+	ImpersonatorID *string `json:"impersonator_id" db:"impersonator_id"`
+	// ClientID is the OAuth client the session was opened for, if any
+	ClientID *string `json:"client_id" db:"client_id"`
+	// Scope is what the user granted the OAuth client
+	Scope *string `json:"scope" db:"scope"`
+	// LastActive is when the session was opened or its refresh token last used
+	LastActive time.Time `json:"last_active" db:"last_active"`
+	// AuthTime is when the user last signed in or re-authenticated on the session
+	AuthTime time.Time `json:"auth_time" db:"auth_time"`
+	Expires  time.Time `json:"expires" db:"expires"`
+	Created  time.Time `json:"created" db:"created"`
+	Updated  time.Time `json:"updated" db:"updated"`
 }
@@ -0,0 +1,128 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/internal/modules/phone"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+// SendMFACode texts a second factor code to the verified phone of an account with
+// two-factor authentication, to be used on LoginMFA instead of the authenticator app
+func (s svc) SendMFACode(ctx context.Context, mfaToken string) error {
+	// Development drivers don't reach the phone, a code texted through them proves nothing
+	if !s.sms.Delivers() {
+		return NewBadRequestError("sms is not available", nil)
+	}
+
+	challenge, err := token.VerifyChallenge(s.keys, mfaToken, token.PurposeMFA)
+	if err != nil {
+		return NewUnauthorizedError("invalid or expired mfa token", err)
+	}
+
+	account, err := s.repo.FindByID(ctx, challenge.AccountID)
+	if err != nil {
+		return NewBadRequestError("error on find account by id", err)
+	}
+	if account == nil || account.ID == "" {
+		return NewNotFoundError("account not found", nil)
+	}
+	if !account.IsActive {
+		return NewBadRequestError("account is not active", nil)
+	}
+	if isLocked(account.User) {
+		return errLockedAccount
+	}
+	if err := checkBan(account.User); err != nil {
+		return err
+	}
+
+	record, err := s.mfaRepo.FindByAccountID(ctx, account.ID)
+	if err != nil {
+		return NewBadRequestError("error on find mfa by account id", err)
+	}
+	if record == nil || !record.IsEnabled {
+		return NewUnauthorizedError("invalid or expired mfa token", nil)
+	}
+	if !account.User.PhoneVerified {
+		return NewForbiddenError("phone number is not verified", PhoneNotVerified, nil)
+	}
+
+	userId := account.User.ID
+
+	latest, err := s.phoneRepo.FindCodeValidationByUserID(ctx, userId, phone.PurposeMFA)
+	if err != nil {
+		return NewBadRequestError("error on find phone validation", err)
+	}
+	if latest != nil {
+		latest := phone.NewCodeValidationFromEntity(*latest)
+		if latest.InCooldown() {
+			return NewTooManyRequestsError("a code was sent recently, try again later", time.Until(latest.Created().Add(phone.ResendCooldown)), nil)
+		}
+	}
+
+	if err := s.phoneRepo.InvalidateCodesByUserID(ctx, userId, phone.PurposeMFA); err != nil {
+		return NewBadRequestError("error on invalidate previous phone validations", err)
+	}
+
+	newCode, code, err := phone.NewMFACodeValidation(userId, account.User.PhoneNumber, challenge.ID)
+	if err != nil {
+		return NewBadRequestError("error on generate phone validation", err)
+	}
+
+	if err := s.phoneRepo.InsertCodeValidation(ctx, newCode.Store()); err != nil {
+		return NewBadRequestError("error on insert phone validation", err)
+	}
+
+	if err := s.sms.Send(ctx, account.User.PhoneNumber, phone.Message(code)); err != nil {
+		s.log.Errorw(ctx, "error on send mfa code", logger.Err(err))
+		return NewBadRequestError("error on send mfa code", err)
+	}
+
+	return nil
+}
+
+// verifySMSCode reports whether the code is the latest second factor code texted to the user
+// for the login challenge, counting the attempt against it
+func (s svc) verifySMSCode(ctx context.Context, userId, challengeId, code string) (bool, error) {
+	// No code is texted when sms is not available, and recovery codes have another
+	// format, checking them would only waste an attempt
+	if !s.sms.Delivers() || len(code) != phone.CodeLength {
+		return false, nil
+	}
+
+	record, err := s.phoneRepo.FindCodeValidationByUserID(ctx, userId, phone.PurposeMFA)
+	if err != nil {
+		return false, NewBadRequestError("error on find phone validation", err)
+	}
+	if record == nil {
+		return false, nil
+	}
+
+	current := phone.NewCodeValidationFromEntity(*record)
+	if !current.IsForChallenge(challengeId) || !current.IsUsable() {
+		return false, nil
+	}
+
+	// The attempt is counted before the code is compared, so concurrent guesses can't exceed the limit
+	counted, err := s.phoneRepo.CountCodeValidationAttempt(ctx, current.ID(), phone.MaxAttempts)
+	if err != nil {
+		return false, NewBadRequestError("error on update phone validation", err)
+	}
+	if counted == nil || !current.ValidateCode(code) {
+		return false, nil
+	}
+
+	if err := s.phoneRepo.ConsumeCodeValidation(ctx, current.ID()); err != nil {
+		if errors.Is(err, phone.ErrAlreadyConsumed) {
+			return false, nil
+		}
+		return false, NewBadRequestError("error on consume phone validation", err)
+	}
+
+	return true, nil
+}
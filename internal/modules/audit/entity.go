@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+)
+
+var (
+	ErrEmptyAction = errors.New("action is required")
+	ErrEmptyActor  = errors.New("actor is required")
+)
+
+type audit struct {
+	id        string
+	action    string
+	actorId   string
+	actorType string
+	subjectId *string
+	detail    string
+	ip        string
+	agent     string
+	created   time.Time
+}
+
+// New records an action of the actor, subjectId is the user it was performed on, if any
+func New(action, actorId, actorType string, subjectId *string, detail, ip, agent string) (*audit, error) {
+	a := &audit{
+		id:        util.GenID("aud"),
+		action:    action,
+		actorId:   actorId,
+		actorType: actorType,
+		subjectId: subjectId,
+		detail:    detail,
+		ip:        ip,
+		agent:     agent,
+		created:   time.Now(),
+	}
+
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *audit) validate() error {
+	if a.action == "" {
+		return ErrEmptyAction
+	}
+	if a.actorId == "" {
+		return ErrEmptyActor
+	}
+	return nil
+}
+
+func (a *audit) Store() Entity {
+	return Entity{
+		ID:        a.ID(),
+		Action:    a.Action(),
+		ActorID:   a.ActorID(),
+		ActorType: a.ActorType(),
+		SubjectID: a.SubjectID(),
+		Detail:    a.Detail(),
+		IP:        a.IP(),
+		Agent:     a.Agent(),
+		Created:   a.Created(),
+	}
+}
+
+func (a *audit) ID() string         { return a.id }
+func (a *audit) Action() string     { return a.action }
+func (a *audit) ActorID() string    { return a.actorId }
+func (a *audit) ActorType() string  { return a.actorType }
+func (a *audit) SubjectID() *string { return a.subjectId }
+func (a *audit) Detail() string     { return a.detail }
+func (a *audit) IP() string         { return a.ip }
+func (a *audit) Agent() string      { return a.agent }
+func (a *audit) Created() time.Time { return a.created }
@@ -0,0 +1,8 @@
+package audit
+
+import "context"
+
+type RepositoryInterface interface {
+	Insert(ctx context.Context, entity Entity) error
+	FindAllBySubjectID(ctx context.Context, subjectId string) ([]Entity, error)
+}
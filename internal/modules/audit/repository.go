@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) RepositoryInterface {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			INSERT INTO audit_logs (
+				id,
+				action,
+				actor_id,
+				actor_type,
+				subject_id,
+				detail,
+				ip,
+				agent,
+				created
+			) VALUES (
+				:id,
+				:action,
+				:actor_id,
+				:actor_type,
+				:subject_id,
+				:detail,
+				:ip,
+				:agent,
+				:created
+			)
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on insert audit log: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) FindAllBySubjectID(ctx context.Context, subjectId string) ([]Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entities = []Entity{}
+	err := r.db.SelectContext(
+		ctx,
+		&entities,
+		"SELECT * FROM audit_logs WHERE subject_id = $1 ORDER BY created DESC",
+		subjectId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error on find audit logs: %w", err)
+	}
+
+	return entities, nil
+}
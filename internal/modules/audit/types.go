@@ -0,0 +1,22 @@
+package audit
+
+import "time"
+
+// Actions recorded on the audit trail
+const (
+	ActionImpersonationStarted = "impersonation.started"
+	ActionImpersonatedRequest  = "impersonation.request"
+)
+
+type Entity struct {
+	ID     string `json:"id" db:"id"`
+	Action string `json:"action" db:"action"`
+	// ActorID is who really performed the action, never the impersonated user
+	ActorID   string    `json:"actor_id" db:"actor_id"`
+	ActorType string    `json:"actor_type" db:"actor_type"`
+	SubjectID *string   `json:"subject_id" db:"subject_id"`
+	Detail    string    `json:"detail" db:"detail"`
+	IP        string    `json:"ip" db:"ip"`
+	Agent     string    `json:"agent" db:"agent"`
+	Created   time.Time `json:"created" db:"created"`
+}
@@ -32,6 +32,24 @@ func (r *repo) FindByEmail(ctx context.Context, email string) (*Entity, error) {
 	return &entity, nil
 }
 
+func (r *repo) FindAllVerifiedByEmail(ctx context.Context, email string) ([]Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entities []Entity
+	err := r.db.SelectContext(
+		ctx,
+		&entities,
+		"SELECT * FROM emails WHERE LOWER(email) = LOWER($1) AND is_verified = true",
+		email,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find verified emails by email: %w", err)
+	}
+
+	return entities, nil
+}
+
 func (r repo) Insert(ctx context.Context, email Entity) error {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
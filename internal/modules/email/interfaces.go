@@ -9,6 +9,9 @@ type RepositoryInterface interface {
 	Update(ctx context.Context, entity Entity) error
 	FindByID(ctx context.Context, emailId string) (*Entity, error)
 	FindByEmail(ctx context.Context, entity string) (*Entity, error)
+	// FindAllVerifiedByEmail returns the verified emails matching the address regardless of case
+	// Addresses are only unique as typed, so more than one user may hold it
+	FindAllVerifiedByEmail(ctx context.Context, email string) ([]Entity, error)
 	FindAllByUser(ctx context.Context, userId string) ([]Entity, error)
 	Delete(ctx context.Context, userId, emailId string) error
 
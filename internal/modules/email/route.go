@@ -7,43 +7,44 @@ import (
 	"github.com/bernardinorafael/internal/_shared/errors"
 	"github.com/bernardinorafael/internal/_shared/util"
 	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/modules/permission"
 	"github.com/bernardinorafael/pkg/logger"
 	"github.com/go-chi/chi"
 )
 
 type controller struct {
-	ctx       context.Context
-	log       logger.Logger
-	svc       ServiceInterface
-	secretKey string
+	ctx  context.Context
+	log  logger.Logger
+	svc  ServiceInterface
+	auth *middleware.Auth
 }
 
 func NewController(
 	ctx context.Context,
 	log logger.Logger,
 	svc ServiceInterface,
-	secretKey string,
+	auth *middleware.Auth,
 ) *controller {
 	return &controller{
-		ctx:       ctx,
-		log:       log,
-		svc:       svc,
-		secretKey: secretKey,
+		ctx:  ctx,
+		log:  log,
+		svc:  svc,
+		auth: auth,
 	}
 }
 
 func (c controller) RegisterRoute(r *chi.Mux) {
-	m := middleware.NewWithAuth(c.log, c.secretKey)
+	m := c.auth
 
 	r.Route("/api/v1/emails", func(r chi.Router) {
-		r.Use(m.WithAuth)
+		r.Use(m.WithAuth, m.DenyService)
 
-		r.Post("/{userId}", c.create)
-		r.Delete("/{userId}/{emailId}", c.delete)
+		r.With(m.WithPermission(permission.KeyProfileWrite), m.DenyImpersonation).Post("/{userId}", c.create)
+		r.With(m.WithPermission(permission.KeyProfileWrite), m.DenyImpersonation, m.RequireRecentAuth(middleware.RecentAuthMaxAge)).Delete("/{userId}/{emailId}", c.delete)
 	})
 
 	r.Route("/api/v1/emails/validations", func(r chi.Router) {
-		r.Use(m.WithAuth)
+		r.Use(m.WithAuth, m.DenyService, m.WithPermission(permission.KeyProfileWrite))
 		r.Post("/", c.requestValidation)
 		r.Post("/{emailId}", c.validateEmail)
 	})
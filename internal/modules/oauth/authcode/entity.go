@@ -0,0 +1,118 @@
+package authcode
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/pkg/crypto"
+)
+
+const (
+	CodeSize = 32
+	CodeTTL  = time.Minute * 5
+)
+
+type authorizationCode struct {
+	id            string
+	codeHash      string
+	clientId      string
+	accountId     string
+	userId        string
+	redirectURI   string
+	scope         string
+	nonce         string
+	codeChallenge string
+	isUsed        bool
+	created       time.Time
+	expires       time.Time
+}
+
+func NewFromDatabase(entity Entity) *authorizationCode {
+	return &authorizationCode{
+		id:            entity.ID,
+		codeHash:      entity.CodeHash,
+		clientId:      entity.ClientID,
+		accountId:     entity.AccountID,
+		userId:        entity.UserID,
+		redirectURI:   entity.RedirectURI,
+		scope:         entity.Scope,
+		nonce:         entity.Nonce,
+		codeChallenge: entity.CodeChallenge,
+		isUsed:        entity.IsUsed,
+		created:       entity.Created,
+		expires:       entity.Expires,
+	}
+}
+
+// New creates an authorization code and returns it along with the plain code
+// Only the code hash is kept, the plain code is handed to the client
+func New(
+	clientId, accountId, userId, redirectURI, scope, nonce, codeChallenge string,
+) (*authorizationCode, string, error) {
+	code, err := crypto.GenerateToken(CodeSize)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &authorizationCode{
+		id:            util.GenID("acd"),
+		codeHash:      crypto.HashToken(code),
+		clientId:      clientId,
+		accountId:     accountId,
+		userId:        userId,
+		redirectURI:   redirectURI,
+		scope:         scope,
+		nonce:         nonce,
+		codeChallenge: codeChallenge,
+		isUsed:        false,
+		created:       time.Now(),
+		expires:       time.Now().Add(CodeTTL),
+	}, code, nil
+}
+
+// VerifyChallenge checks the PKCE verifier against the S256 challenge the code was issued for
+func (a *authorizationCode) VerifyChallenge(verifier string) bool {
+	if verifier == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(challenge), []byte(a.codeChallenge)) == 1
+}
+
+func (a *authorizationCode) IsExpired() bool {
+	return time.Now().After(a.expires)
+}
+
+func (a *authorizationCode) Store() Entity {
+	return Entity{
+		ID:            a.ID(),
+		CodeHash:      a.CodeHash(),
+		ClientID:      a.ClientID(),
+		AccountID:     a.AccountID(),
+		UserID:        a.UserID(),
+		RedirectURI:   a.RedirectURI(),
+		Scope:         a.Scope(),
+		Nonce:         a.Nonce(),
+		CodeChallenge: a.CodeChallenge(),
+		IsUsed:        a.IsUsed(),
+		Created:       a.Created(),
+		Expires:       a.Expires(),
+	}
+}
+
+func (a *authorizationCode) ID() string            { return a.id }
+func (a *authorizationCode) CodeHash() string      { return a.codeHash }
+func (a *authorizationCode) ClientID() string      { return a.clientId }
+func (a *authorizationCode) AccountID() string     { return a.accountId }
+func (a *authorizationCode) UserID() string        { return a.userId }
+func (a *authorizationCode) RedirectURI() string   { return a.redirectURI }
+func (a *authorizationCode) Scope() string         { return a.scope }
+func (a *authorizationCode) Nonce() string         { return a.nonce }
+func (a *authorizationCode) CodeChallenge() string { return a.codeChallenge }
+func (a *authorizationCode) IsUsed() bool          { return a.isUsed }
+func (a *authorizationCode) Created() time.Time    { return a.created }
+func (a *authorizationCode) Expires() time.Time    { return a.expires }
@@ -0,0 +1,17 @@
+package authcode
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAlreadyUsed is returned by Consume when the code was exchanged before
+var ErrAlreadyUsed = errors.New("authorization code already used")
+
+type RepositoryInterface interface {
+	Insert(ctx context.Context, entity Entity) error
+	FindByCodeHash(ctx context.Context, codeHash string) (*Entity, error)
+	// Consume marks the code as used, only one of concurrent exchanges succeeds
+	Consume(ctx context.Context, codeId string) error
+	DeleteExpired(ctx context.Context) error
+}
@@ -0,0 +1,120 @@
+package authcode
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) RepositoryInterface {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			INSERT INTO oauth_authorization_codes (
+				id,
+				code_hash,
+				client_id,
+				account_id,
+				user_id,
+				redirect_uri,
+				scope,
+				nonce,
+				code_challenge,
+				is_used,
+				created,
+				expires
+			) VALUES (
+				:id,
+				:code_hash,
+				:client_id,
+				:account_id,
+				:user_id,
+				:redirect_uri,
+				:scope,
+				:nonce,
+				:code_challenge,
+				:is_used,
+				:created,
+				:expires
+			)
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on insert authorization code: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) FindByCodeHash(ctx context.Context, codeHash string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(
+		ctx,
+		&entity,
+		"SELECT * FROM oauth_authorization_codes WHERE code_hash = $1",
+		codeHash,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find authorization code: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) Consume(ctx context.Context, codeId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE oauth_authorization_codes SET is_used = true WHERE id = $1 AND is_used = false",
+		codeId,
+	)
+	if err != nil {
+		return fmt.Errorf("error on consume authorization code: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error on consume authorization code: %w", err)
+	}
+	if affected == 0 {
+		return ErrAlreadyUsed
+	}
+
+	return nil
+}
+
+func (r repo) DeleteExpired(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM oauth_authorization_codes WHERE expires < now()")
+	if err != nil {
+		return fmt.Errorf("error on delete expired authorization codes: %w", err)
+	}
+
+	return nil
+}
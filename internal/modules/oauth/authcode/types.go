@@ -0,0 +1,18 @@
+package authcode
+
+import "time"
+
+type Entity struct {
+	ID            string    `json:"id" db:"id"`
+	CodeHash      string    `json:"-" db:"code_hash"`
+	ClientID      string    `json:"client_id" db:"client_id"`
+	AccountID     string    `json:"account_id" db:"account_id"`
+	UserID        string    `json:"user_id" db:"user_id"`
+	RedirectURI   string    `json:"redirect_uri" db:"redirect_uri"`
+	Scope         string    `json:"scope" db:"scope"`
+	Nonce         string    `json:"nonce" db:"nonce"`
+	CodeChallenge string    `json:"-" db:"code_challenge"`
+	IsUsed        bool      `json:"is_used" db:"is_used"`
+	Created       time.Time `json:"created" db:"created"`
+	Expires       time.Time `json:"expires" db:"expires"`
+}
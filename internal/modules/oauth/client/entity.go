@@ -0,0 +1,118 @@
+package client
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/pkg/crypto"
+)
+
+const SecretSize = 32
+
+type client struct {
+	id             string
+	name           string
+	secretHash     *string
+	redirectURIs   []string
+	isConfidential bool
+	created        time.Time
+	updated        time.Time
+}
+
+func NewFromDatabase(entity Entity) *client {
+	return &client{
+		id:             entity.ID,
+		name:           entity.Name,
+		secretHash:     entity.SecretHash,
+		redirectURIs:   entity.RedirectURIs,
+		isConfidential: entity.IsConfidential,
+		created:        entity.Created,
+		updated:        entity.Updated,
+	}
+}
+
+// New registers a client allowed to redirect to the given URIs. Confidential
+// clients get a secret, returned in plain text only here, only its hash is kept
+func New(name string, redirectURIs []string, isConfidential bool) (*client, string, error) {
+	c := &client{
+		id:             util.GenID("cli"),
+		name:           name,
+		redirectURIs:   redirectURIs,
+		isConfidential: isConfidential,
+		created:        time.Now(),
+		updated:        time.Now(),
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, "", err
+	}
+
+	if !isConfidential {
+		return c, "", nil
+	}
+
+	secret, err := crypto.GenerateToken(SecretSize)
+	if err != nil {
+		return nil, "", err
+	}
+	hash := crypto.HashToken(secret)
+	c.secretHash = &hash
+
+	return c, secret, nil
+}
+
+// AllowsRedirect reports whether the URI is registered, it must match exactly
+func (c *client) AllowsRedirect(uri string) bool {
+	return slices.Contains(c.redirectURIs, uri)
+}
+
+// Authenticate checks the secret of a confidential client
+// Public clients have nothing to check, PKCE is what protects them
+func (c *client) Authenticate(secret string) bool {
+	if !c.isConfidential {
+		return true
+	}
+	if c.secretHash == nil || secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(*c.secretHash), []byte(crypto.HashToken(secret))) == 1
+}
+
+func (c *client) validate() error {
+	if c.name == "" {
+		return errors.New("name cannot be empty")
+	}
+	if len(c.redirectURIs) == 0 {
+		return errors.New("at least one redirect uri is required")
+	}
+	for _, uri := range c.redirectURIs {
+		parsed, err := url.Parse(uri)
+		if err != nil || !parsed.IsAbs() || parsed.Fragment != "" {
+			return errors.New("redirect uris must be absolute and without fragment")
+		}
+	}
+	return nil
+}
+
+func (c *client) Store() Entity {
+	return Entity{
+		ID:             c.ID(),
+		Name:           c.Name(),
+		SecretHash:     c.secretHash,
+		RedirectURIs:   c.RedirectURIs(),
+		IsConfidential: c.IsConfidential(),
+		Created:        c.Created(),
+		Updated:        c.Updated(),
+	}
+}
+
+func (c *client) ID() string             { return c.id }
+func (c *client) Name() string           { return c.name }
+func (c *client) RedirectURIs() []string { return c.redirectURIs }
+func (c *client) IsConfidential() bool   { return c.isConfidential }
+func (c *client) Created() time.Time     { return c.created }
+func (c *client) Updated() time.Time     { return c.updated }
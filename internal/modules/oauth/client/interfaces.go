@@ -0,0 +1,10 @@
+package client
+
+import "context"
+
+type RepositoryInterface interface {
+	Insert(ctx context.Context, entity Entity) error
+	FindByID(ctx context.Context, clientId string) (*Entity, error)
+	FindAll(ctx context.Context) ([]Entity, error)
+	Delete(ctx context.Context, clientId string) error
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) RepositoryInterface {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			INSERT INTO oauth_clients (
+				id,
+				name,
+				secret_hash,
+				redirect_uris,
+				is_confidential,
+				created,
+				updated
+			) VALUES (
+				:id,
+				:name,
+				:secret_hash,
+				:redirect_uris,
+				:is_confidential,
+				:created,
+				:updated
+			)
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on insert oauth client: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) FindByID(ctx context.Context, clientId string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(ctx, &entity, "SELECT * FROM oauth_clients WHERE id = $1", clientId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find oauth client: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) FindAll(ctx context.Context) ([]Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entities = []Entity{}
+	err := r.db.SelectContext(ctx, &entities, "SELECT * FROM oauth_clients ORDER BY created DESC")
+	if err != nil {
+		return nil, fmt.Errorf("error on find all oauth clients: %w", err)
+	}
+
+	return entities, nil
+}
+
+func (r repo) Delete(ctx context.Context, clientId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM oauth_clients WHERE id = $1", clientId)
+	if err != nil {
+		return fmt.Errorf("error on delete oauth client: %w", err)
+	}
+
+	return nil
+}
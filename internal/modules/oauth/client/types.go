@@ -0,0 +1,17 @@
+package client
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type Entity struct {
+	ID             string         `json:"id" db:"id"`
+	Name           string         `json:"name" db:"name"`
+	SecretHash     *string        `json:"-" db:"secret_hash"`
+	RedirectURIs   pq.StringArray `json:"redirect_uris" db:"redirect_uris"`
+	IsConfidential bool           `json:"is_confidential" db:"is_confidential"`
+	Created        time.Time      `json:"created" db:"created"`
+	Updated        time.Time      `json:"updated" db:"updated"`
+}
@@ -0,0 +1,45 @@
+package oauth
+
+import "net/http"
+
+// Error codes of RFC 6749, section 5.2 and 4.1.2.1
+const (
+	InvalidRequest          = "invalid_request"
+	InvalidClient           = "invalid_client"
+	InvalidGrant            = "invalid_grant"
+	InvalidScope            = "invalid_scope"
+	UnsupportedGrantType    = "unsupported_grant_type"
+	UnsupportedResponseType = "unsupported_response_type"
+	AccessDenied            = "access_denied"
+	ServerError             = "server_error"
+)
+
+// Error is reported to OAuth clients in the format they expect, instead of
+// the ApplicationError used by the rest of the API
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	status      int
+	err         error
+}
+
+func newError(code, description string, err error) *Error {
+	status := http.StatusBadRequest
+	switch code {
+	case InvalidClient:
+		status = http.StatusUnauthorized
+	case ServerError:
+		status = http.StatusInternalServerError
+	}
+	return &Error{Code: code, Description: description, status: status, err: err}
+}
+
+func (e *Error) Error() string {
+	if e.err != nil {
+		return e.Code + ": " + e.Description + ": " + e.err.Error()
+	}
+	return e.Code + ": " + e.Description
+}
+
+func (e *Error) Unwrap() error   { return e.err }
+func (e *Error) StatusCode() int { return e.status }
@@ -0,0 +1,22 @@
+package oauth
+
+import (
+	"context"
+
+	"github.com/bernardinorafael/internal/modules/oauth/client"
+)
+
+type ServiceInterface interface {
+	CreateClient(ctx context.Context, input CreateClientDTO) (*CreatedClient, error)
+	GetAllClients(ctx context.Context) ([]client.Entity, error)
+	DeleteClient(ctx context.Context, clientId string) error
+	// StartAuthorization validates the client of an authorization request and returns
+	// the front end page where the user signs in and grants the authorization
+	StartAuthorization(ctx context.Context, clientId, redirectURI, rawQuery string) (redirectTo string, err error)
+	// Authorize issues an authorization code to the signed in user, returning where
+	// the user agent must be redirected to, with either the code or the error
+	Authorize(ctx context.Context, input AuthorizeDTO) (redirectTo string, err error)
+	Token(ctx context.Context, input TokenDTO) (*TokenResponse, error)
+	UserInfo(ctx context.Context) (*UserInfo, error)
+	Discovery() Discovery
+}
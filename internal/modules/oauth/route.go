@@ -0,0 +1,189 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/modules/permission"
+	"github.com/bernardinorafael/pkg/logger"
+	"github.com/go-chi/chi"
+)
+
+type controller struct {
+	ctx  context.Context
+	log  logger.Logger
+	svc  ServiceInterface
+	auth *middleware.Auth
+}
+
+func NewController(
+	ctx context.Context,
+	log logger.Logger,
+	svc ServiceInterface,
+	auth *middleware.Auth,
+) *controller {
+	return &controller{ctx, log, svc, auth}
+}
+
+func (c controller) RegisterRoute(r *chi.Mux) {
+	m := c.auth
+
+	r.Get("/.well-known/openid-configuration", c.discovery)
+
+	// Protocol endpoints, called by the OAuth clients
+	r.Route("/oauth", func(r chi.Router) {
+		r.Get("/authorize", c.startAuthorization)
+		r.Post("/token", c.token)
+		r.With(m.WithClientAuth, m.DenyService).Get("/userinfo", c.userInfo)
+	})
+
+	r.Route("/api/v1/oauth", func(r chi.Router) {
+		r.Use(m.WithAuth, m.DenyService)
+
+		// Called by the front end once the user granted the authorization
+		// Granting third parties access to the account is up to the user alone
+		r.With(m.DenyImpersonation).Post("/authorize", c.authorize)
+
+		r.Group(func(r chi.Router) {
+			r.Use(m.WithPermission(permission.KeyPlatformOAuthClients))
+
+			r.Post("/clients", c.createClient)
+			r.Get("/clients", c.getAllClients)
+			r.Delete("/clients/{clientId}", c.deleteClient)
+		})
+	})
+}
+
+func (c controller) discovery(w http.ResponseWriter, r *http.Request) {
+	util.WriteJSONResponse(w, http.StatusOK, c.svc.Discovery())
+}
+
+func (c controller) startAuthorization(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	redirectTo, err := c.svc.StartAuthorization(r.Context(), q.Get("client_id"), q.Get("redirect_uri"), r.URL.RawQuery)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, redirectTo, http.StatusFound)
+}
+
+func (c controller) authorize(w http.ResponseWriter, r *http.Request) {
+	var body AuthorizeDTO
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	redirectTo, err := c.svc.Authorize(r.Context(), body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, map[string]string{"redirect_to": redirectTo})
+}
+
+func (c controller) token(w http.ResponseWriter, r *http.Request) {
+	// Tokens must never be cached, RFC 6749 section 5.1
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		writeError(w, newError(InvalidRequest, "request body must be form encoded", err))
+		return
+	}
+
+	input := TokenDTO{
+		GrantType:    r.PostForm.Get("grant_type"),
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		UserAgent:    r.UserAgent(),
+		IP:           r.RemoteAddr,
+	}
+	// client_secret_basic takes precedence over credentials in the body
+	if id, secret, ok := r.BasicAuth(); ok {
+		input.ClientID = id
+		input.ClientSecret = secret
+	}
+
+	res, err := c.svc.Token(r.Context(), input)
+	if err != nil {
+		c.log.Errorw(r.Context(), "oauth token request failed", logger.Err(err))
+		writeError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, res)
+}
+
+func (c controller) userInfo(w http.ResponseWriter, r *http.Request) {
+	info, err := c.svc.UserInfo(r.Context())
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, info)
+}
+
+func (c controller) createClient(w http.ResponseWriter, r *http.Request) {
+	var body CreateClientDTO
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	created, err := c.svc.CreateClient(r.Context(), body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusCreated, map[string]any{"client": created})
+}
+
+func (c controller) getAllClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := c.svc.GetAllClients(r.Context())
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, map[string]any{"clients": clients})
+}
+
+func (c controller) deleteClient(w http.ResponseWriter, r *http.Request) {
+	err := c.svc.DeleteClient(r.Context(), chi.URLParam(r, "clientId"))
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+// writeError writes errors of the token endpoint in the format of RFC 6749 section 5.2
+func writeError(w http.ResponseWriter, err error) {
+	var oauthErr *Error
+	if !errors.As(err, &oauthErr) {
+		oauthErr = newError(ServerError, "unexpected error", err)
+	}
+
+	if oauthErr.Code == InvalidClient {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	util.WriteJSONResponse(w, oauthErr.StatusCode(), oauthErr)
+}
@@ -0,0 +1,511 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/internal/modules/account"
+	"github.com/bernardinorafael/internal/modules/oauth/authcode"
+	"github.com/bernardinorafael/internal/modules/oauth/client"
+	"github.com/bernardinorafael/internal/modules/permission"
+	"github.com/bernardinorafael/internal/modules/serviceaccount"
+	"github.com/bernardinorafael/internal/modules/user"
+	"github.com/bernardinorafael/pkg/crypto"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+const (
+	idTokenDuration = time.Hour
+
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+	ScopePhone   = "phone"
+
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+
+	CodeChallengeS256 = "S256"
+)
+
+var supportedScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail, ScopePhone}
+
+type svc struct {
+	log        logger.Logger
+	clientRepo client.RepositoryInterface
+	codeRepo   authcode.RepositoryInterface
+	userRepo   user.RepositoryInterface
+	accountSvc account.ServiceInterface
+	serviceSvc serviceaccount.ServiceInterface
+	permRepo   permission.RepositoryInterface
+	keys       *token.KeySet
+	issuer     string
+	// frontEndURL hosts the page where users sign in and grant authorizations
+	frontEndURL string
+}
+
+func NewService(
+	log logger.Logger,
+	clientRepo client.RepositoryInterface,
+	codeRepo authcode.RepositoryInterface,
+	userRepo user.RepositoryInterface,
+	accountSvc account.ServiceInterface,
+	serviceSvc serviceaccount.ServiceInterface,
+	permRepo permission.RepositoryInterface,
+	keys *token.KeySet,
+	issuer string,
+	frontEndURL string,
+) ServiceInterface {
+	return &svc{
+		log:         log,
+		clientRepo:  clientRepo,
+		codeRepo:    codeRepo,
+		userRepo:    userRepo,
+		accountSvc:  accountSvc,
+		serviceSvc:  serviceSvc,
+		permRepo:    permRepo,
+		keys:        keys,
+		issuer:      strings.TrimSuffix(issuer, "/"),
+		frontEndURL: frontEndURL,
+	}
+}
+
+func (s svc) CreateClient(ctx context.Context, input CreateClientDTO) (*CreatedClient, error) {
+	if err := s.checkClientAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	newClient, secret, err := client.New(input.Name, input.RedirectURIs, input.IsConfidential)
+	if err != nil {
+		msg := "failed to validate oauth client"
+		s.log.Errorw(ctx, msg, logger.Err(err))
+		return nil, NewValidationFieldError(msg, err, nil)
+	}
+
+	if err := s.clientRepo.Insert(ctx, newClient.Store()); err != nil {
+		return nil, NewBadRequestError("error on insert oauth client", err)
+	}
+
+	s.log.Infow(ctx, "oauth client created", logger.String("client_id", newClient.ID()))
+
+	return &CreatedClient{
+		ID:           newClient.ID(),
+		Name:         newClient.Name(),
+		RedirectURIs: newClient.RedirectURIs(),
+		Secret:       secret,
+	}, nil
+}
+
+func (s svc) GetAllClients(ctx context.Context) ([]client.Entity, error) {
+	if err := s.checkClientAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	clients, err := s.clientRepo.FindAll(ctx)
+	if err != nil {
+		return nil, NewBadRequestError("error on find all oauth clients", err)
+	}
+	return clients, nil
+}
+
+func (s svc) DeleteClient(ctx context.Context, clientId string) error {
+	if err := s.checkClientAdmin(ctx); err != nil {
+		return err
+	}
+
+	record, err := s.clientRepo.FindByID(ctx, clientId)
+	if err != nil {
+		return NewBadRequestError("error on find oauth client", err)
+	}
+	if record == nil {
+		return NewNotFoundError("oauth client not found", nil)
+	}
+
+	if err := s.clientRepo.Delete(ctx, clientId); err != nil {
+		return NewBadRequestError("error on delete oauth client", err)
+	}
+
+	return nil
+}
+
+// checkClientAdmin allows only platform admins to manage the OAuth clients, which sign in
+// users of every organization
+func (s svc) checkClientAdmin(ctx context.Context) error {
+	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
+	if !ok {
+		return NewBadRequestError("user ID not found in context", nil)
+	}
+	if claims.IsService() || claims.IsImpersonated() {
+		return NewForbiddenError("missing permission "+permission.KeyPlatformOAuthClients, MissingPermission, nil)
+	}
+
+	granted, err := s.permRepo.HasPlatformPermission(ctx, claims.UserID, permission.KeyPlatformOAuthClients)
+	if err != nil {
+		return NewBadRequestError("error on find platform permissions by user id", err)
+	}
+	if !granted {
+		return NewForbiddenError("missing permission "+permission.KeyPlatformOAuthClients, MissingPermission, nil)
+	}
+
+	return nil
+}
+
+func (s svc) StartAuthorization(ctx context.Context, clientId, redirectURI, rawQuery string) (string, error) {
+	if err := s.checkRedirect(ctx, clientId, redirectURI); err != nil {
+		return "", err
+	}
+	// The page posts the same parameters back to Authorize once the user is signed in
+	return s.frontEndURL + "/oauth/authorize?" + rawQuery, nil
+}
+
+// checkRedirect validates the client and the redirect URI of an authorization request,
+// nothing can be redirected back to the client until they are trusted
+func (s svc) checkRedirect(ctx context.Context, clientId, redirectURI string) error {
+	record, err := s.clientRepo.FindByID(ctx, clientId)
+	if err != nil {
+		return NewBadRequestError("error on find oauth client", err)
+	}
+	if record == nil {
+		return NewBadRequestError("unknown oauth client", nil)
+	}
+	if !client.NewFromDatabase(*record).AllowsRedirect(redirectURI) {
+		return NewBadRequestError("redirect uri is not registered for the client", nil)
+	}
+	return nil
+}
+
+func (s svc) Authorize(ctx context.Context, input AuthorizeDTO) (string, error) {
+	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
+	if !ok {
+		return "", NewBadRequestError("user ID not found in context", nil)
+	}
+
+	if err := s.checkRedirect(ctx, input.ClientID, input.RedirectURI); err != nil {
+		return "", err
+	}
+
+	// From here on errors are reported to the client through the redirect
+	fail := func(code, description string) (string, error) {
+		return redirectURL(input.RedirectURI, url.Values{
+			"error":             {code},
+			"error_description": {description},
+			"state":             {input.State},
+		}), nil
+	}
+
+	if input.ResponseType != "code" {
+		return fail(UnsupportedResponseType, "only the authorization code flow is supported")
+	}
+	if input.CodeChallenge == "" || input.CodeChallengeMethod != CodeChallengeS256 {
+		return fail(InvalidRequest, "a PKCE code challenge using S256 is required")
+	}
+
+	scope, ok := normalizeScope(input.Scope)
+	if !ok {
+		return fail(InvalidScope, "unsupported scope requested")
+	}
+
+	// Expired codes are useless, this is a good moment to get rid of them
+	if err := s.codeRepo.DeleteExpired(ctx); err != nil {
+		s.log.Errorw(ctx, "error on delete expired authorization codes", logger.Err(err))
+	}
+
+	newCode, code, err := authcode.New(
+		input.ClientID,
+		claims.AccountID,
+		claims.UserID,
+		input.RedirectURI,
+		scope,
+		input.Nonce,
+		input.CodeChallenge,
+	)
+	if err != nil {
+		return "", NewBadRequestError("error on generate authorization code", err)
+	}
+
+	if err := s.codeRepo.Insert(ctx, newCode.Store()); err != nil {
+		return "", NewBadRequestError("error on insert authorization code", err)
+	}
+
+	s.log.Infow(
+		ctx,
+		"authorization code issued",
+		logger.String("client_id", input.ClientID),
+		logger.String("user_id", claims.UserID),
+	)
+
+	return redirectURL(input.RedirectURI, url.Values{"code": {code}, "state": {input.State}}), nil
+}
+
+func (s svc) Token(ctx context.Context, input TokenDTO) (*TokenResponse, error) {
+	// Service accounts are clients of their own, they don't act on behalf of anyone
+	if input.GrantType == GrantClientCredentials {
+		return s.clientCredentials(ctx, input)
+	}
+
+	record, err := s.clientRepo.FindByID(ctx, input.ClientID)
+	if err != nil {
+		return nil, newError(ServerError, "error on find client", err)
+	}
+	if record == nil {
+		return nil, newError(InvalidClient, "client authentication failed", nil)
+	}
+	authClient := client.NewFromDatabase(*record)
+	if !authClient.Authenticate(input.ClientSecret) {
+		return nil, newError(InvalidClient, "client authentication failed", nil)
+	}
+
+	switch input.GrantType {
+	case GrantAuthorizationCode:
+		return s.exchangeCode(ctx, authClient.ID(), input)
+	case GrantRefreshToken:
+		return s.refresh(ctx, input)
+	default:
+		return nil, newError(UnsupportedGrantType, "grant type is not supported", nil)
+	}
+}
+
+func (s svc) exchangeCode(ctx context.Context, clientId string, input TokenDTO) (*TokenResponse, error) {
+	errInvalidCode := newError(InvalidGrant, "authorization code is invalid or expired", nil)
+
+	record, err := s.codeRepo.FindByCodeHash(ctx, crypto.HashToken(input.Code))
+	if err != nil {
+		return nil, newError(ServerError, "error on find authorization code", err)
+	}
+	if record == nil {
+		return nil, errInvalidCode
+	}
+
+	code := authcode.NewFromDatabase(*record)
+	if code.IsExpired() || code.ClientID() != clientId || code.RedirectURI() != input.RedirectURI {
+		return nil, errInvalidCode
+	}
+	if !code.VerifyChallenge(input.CodeVerifier) {
+		return nil, newError(InvalidGrant, "code verifier does not match the code challenge", nil)
+	}
+
+	if err := s.codeRepo.Consume(ctx, code.ID()); err != nil {
+		if errors.Is(err, authcode.ErrAlreadyUsed) {
+			s.log.Criticalw(
+				ctx,
+				"security event: authorization code reuse detected",
+				logger.String("client_id", clientId),
+				logger.String("user_id", code.UserID()),
+			)
+			return nil, errInvalidCode
+		}
+		return nil, newError(ServerError, "error on consume authorization code", err)
+	}
+
+	session, err := s.accountSvc.IssueClientSession(ctx, code.AccountID(), clientId, code.Scope(), input.UserAgent, input.IP)
+	if err != nil {
+		var appErr ApplicationError
+		if errors.As(err, &appErr) && appErr.HTTPCode < 500 {
+			return nil, newError(InvalidGrant, appErr.Msg, err)
+		}
+		return nil, newError(ServerError, "error on issue session", err)
+	}
+
+	res := &TokenResponse{
+		AccessToken:  session.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    session.AccessTokenExpires - time.Now().Unix(),
+		RefreshToken: session.RefreshToken,
+		Scope:        code.Scope(),
+	}
+
+	scopes := strings.Fields(code.Scope())
+	if slices.Contains(scopes, ScopeOpenID) {
+		idToken, err := s.idToken(ctx, clientId, code.UserID(), code.Nonce(), scopes)
+		if err != nil {
+			return nil, newError(ServerError, "error on generate id token", err)
+		}
+		res.IDToken = idToken
+	}
+
+	return res, nil
+}
+
+func (s svc) refresh(ctx context.Context, input TokenDTO) (*TokenResponse, error) {
+	if input.RefreshToken == "" {
+		return nil, newError(InvalidRequest, "refresh token is required", nil)
+	}
+
+	// The session is bound to the client, so a refresh token only works for the client it was issued to
+	renewed, err := s.accountSvc.RenewAccessToken(ctx, input.RefreshToken, input.ClientID)
+	if err != nil {
+		return nil, newError(InvalidGrant, "refresh token is invalid or expired", err)
+	}
+
+	return &TokenResponse{
+		AccessToken:  renewed.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    renewed.AccessTokenExpires - time.Now().Unix(),
+		RefreshToken: renewed.RefreshToken,
+		Scope:        renewed.Scope,
+	}, nil
+}
+
+func (s svc) clientCredentials(ctx context.Context, input TokenDTO) (*TokenResponse, error) {
+	issued, err := s.serviceSvc.IssueToken(ctx, input.ClientID, input.ClientSecret)
+	if err != nil {
+		var appErr ApplicationError
+		if errors.As(err, &appErr) && appErr.HTTPCode == http.StatusUnauthorized {
+			return nil, newError(InvalidClient, "client authentication failed", err)
+		}
+		return nil, newError(ServerError, "error on issue service token", err)
+	}
+
+	return &TokenResponse{
+		AccessToken: issued.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   issued.ExpiresIn,
+	}, nil
+}
+
+// idToken signs an ID token for the user, with the profile claims the scopes grant
+func (s svc) idToken(ctx context.Context, clientId, userId, nonce string, scopes []string) (string, error) {
+	found, err := s.userRepo.FindByID(ctx, userId)
+	if err != nil {
+		return "", err
+	}
+	if found == nil {
+		return "", errors.New("user not found")
+	}
+
+	claims := token.NewIDClaims(s.issuer, clientId, userId, nonce, idTokenDuration)
+	if slices.Contains(scopes, ScopeProfile) {
+		claims.Name = found.FullName
+		claims.PreferredUsername = found.Username
+		if found.AvatarURL != nil {
+			claims.Picture = *found.AvatarURL
+		}
+	}
+	if slices.Contains(scopes, ScopeEmail) {
+		claims.Email = found.EmailAddress
+	}
+	if slices.Contains(scopes, ScopePhone) {
+		claims.PhoneNumber = found.PhoneNumber
+	}
+
+	return token.GenerateIDToken(s.keys, claims)
+}
+
+func (s svc) UserInfo(ctx context.Context) (*UserInfo, error) {
+	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
+	if !ok {
+		return nil, NewBadRequestError("user ID not found in context", nil)
+	}
+
+	found, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, NewBadRequestError("error on get user by id", err)
+	}
+	if found == nil {
+		return nil, NewNotFoundError("user not found", nil)
+	}
+
+	// Tokens of the first-party apps see the whole profile, the ones of clients what their scope grants
+	scopes := strings.Fields(claims.Scope)
+	grants := func(scope string) bool {
+		return !claims.IsDelegated() || slices.Contains(scopes, scope)
+	}
+	if !grants(ScopeOpenID) {
+		return nil, NewForbiddenError("token was not granted the openid scope", MissingPermission, nil)
+	}
+
+	info := &UserInfo{Subject: found.ID}
+	if grants(ScopeProfile) {
+		info.Name = found.FullName
+		info.PreferredUsername = found.Username
+		if found.AvatarURL != nil {
+			info.Picture = *found.AvatarURL
+		}
+	}
+	if grants(ScopeEmail) {
+		info.Email = found.EmailAddress
+	}
+	if grants(ScopePhone) {
+		info.PhoneNumber = found.PhoneNumber
+	}
+
+	return info, nil
+}
+
+func (s svc) Discovery() Discovery {
+	return Discovery{
+		Issuer:                            s.issuer,
+		AuthorizationEndpoint:             s.issuer + "/oauth/authorize",
+		TokenEndpoint:                     s.issuer + "/oauth/token",
+		UserInfoEndpoint:                  s.issuer + "/oauth/userinfo",
+		JWKSURI:                           s.issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   supportedScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{GrantAuthorizationCode, GrantRefreshToken, GrantClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"EdDSA"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{CodeChallengeS256},
+		ClaimsSupported: []string{
+			"sub",
+			"iss",
+			"aud",
+			"exp",
+			"iat",
+			"nonce",
+			"name",
+			"preferred_username",
+			"picture",
+			"email",
+			"phone_number",
+		},
+	}
+}
+
+// normalizeScope removes duplicated scopes, an empty scope defaults to openid
+func normalizeScope(scope string) (string, bool) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return ScopeOpenID, true
+	}
+
+	var granted []string
+	for _, v := range requested {
+		if !slices.Contains(supportedScopes, v) {
+			return "", false
+		}
+		if !slices.Contains(granted, v) {
+			granted = append(granted, v)
+		}
+	}
+
+	return strings.Join(granted, " "), true
+}
+
+// redirectURL appends the params to the redirect URI, keeping the query it already has
+func redirectURL(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	q := u.Query()
+	for k, v := range params {
+		if len(v) == 0 || v[0] == "" {
+			continue
+		}
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
@@ -12,4 +12,6 @@ type ServiceInterface interface {
 	CreateOrg(ctx context.Context, name, ownerId string) error
 	GetOrgByID(ctx context.Context, orgId string) (*EntityWithOwner, error)
 	GetOrgBySlug(ctx context.Context, slug string) (*EntityWithOwner, error)
+	// CheckOwner allows only the owner of the organization, for the settings nobody else may manage
+	CheckOwner(ctx context.Context, orgId string) error
 }
@@ -7,40 +7,41 @@ import (
 	. "github.com/bernardinorafael/internal/_shared/errors"
 	"github.com/bernardinorafael/internal/_shared/util"
 	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/modules/permission"
 	"github.com/bernardinorafael/pkg/logger"
 	"github.com/go-chi/chi"
 )
 
 type controller struct {
-	ctx       context.Context
-	log       logger.Logger
-	svc       ServiceInterface
-	secretKey string
+	ctx  context.Context
+	log  logger.Logger
+	svc  ServiceInterface
+	auth *middleware.Auth
 }
 
 func NewController(
 	ctx context.Context,
 	log logger.Logger,
 	svc ServiceInterface,
-	secretKey string,
+	auth *middleware.Auth,
 ) *controller {
 	return &controller{
-		ctx:       ctx,
-		log:       log,
-		svc:       svc,
-		secretKey: secretKey,
+		ctx:  ctx,
+		log:  log,
+		svc:  svc,
+		auth: auth,
 	}
 }
 
 func (c controller) RegisterRoute(r *chi.Mux) {
-	m := middleware.NewWithAuth(c.log, c.secretKey)
+	m := c.auth
 
 	r.Route("/api/v1/organizations", func(r chi.Router) {
 		r.Use(m.WithAuth)
 
-		r.Post("/", c.CreateOrg)
-		r.Get("/{orgId}", c.GetOrgByID)
-		r.Get("/slug/{slug}", c.GetOrgBySlug)
+		r.With(m.DenyService, m.WithPermission(permission.KeyOrganizationsWrite)).Post("/", c.CreateOrg)
+		r.With(m.WithOrgScope("orgId"), m.WithPermission(permission.KeyOrganizationsRead)).Get("/{orgId}", c.GetOrgByID)
+		r.With(m.DenyService, m.WithPermission(permission.KeyOrganizationsRead)).Get("/slug/{slug}", c.GetOrgBySlug)
 	})
 }
 
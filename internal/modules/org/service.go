@@ -4,6 +4,8 @@ import (
 	"context"
 
 	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/infra/token"
 	"github.com/bernardinorafael/pkg/logger"
 )
 
@@ -54,3 +56,21 @@ func (s svc) GetOrgBySlug(ctx context.Context, slug string) (*EntityWithOwner, e
 
 	return org, nil
 }
+
+func (s svc) CheckOwner(ctx context.Context, orgId string) error {
+	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
+	if !ok {
+		return NewBadRequestError("user ID not found in context", nil)
+	}
+
+	org, err := s.repo.FindByID(ctx, orgId)
+	if err != nil || org == nil {
+		return NewNotFoundError("organization not found", err)
+	}
+	// Service accounts have no user ID, so they never pass as the owner
+	if claims.IsService() || org.Owner.ID != claims.UserID {
+		return NewForbiddenError("only the organization owner can perform this action", AccessTokenUnauthorized, nil)
+	}
+
+	return nil
+}
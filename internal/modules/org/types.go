@@ -4,18 +4,30 @@ import (
 	"time"
 
 	"github.com/bernardinorafael/internal/modules/user"
+	"github.com/lib/pq"
 )
 
 type Settings struct {
-	ID                        string    `json:"id" db:"id"`
-	OrgID                     string    `json:"org_id" db:"org_id"`
-	IsActive                  bool      `json:"is_active" db:"is_active"`
-	DefaultMembershipPassword string    `json:"default_membership_password" db:"default_membership_password"`
-	MaxAllowedMemberships     int       `json:"max_allowed_memberships" db:"max_allowed_memberships"`
-	MaxAllowedRoles           int       `json:"max_allowed_roles" db:"max_allowed_roles"`
-	UseMasterPassword         bool      `json:"use_master_password" db:"use_master_password"`
-	Created                   time.Time `json:"created" db:"created"`
-	Updated                   time.Time `json:"updated" db:"updated"`
+	ID                        string `json:"id" db:"id"`
+	OrgID                     string `json:"org_id" db:"org_id"`
+	IsActive                  bool   `json:"is_active" db:"is_active"`
+	DefaultMembershipPassword string `json:"default_membership_password" db:"default_membership_password"`
+	MaxAllowedMemberships     int    `json:"max_allowed_memberships" db:"max_allowed_memberships"`
+	MaxAllowedRoles           int    `json:"max_allowed_roles" db:"max_allowed_roles"`
+	UseMasterPassword         bool   `json:"use_master_password" db:"use_master_password"`
+	MaxSessionsPerUser        *int   `json:"max_sessions_per_user" db:"max_sessions_per_user"`
+
+	PasswordMinLength        int            `json:"password_min_length" db:"password_min_length"`
+	PasswordRequireUppercase bool           `json:"password_require_uppercase" db:"password_require_uppercase"`
+	PasswordRequireLowercase bool           `json:"password_require_lowercase" db:"password_require_lowercase"`
+	PasswordRequireNumber    bool           `json:"password_require_number" db:"password_require_number"`
+	PasswordRequireSpecial   bool           `json:"password_require_special" db:"password_require_special"`
+	PasswordMaxAgeDays       int            `json:"password_max_age_days" db:"password_max_age_days"`
+	PasswordHistoryDepth     int            `json:"password_history_depth" db:"password_history_depth"`
+	PasswordBannedWords      pq.StringArray `json:"password_banned_words" db:"password_banned_words"`
+
+	Created time.Time `json:"created" db:"created"`
+	Updated time.Time `json:"updated" db:"updated"`
 }
 
 type EntityWithSettings struct {
@@ -5,6 +5,15 @@ import "context"
 type RepositoryInterface interface {
 	FindAll(ctx context.Context) ([]Entity, error)
 	FindByRoleID(ctx context.Context, roleId string) ([]Entity, error)
+	// FindKeysByUserID returns the permission keys granted to the user by their team role
+	FindKeysByUserID(ctx context.Context, userId string) ([]string, error)
+	// HasPlatformPermission reports whether the user was granted the platform permission on every organization
+	HasPlatformPermission(ctx context.Context, userId, key string) (bool, error)
+	// HasPlatformPermissionOver reports whether the user was granted the platform permission
+	// on every organization or on one the target user belongs to
+	HasPlatformPermissionOver(ctx context.Context, userId, key, targetUserId string) (bool, error)
+	// IsPlatformAdmin reports whether the user was granted any platform permission
+	IsPlatformAdmin(ctx context.Context, userId string) (bool, error)
 }
 
 type ServiceInterface interface {
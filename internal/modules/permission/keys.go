@@ -0,0 +1,38 @@
+package permission
+
+// Keys of the permissions checked on the routes with middleware.WithPermission
+// Personal access tokens restricted to a subset of keys and service accounts,
+// which hold the keys of their role, are only allowed the routes of their keys
+const (
+	KeyUsersRead  = "users:read"
+	KeyUsersWrite = "users:write"
+	// KeyUsersModerate allows locking, banning and unbanning users
+	KeyUsersModerate = "users:moderate"
+
+	KeyTeamsRead  = "teams:read"
+	KeyTeamsWrite = "teams:write"
+
+	KeyRolesRead       = "roles:read"
+	KeyRolesWrite      = "roles:write"
+	KeyPermissionsRead = "permissions:read"
+
+	KeyOrganizationsRead  = "organizations:read"
+	KeyOrganizationsWrite = "organizations:write"
+
+	KeyServiceAccountsManage = "service-accounts:manage"
+	KeySSOManage             = "sso:manage"
+
+	// KeyProfileRead and KeyProfileWrite cover the user's own account,
+	// such as their emails, phones and sessions
+	KeyProfileRead  = "profile:read"
+	KeyProfileWrite = "profile:write"
+)
+
+// Keys of the platform permissions, granted in platform_admins and never through the
+// roles organizations manage, see RepositoryInterface.HasPlatformPermission
+const (
+	KeyPlatformImpersonate  = "platform:impersonate"
+	KeyPlatformOAuthClients = "platform:oauth-clients"
+	// KeyPlatformModerateUsers allows banning and unbanning users
+	KeyPlatformModerateUsers = "platform:users-moderate"
+)
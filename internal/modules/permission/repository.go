@@ -39,6 +39,105 @@ func (r *repo) FindByRoleID(ctx context.Context, roleId string) ([]Entity, error
 	return permissions, nil
 }
 
+func (r *repo) FindKeysByUserID(ctx context.Context, userId string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var keys []string
+	err := r.db.SelectContext(
+		ctx,
+		&keys,
+		`
+		SELECT p.key
+		FROM permissions p
+		JOIN role_permissions rp ON p.id = rp.permission_id
+		JOIN team_members tm ON rp.role_id = tm.role_id
+		WHERE tm.user_id = $1
+		`,
+		userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find permissions: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *repo) HasPlatformPermission(ctx context.Context, userId, key string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var granted bool
+	err := r.db.GetContext(
+		ctx,
+		&granted,
+		`
+		SELECT EXISTS (
+			SELECT 1 FROM platform_admins
+			WHERE user_id = $1
+			AND permission_key = $2
+			AND org_id IS NULL
+		)
+		`,
+		userId,
+		key,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to find platform permission: %w", err)
+	}
+
+	return granted, nil
+}
+
+func (r *repo) HasPlatformPermissionOver(ctx context.Context, userId, key, targetUserId string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var granted bool
+	err := r.db.GetContext(
+		ctx,
+		&granted,
+		`
+		SELECT EXISTS (
+			SELECT 1 FROM platform_admins pa
+			WHERE pa.user_id = $1
+			AND pa.permission_key = $2
+			AND (
+				pa.org_id IS NULL
+				OR pa.org_id IN (SELECT org_id FROM team_members WHERE user_id = $3)
+				OR pa.org_id IN (SELECT id FROM organizations WHERE owner_id = $3)
+			)
+		)
+		`,
+		userId,
+		key,
+		targetUserId,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to find platform permission: %w", err)
+	}
+
+	return granted, nil
+}
+
+func (r *repo) IsPlatformAdmin(ctx context.Context, userId string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var granted bool
+	err := r.db.GetContext(
+		ctx,
+		&granted,
+		"SELECT EXISTS (SELECT 1 FROM platform_admins WHERE user_id = $1)",
+		userId,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to find platform permission: %w", err)
+	}
+
+	return granted, nil
+}
+
 func (r *repo) FindAll(ctx context.Context) ([]Entity, error) {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
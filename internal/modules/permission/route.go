@@ -12,33 +12,33 @@ import (
 )
 
 type controller struct {
-	ctx       context.Context
-	log       logger.Logger
-	svc       ServiceInterface
-	secretKey string
+	ctx  context.Context
+	log  logger.Logger
+	svc  ServiceInterface
+	auth *middleware.Auth
 }
 
 func NewController(
 	ctx context.Context,
 	log logger.Logger,
 	svc ServiceInterface,
-	secretKey string,
+	auth *middleware.Auth,
 ) *controller {
 	return &controller{
-		ctx:       ctx,
-		log:       log,
-		svc:       svc,
-		secretKey: secretKey,
+		ctx:  ctx,
+		log:  log,
+		svc:  svc,
+		auth: auth,
 	}
 }
 
 func (c controller) RegisterRoute(r *chi.Mux) {
-	m := middleware.NewWithAuth(c.log, c.secretKey)
+	m := c.auth
 
 	r.Route("/api/v1/permissions", func(r chi.Router) {
 		r.Use(m.WithAuth)
 
-		r.Get("/", c.findAll)
+		r.With(m.WithPermission(KeyPermissionsRead)).Get("/", c.findAll)
 	})
 }
 
@@ -0,0 +1,160 @@
+package phone
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/pkg/crypto"
+)
+
+const (
+	MaxAttempts = 3
+	CodeLength  = 6
+	CodeTTL     = time.Minute * 10
+	// ResendCooldown is how long a new code can't be sent for the same purpose
+	ResendCooldown = time.Minute
+)
+
+// What a code is sent for
+const (
+	PurposeVerification = "verification"
+	PurposeMFA          = "mfa"
+)
+
+type Validation struct {
+	id          string
+	userId      string
+	phoneNumber string
+	purpose     string
+	challengeId *string
+	codeHash    string
+	attempts    int
+	isConsumed  bool
+	isValid     bool
+	created     time.Time
+	expires     time.Time
+}
+
+// NewCodeValidationFromEntity creates a new Validation from a ValidationEntity
+func NewCodeValidationFromEntity(entity ValidationEntity) *Validation {
+	return &Validation{
+		id:          entity.ID,
+		userId:      entity.UserID,
+		phoneNumber: entity.PhoneNumber,
+		purpose:     entity.Purpose,
+		challengeId: entity.ChallengeID,
+		codeHash:    entity.CodeHash,
+		attempts:    entity.Attempts,
+		isConsumed:  entity.IsConsumed,
+		isValid:     entity.IsValid,
+		created:     entity.Created,
+		expires:     entity.Expires,
+	}
+}
+
+// NewCodeValidation creates a code sent to the phone number, returning the plain code
+// Only its hash is kept, the code must be texted right away
+func NewCodeValidation(userId, phoneNumber, purpose string) (*Validation, string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate code: %w", err)
+	}
+	code := fmt.Sprintf("%0*d", CodeLength, n.Int64())
+
+	validation := &Validation{
+		id:          util.GenID("pv"),
+		userId:      userId,
+		phoneNumber: phoneNumber,
+		purpose:     purpose,
+		codeHash:    crypto.HashToken(code),
+		attempts:    0,
+		isConsumed:  false,
+		isValid:     true,
+		created:     time.Now(),
+		expires:     time.Now().Add(CodeTTL),
+	}
+
+	return validation, code, nil
+}
+
+// NewMFACodeValidation creates a second factor code that only counts for the login challenge
+// it is texted for, returning the plain code
+func NewMFACodeValidation(userId, phoneNumber, challengeId string) (*Validation, string, error) {
+	validation, code, err := NewCodeValidation(userId, phoneNumber, PurposeMFA)
+	if err != nil {
+		return nil, "", err
+	}
+	validation.challengeId = &challengeId
+
+	return validation, code, nil
+}
+
+// Message returns the text carrying the code
+func Message(code string) string {
+	return fmt.Sprintf("%s é o seu código de verificação. Ele expira em %d minutos.", code, int(CodeTTL.Minutes()))
+}
+
+func (v *Validation) ValidateCode(code string) bool {
+	if subtle.ConstantTimeCompare([]byte(crypto.HashToken(code)), []byte(v.codeHash)) == 1 {
+		v.isConsumed = true
+		return true
+	}
+
+	return false
+}
+
+func (v *Validation) IsMaxAttempts() bool {
+	return v.attempts >= MaxAttempts
+}
+
+func (v *Validation) IsExpired() bool {
+	return time.Now().After(v.expires)
+}
+
+// InCooldown reports whether the code was sent too recently for another one to be sent
+func (v *Validation) InCooldown() bool {
+	return time.Since(v.created) < ResendCooldown
+}
+
+// IsForChallenge reports whether the code was texted for the challenge
+func (v *Validation) IsForChallenge(challengeId string) bool {
+	return v.challengeId != nil && *v.challengeId == challengeId
+}
+
+// IsUsable reports whether the code may still be checked
+func (v *Validation) IsUsable() bool {
+	return v.isValid && !v.isConsumed && !v.IsExpired() && !v.IsMaxAttempts()
+}
+
+// Store returns the Entity ready to be stored in the database
+func (v *Validation) Store() ValidationEntity {
+	return ValidationEntity{
+		ID:          v.ID(),
+		UserID:      v.UserID(),
+		PhoneNumber: v.PhoneNumber(),
+		Purpose:     v.Purpose(),
+		ChallengeID: v.ChallengeID(),
+		CodeHash:    v.CodeHash(),
+		Attempts:    v.Attempts(),
+		IsConsumed:  v.IsConsumed(),
+		IsValid:     v.IsValid(),
+		Created:     v.Created(),
+		Expires:     v.Expires(),
+	}
+}
+
+func (v *Validation) ID() string           { return v.id }
+func (v *Validation) UserID() string       { return v.userId }
+func (v *Validation) PhoneNumber() string  { return v.phoneNumber }
+func (v *Validation) Purpose() string      { return v.purpose }
+func (v *Validation) ChallengeID() *string { return v.challengeId }
+func (v *Validation) CodeHash() string     { return v.codeHash }
+func (v *Validation) Attempts() int        { return v.attempts }
+func (v *Validation) IsConsumed() bool     { return v.isConsumed }
+func (v *Validation) IsValid() bool        { return v.isValid }
+func (v *Validation) Created() time.Time   { return v.created }
+func (v *Validation) Expires() time.Time   { return v.expires }
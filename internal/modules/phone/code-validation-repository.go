@@ -0,0 +1,154 @@
+package phone
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (r repo) FindCodeValidationByUserID(ctx context.Context, userId, purpose string) (*ValidationEntity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity ValidationEntity
+	err := r.db.GetContext(
+		ctx,
+		&entity,
+		`
+			SELECT * FROM phone_validations
+			WHERE user_id = $1
+			AND purpose = $2
+			AND is_consumed = false
+			AND is_valid = true
+			ORDER BY created DESC
+			LIMIT 1
+		`,
+		userId,
+		purpose,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find phone validation by user id: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) CountCodeValidationAttempt(ctx context.Context, validationId string, maxAttempts int) (*ValidationEntity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity ValidationEntity
+	err := r.db.GetContext(
+		ctx,
+		&entity,
+		`
+			UPDATE phone_validations
+			SET attempts = attempts + 1
+			WHERE id = $1
+			AND attempts < $2
+			AND is_consumed = false
+			AND is_valid = true
+			AND expires > now()
+			RETURNING *
+		`,
+		validationId,
+		maxAttempts,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on count phone validation attempt: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) ConsumeCodeValidation(ctx context.Context, validationId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`
+			UPDATE phone_validations
+			SET is_consumed = true, is_valid = false
+			WHERE id = $1 AND is_consumed = false AND is_valid = true
+		`,
+		validationId,
+	)
+	if err != nil {
+		return fmt.Errorf("error on consume phone validation: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error on consume phone validation: %w", err)
+	}
+	if affected == 0 {
+		return ErrAlreadyConsumed
+	}
+
+	return nil
+}
+
+func (r repo) InvalidateCodesByUserID(ctx context.Context, userId, purpose string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE phone_validations SET is_valid = false WHERE user_id = $1 AND purpose = $2 AND is_valid = true",
+		userId,
+		purpose,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate phone validations: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) InsertCodeValidation(ctx context.Context, entity ValidationEntity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	sql := `
+		INSERT INTO phone_validations (
+			id,
+			user_id,
+			phone_number,
+			purpose,
+			challenge_id,
+			code_hash,
+			attempts,
+			is_consumed,
+			is_valid,
+			created,
+			expires
+		) VALUES (
+			:id,
+			:user_id,
+			:phone_number,
+			:purpose,
+			:challenge_id,
+			:code_hash,
+			:attempts,
+			:is_consumed,
+			:is_valid,
+			:created,
+			:expires
+		)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, sql, entity); err != nil {
+		return fmt.Errorf("failed to insert phone validation: %w", err)
+	}
+
+	return nil
+}
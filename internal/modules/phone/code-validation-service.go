@@ -0,0 +1,155 @@
+package phone
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/pkg/logger"
+	"github.com/lib/pq"
+)
+
+var errPhoneClaimed = NewConflictError("phone already verified by another user", ResourceAlreadyTaken, nil,
+	[]Field{{Field: "phone_number", Msg: "phone already taken"}},
+)
+
+func (s svc) GenerateValidationCode(ctx context.Context, dto GeneratePhoneValidationDTO) error {
+	phone, err := s.FindByID(ctx, dto.PhoneID)
+	if err != nil {
+		return err
+	}
+	if phone.UserID != dto.UserID {
+		return NewNotFoundError("phone not found", nil)
+	}
+	if phone.IsVerified {
+		return NewConflictError("phone already verified", ResourceAlreadyTaken, nil, nil)
+	}
+	// No code is texted for a number it could never verify
+	if err := s.checkNumberUnclaimed(ctx, *phone); err != nil {
+		return err
+	}
+
+	exists, err := s.repo.FindCodeValidationByUserID(ctx, phone.UserID, PurposeVerification)
+	if err != nil {
+		return NewBadRequestError("failed to find phone validation", err)
+	}
+	if exists != nil {
+		exists := NewCodeValidationFromEntity(*exists)
+
+		if exists.InCooldown() {
+			return NewTooManyRequestsError("a code was sent recently, try again later", time.Until(exists.Created().Add(ResendCooldown)), nil)
+		}
+	}
+
+	// Only the latest code works, the ones sent before are of no use anymore
+	if err := s.repo.InvalidateCodesByUserID(ctx, phone.UserID, PurposeVerification); err != nil {
+		return NewBadRequestError("failed to invalidate phone validations", err)
+	}
+
+	newCode, code, err := NewCodeValidation(phone.UserID, phone.PhoneNumber, PurposeVerification)
+	if err != nil {
+		return NewBadRequestError("failed to generate phone validation", err)
+	}
+
+	err = s.repo.InsertCodeValidation(ctx, newCode.Store())
+	if err != nil {
+		s.log.Errorw(ctx, "failed to insert phone validation", logger.Err(err))
+		return NewBadRequestError("failed to insert phone validation", nil)
+	}
+
+	if err := s.sms.Send(ctx, phone.PhoneNumber, Message(code)); err != nil {
+		s.log.Errorw(ctx, "failed to send phone validation", logger.Err(err))
+		return NewBadRequestError("failed to send phone validation", err)
+	}
+
+	s.log.Infow(ctx, "phone validation sent", logger.String("phone_id", phone.ID))
+
+	return nil
+}
+
+func (s svc) ValidatePhone(ctx context.Context, dto ValidatePhoneDTO) error {
+	phone, err := s.FindByID(ctx, dto.PhoneID)
+	if err != nil {
+		return err
+	}
+	if phone.UserID != dto.UserID {
+		return NewNotFoundError("phone not found", nil)
+	}
+
+	// A number belongs to the one user who verified it first
+	if err := s.checkNumberUnclaimed(ctx, *phone); err != nil {
+		return err
+	}
+
+	existsCode, err := s.repo.FindCodeValidationByUserID(ctx, phone.UserID, PurposeVerification)
+	if err != nil {
+		return NewBadRequestError("failed to find phone validation", err)
+	}
+	if existsCode == nil {
+		return NewConflictError("phone validation not found", ResourceNotFound, nil, nil)
+	}
+
+	code := NewCodeValidationFromEntity(*existsCode)
+
+	// The latest code was sent to another phone of the user
+	if code.PhoneNumber() != phone.PhoneNumber {
+		return NewConflictError("phone validation not found", ResourceNotFound, nil, nil)
+	}
+
+	if code.IsExpired() {
+		return NewForbiddenError("code expired", Expired, nil)
+	}
+
+	// The attempt is counted before the code is compared, so concurrent guesses can't exceed the limit
+	counted, err := s.repo.CountCodeValidationAttempt(ctx, code.ID(), MaxAttempts)
+	if err != nil {
+		return NewBadRequestError("failed to update phone validation", err)
+	}
+	if counted == nil {
+		return NewForbiddenError("max attempts reached", MaxLimitResourceReached, nil)
+	}
+
+	if !code.ValidateCode(dto.Code) {
+		return NewValidationFieldError("invalid code", nil, []Field{
+			{Field: "code", Msg: "invalid code"},
+		})
+	}
+
+	if err := s.repo.ConsumeCodeValidation(ctx, code.ID()); err != nil {
+		if errors.Is(err, ErrAlreadyConsumed) {
+			return NewConflictError("phone validation not found", ResourceNotFound, nil, nil)
+		}
+		return NewBadRequestError("failed to update phone validation", err)
+	}
+
+	phoneEntity := NewFromEntity(*phone)
+	phoneEntity.Verify()
+
+	// Verifying the primary phone verifies the phone number of the user as well
+	if err := s.repo.Update(ctx, phoneEntity.Store()); err != nil {
+		var pqErr *pq.Error
+		// 23505 is the code for unique constraint violation, another user verified it concurrently
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return errPhoneClaimed
+		}
+		return NewBadRequestError("failed to update phone", err)
+	}
+
+	s.log.Infow(ctx, "phone verified", logger.String("phone_id", phone.ID))
+
+	return nil
+}
+
+// checkNumberUnclaimed fails when another user already verified the number of the phone
+func (s svc) checkNumberUnclaimed(ctx context.Context, phone Entity) error {
+	verified, err := s.repo.FindVerifiedByNumber(ctx, phone.PhoneNumber)
+	if err != nil {
+		return NewBadRequestError("failed to find verified phone", err)
+	}
+	if verified != nil && verified.UserID != phone.UserID {
+		return errPhoneClaimed
+	}
+
+	return nil
+}
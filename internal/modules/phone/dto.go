@@ -0,0 +1,18 @@
+package phone
+
+type CreatePhoneDTO struct {
+	UserID      string `json:"-"`
+	PhoneNumber string `json:"phone_number"`
+	SendCode    bool   `json:"send_code"`
+}
+
+type ValidatePhoneDTO struct {
+	PhoneID string `json:"phone_id"`
+	UserID  string `json:"-"`
+	Code    string `json:"code"`
+}
+
+type GeneratePhoneValidationDTO struct {
+	PhoneID string `json:"phone_id"`
+	UserID  string `json:"-"`
+}
@@ -0,0 +1,48 @@
+package phone
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAlreadyConsumed is returned when a concurrent request consumed the code first
+var ErrAlreadyConsumed = errors.New("phone validation already consumed")
+
+// Writes to the primary phone mirror it on users.phone_number and users.phone_verified
+// in the same transaction
+type RepositoryInterface interface {
+	Insert(ctx context.Context, entity Entity) error
+	Update(ctx context.Context, entity Entity) error
+	// SetPrimary makes the phone the primary one of the user, returning ErrPhoneNotFound
+	// when the user has no such phone
+	SetPrimary(ctx context.Context, userId, phoneId string) error
+	// Delete deletes a phone of the user other than the primary one
+	Delete(ctx context.Context, userId, phoneId string) error
+	FindAllByUser(ctx context.Context, userId string) ([]Entity, error)
+	FindByID(ctx context.Context, phoneId string) (*Entity, error)
+	// FindVerifiedByNumber returns the phone verified with the number, by whichever user
+	FindVerifiedByNumber(ctx context.Context, phoneNumber string) (*Entity, error)
+
+	InsertCodeValidation(ctx context.Context, entity ValidationEntity) error
+	// CountCodeValidationAttempt counts an attempt against the code before it is compared, returning
+	// the code as it is afterwards, or nil when it is no longer usable or ran out of attempts
+	CountCodeValidationAttempt(ctx context.Context, validationId string, maxAttempts int) (*ValidationEntity, error)
+	// ConsumeCodeValidation marks the code as consumed, failing with ErrAlreadyConsumed if it no longer is usable
+	ConsumeCodeValidation(ctx context.Context, validationId string) error
+	// FindCodeValidationByUserID returns the latest code of the purpose still valid and not consumed
+	FindCodeValidationByUserID(ctx context.Context, userId, purpose string) (*ValidationEntity, error)
+	// InvalidateCodesByUserID invalidates every code of the purpose sent to the user
+	InvalidateCodesByUserID(ctx context.Context, userId, purpose string) error
+}
+
+type ServiceInterface interface {
+	FindAllByUser(ctx context.Context, userId string) ([]Entity, error)
+	FindByID(ctx context.Context, phoneId string) (*Entity, error)
+	AddPhone(ctx context.Context, dto CreatePhoneDTO) error
+	SetPrimaryPhone(ctx context.Context, userId, phoneId string) error
+	DeletePhone(ctx context.Context, userId, phoneId string) error
+
+	// GenerateValidationCode texts a code verifying the phone number
+	GenerateValidationCode(ctx context.Context, dto GeneratePhoneValidationDTO) error
+	ValidatePhone(ctx context.Context, dto ValidatePhoneDTO) error
+}
@@ -0,0 +1,101 @@
+package phone
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+)
+
+const (
+	// Same limit as the emails of a user
+	maxPhonesByUser = 3
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{8,15}$`)
+
+type Phone struct {
+	id          string
+	userId      string
+	phoneNumber string
+	isPrimary   bool
+	isVerified  bool
+	created     time.Time
+	updated     time.Time
+}
+
+// NewFromEntity creates a new phone from an existing entity
+// Numbers stored before the format was checked are kept as they are
+func NewFromEntity(e Entity) *Phone {
+	return &Phone{
+		id:          e.ID,
+		userId:      e.UserID,
+		phoneNumber: e.PhoneNumber,
+		isPrimary:   e.IsPrimary,
+		isVerified:  e.IsVerified,
+		created:     e.Created,
+		updated:     e.Updated,
+	}
+}
+
+// NewPhone creates a new phone entity from scratch
+func NewPhone(userId, phoneNumber string) (*Phone, error) {
+	p := &Phone{
+		id:          util.GenID("phone"),
+		userId:      userId,
+		phoneNumber: phoneNumber,
+		isPrimary:   false,
+		isVerified:  false,
+		created:     time.Now(),
+		updated:     time.Now(),
+	}
+
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *Phone) Verify() {
+	p.isVerified = true
+	p.updated = time.Now()
+}
+
+// MakePrimary is only meant for the first phone of a user, SetPrimary switches it afterwards
+func (p *Phone) MakePrimary() {
+	p.isPrimary = true
+	p.updated = time.Now()
+}
+
+func (p *Phone) Store() Entity {
+	return Entity{
+		ID:          p.ID(),
+		UserID:      p.UserID(),
+		PhoneNumber: p.PhoneNumber(),
+		IsPrimary:   p.IsPrimary(),
+		IsVerified:  p.IsVerified(),
+		Created:     p.Created(),
+		Updated:     p.Updated(),
+	}
+}
+
+func (p *Phone) ID() string          { return p.id }
+func (p *Phone) UserID() string      { return p.userId }
+func (p *Phone) PhoneNumber() string { return p.phoneNumber }
+func (p *Phone) IsPrimary() bool     { return p.isPrimary }
+func (p *Phone) IsVerified() bool    { return p.isVerified }
+func (p *Phone) Created() time.Time  { return p.created }
+func (p *Phone) Updated() time.Time  { return p.updated }
+
+func (p *Phone) validate() error {
+	if p.phoneNumber == "" {
+		return errors.New("phone number is required")
+	}
+	if !phonePattern.MatchString(p.phoneNumber) {
+		return errors.New("invalid phone number format")
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package phone
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bernardinorafael/pkg/transaction"
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct{ db *sqlx.DB }
+
+func NewRepository(db *sqlx.DB) RepositoryInterface {
+	return &repo{db}
+}
+
+// ErrPhoneNotFound is returned when the phone to set as primary is not one of the user's
+var ErrPhoneNotFound = errors.New("phone not found")
+
+func (r repo) Insert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return transaction.ExecTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var query = `
+			INSERT INTO phones (
+				id,
+				user_id,
+				phone_number,
+				is_primary,
+				is_verified,
+				created,
+				updated
+			) VALUES (
+				:id,
+				:user_id,
+				:phone_number,
+				:is_primary,
+				:is_verified,
+				:created,
+				:updated
+			)
+		`
+		if _, err := tx.NamedExecContext(ctx, query, entity); err != nil {
+			return fmt.Errorf("failed to insert phone: %w", err)
+		}
+
+		if entity.IsPrimary {
+			return syncUser(ctx, tx, entity)
+		}
+
+		return nil
+	})
+}
+
+func (r repo) Update(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	entity.Updated = time.Now()
+
+	return transaction.ExecTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var query = `
+			UPDATE phones
+			SET
+				is_verified = :is_verified,
+				updated = :updated
+			WHERE id = :id
+		`
+		if _, err := tx.NamedExecContext(ctx, query, entity); err != nil {
+			return fmt.Errorf("failed to update phone: %w", err)
+		}
+
+		if entity.IsPrimary {
+			return syncUser(ctx, tx, entity)
+		}
+
+		return nil
+	})
+}
+
+func (r repo) SetPrimary(ctx context.Context, userId, phoneId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return transaction.ExecTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		// Unset first, a user can't hold two primary phones even for a moment
+		_, err := tx.ExecContext(
+			ctx,
+			"UPDATE phones SET is_primary = false, updated = now() WHERE user_id = $1 AND is_primary = true",
+			userId,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to unset primary phone: %w", err)
+		}
+
+		var entity Entity
+		err = tx.GetContext(
+			ctx,
+			&entity,
+			"UPDATE phones SET is_primary = true, updated = now() WHERE id = $1 AND user_id = $2 RETURNING *",
+			phoneId,
+			userId,
+		)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrPhoneNotFound
+			}
+			return fmt.Errorf("failed to set primary phone: %w", err)
+		}
+
+		return syncUser(ctx, tx, entity)
+	})
+}
+
+func (r repo) Delete(ctx context.Context, userId, phoneId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	// The primary phone is mirrored on the user, it is never deleted
+	_, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM phones WHERE id = $1 AND user_id = $2 AND is_primary = false",
+		phoneId,
+		userId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete phone: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) FindAllByUser(ctx context.Context, userId string) ([]Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var phones = []Entity{}
+	err := r.db.SelectContext(
+		ctx,
+		&phones,
+		"SELECT * FROM phones WHERE user_id = $1 ORDER BY created DESC",
+		userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find all phones by user: %w", err)
+	}
+
+	return phones, nil
+}
+
+func (r repo) FindByID(ctx context.Context, phoneId string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var phone Entity
+	err := r.db.GetContext(ctx, &phone, "SELECT * FROM phones WHERE id = $1", phoneId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find phone by id: %w", err)
+	}
+
+	return &phone, nil
+}
+
+func (r repo) FindVerifiedByNumber(ctx context.Context, phoneNumber string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var phone Entity
+	err := r.db.GetContext(
+		ctx,
+		&phone,
+		"SELECT * FROM phones WHERE phone_number = $1 AND is_verified = true",
+		phoneNumber,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find verified phone by number: %w", err)
+	}
+
+	return &phone, nil
+}
+
+// syncUser mirrors the primary phone on the user
+func syncUser(ctx context.Context, tx *sqlx.Tx, primary Entity) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"UPDATE users SET phone_number = $2, phone_verified = $3, updated = now() WHERE id = $1",
+		primary.UserID,
+		primary.PhoneNumber,
+		primary.IsVerified,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to sync user phone: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package phone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/pkg/logger"
+	"github.com/lib/pq"
+)
+
+func (s svc) AddPhone(ctx context.Context, dto CreatePhoneDTO) error {
+	phones, err := s.FindAllByUser(ctx, dto.UserID)
+	if err != nil {
+		return err
+	}
+
+	if len(phones) >= maxPhonesByUser {
+		s.log.Info(ctx, "max phones reached")
+		return NewForbiddenError("max phones reached", MaxLimitResourceReached, nil)
+	}
+
+	for _, v := range phones {
+		if v.PhoneNumber == dto.PhoneNumber {
+			return NewConflictError("phone already taken", ResourceAlreadyTaken, nil,
+				[]Field{{Field: "phone_number", Msg: "phone already taken"}},
+			)
+		}
+	}
+
+	phone, err := NewPhone(dto.UserID, dto.PhoneNumber)
+	if err != nil {
+		return NewBadRequestError("error on create phone", err)
+	}
+	// The first phone of a user is the primary one, users provisioned without a number have none
+	if len(phones) == 0 {
+		phone.MakePrimary()
+	}
+
+	if err := s.repo.Insert(ctx, phone.Store()); err != nil {
+		var pqErr *pq.Error
+		// 23505 is the code for unique constraint violation
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			msg := "phone already exists"
+			var appErr = NewConflictError(msg, ResourceAlreadyTaken, err, nil)
+			field := util.ExtractFieldFromDetail(pqErr.Detail)
+			s.log.Errorw(ctx, msg, logger.Err(err))
+			appErr.AddField(field, field+" already exists")
+			return appErr
+		}
+
+		s.log.Errorw(ctx, "failed to create phone", logger.Err(err))
+		return NewBadRequestError("failed to create phone", err)
+	}
+
+	if dto.SendCode {
+		if err := s.GenerateValidationCode(ctx, GeneratePhoneValidationDTO{
+			PhoneID: phone.ID(),
+			UserID:  dto.UserID,
+		}); err != nil {
+			s.log.Errorw(ctx, "failed to generate validation code", logger.Err(err))
+		}
+	}
+
+	return nil
+}
+
+func (s svc) FindAllByUser(ctx context.Context, userId string) ([]Entity, error) {
+	phones, err := s.repo.FindAllByUser(ctx, userId)
+	if err != nil {
+		s.log.Errorw(ctx, "failed to find all phones by user", logger.Err(err))
+		return nil, NewBadRequestError("failed to find all phones by user", err)
+	}
+
+	return phones, nil
+}
+
+func (s svc) FindByID(ctx context.Context, phoneId string) (*Entity, error) {
+	foundPhone, err := s.repo.FindByID(ctx, phoneId)
+	if err != nil {
+		s.log.Errorw(ctx, "failed to find phone by id", logger.Err(err))
+		return nil, NewBadRequestError("failed to find phone by id", err)
+	}
+	if foundPhone == nil {
+		return nil, NewNotFoundError("phone not found", nil)
+	}
+
+	return foundPhone, nil
+}
+
+// SetPrimaryPhone makes a verified phone the primary one, which becomes the phone number of the user
+func (s svc) SetPrimaryPhone(ctx context.Context, userId, phoneId string) error {
+	foundPhone, err := s.FindByID(ctx, phoneId)
+	if err != nil {
+		return err
+	}
+	if foundPhone.UserID != userId {
+		msg := fmt.Sprintf("not found phone with id %s", phoneId)
+		return NewNotFoundError(msg, nil)
+	}
+	if foundPhone.IsPrimary {
+		return nil
+	}
+	if !foundPhone.IsVerified {
+		return NewForbiddenError("phone not verified", PhoneNotVerified, nil)
+	}
+
+	err = s.repo.SetPrimary(ctx, userId, phoneId)
+	if err != nil {
+		if errors.Is(err, ErrPhoneNotFound) {
+			return NewNotFoundError(fmt.Sprintf("not found phone with id %s", phoneId), err)
+		}
+		s.log.Errorw(ctx, "failed to set primary phone", logger.Err(err))
+		return NewBadRequestError("failed to set primary phone", err)
+	}
+
+	return nil
+}
+
+func (s svc) DeletePhone(ctx context.Context, userId, phoneId string) error {
+	foundPhone, err := s.FindByID(ctx, phoneId)
+	if err != nil {
+		return err
+	}
+	if foundPhone.UserID != userId {
+		return NewNotFoundError(fmt.Sprintf("not found phone with id %s", phoneId), nil)
+	}
+	if foundPhone.IsPrimary {
+		return NewForbiddenError("primary phone can't be deleted", InvalidDeletion, nil)
+	}
+
+	if err := s.repo.Delete(ctx, userId, phoneId); err != nil {
+		s.log.Errorw(ctx, "failed to delete phone", logger.Err(err))
+		return NewBadRequestError("failed to delete phone", nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package phone
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/internal/modules/permission"
+	"github.com/bernardinorafael/pkg/logger"
+	"github.com/go-chi/chi"
+)
+
+type controller struct {
+	ctx  context.Context
+	log  logger.Logger
+	svc  ServiceInterface
+	auth *middleware.Auth
+}
+
+func NewController(
+	ctx context.Context,
+	log logger.Logger,
+	svc ServiceInterface,
+	auth *middleware.Auth,
+) *controller {
+	return &controller{
+		ctx:  ctx,
+		log:  log,
+		svc:  svc,
+		auth: auth,
+	}
+}
+
+func (c controller) RegisterRoute(r *chi.Mux) {
+	m := c.auth
+
+	r.Route("/api/v1/phones", func(r chi.Router) {
+		r.Use(m.WithAuth, m.DenyService)
+
+		r.With(m.WithPermission(permission.KeyProfileRead)).Get("/", c.getAll)
+		r.With(m.WithPermission(permission.KeyProfileWrite), m.DenyImpersonation).Post("/", c.create)
+		r.With(m.WithPermission(permission.KeyProfileWrite), m.DenyImpersonation, m.RequireRecentAuth(middleware.RecentAuthMaxAge)).Delete("/{phoneId}", c.delete)
+		r.With(m.WithPermission(permission.KeyProfileWrite), m.DenyImpersonation, m.RequireRecentAuth(middleware.RecentAuthMaxAge)).Patch("/{phoneId}/set-primary", c.setPrimary)
+	})
+
+	r.Route("/api/v1/phones/validations", func(r chi.Router) {
+		r.Use(m.WithAuth, m.DenyService, m.WithPermission(permission.KeyProfileWrite))
+		r.Post("/", c.requestValidation)
+		r.Post("/{phoneId}", c.validatePhone)
+	})
+}
+
+func (c controller) getAll(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.AuthKey{}).(*token.AccountClaims)
+
+	phones, err := c.svc.FindAllByUser(c.ctx, claims.UserID)
+	if err != nil {
+		errors.NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, phones)
+}
+
+func (c controller) create(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.AuthKey{}).(*token.AccountClaims)
+	var body CreatePhoneDTO
+
+	err := util.ReadRequestBody(w, r, &body)
+	if err != nil {
+		errors.NewHttpError(w, err)
+		return
+	}
+	body.UserID = claims.UserID
+
+	err = c.svc.AddPhone(c.ctx, body)
+	if err != nil {
+		errors.NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) delete(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.AuthKey{}).(*token.AccountClaims)
+
+	err := c.svc.DeletePhone(
+		c.ctx,
+		claims.UserID,
+		chi.URLParam(r, "phoneId"),
+	)
+	if err != nil {
+		errors.NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) setPrimary(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.AuthKey{}).(*token.AccountClaims)
+
+	err := c.svc.SetPrimaryPhone(
+		c.ctx,
+		claims.UserID,
+		chi.URLParam(r, "phoneId"),
+	)
+	if err != nil {
+		errors.NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) requestValidation(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.AuthKey{}).(*token.AccountClaims)
+	var body GeneratePhoneValidationDTO
+
+	err := util.ReadRequestBody(w, r, &body)
+	if err != nil {
+		errors.NewHttpError(w, err)
+		return
+	}
+	body.UserID = claims.UserID
+
+	err = c.svc.GenerateValidationCode(c.ctx, body)
+	if err != nil {
+		errors.NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) validatePhone(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.AuthKey{}).(*token.AccountClaims)
+	var body ValidatePhoneDTO
+
+	err := util.ReadRequestBody(w, r, &body)
+	if err != nil {
+		errors.NewHttpError(w, err)
+		return
+	}
+	body.PhoneID = chi.URLParam(r, "phoneId")
+	body.UserID = claims.UserID
+
+	err = c.svc.ValidatePhone(c.ctx, body)
+	if err != nil {
+		errors.NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
@@ -0,0 +1,16 @@
+package phone
+
+import (
+	"github.com/bernardinorafael/internal/sms"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+type svc struct {
+	log  logger.Logger
+	repo RepositoryInterface
+	sms  sms.Sender
+}
+
+func NewService(log logger.Logger, repo RepositoryInterface, sms sms.Sender) ServiceInterface {
+	return &svc{log, repo, sms}
+}
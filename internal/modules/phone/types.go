@@ -0,0 +1,27 @@
+package phone
+
+import "time"
+
+type Entity struct {
+	ID          string    `json:"id" db:"id"`
+	UserID      string    `json:"user_id" db:"user_id"`
+	PhoneNumber string    `json:"phone_number" db:"phone_number"`
+	IsPrimary   bool      `json:"is_primary" db:"is_primary"`
+	IsVerified  bool      `json:"is_verified" db:"is_verified"`
+	Created     time.Time `json:"created" db:"created"`
+	Updated     time.Time `json:"updated" db:"updated"`
+}
+
+type ValidationEntity struct {
+	ID          string    `json:"id" db:"id"`
+	UserID      string    `json:"user_id" db:"user_id"`
+	PhoneNumber string    `json:"phone_number" db:"phone_number"`
+	Purpose     string    `json:"purpose" db:"purpose"`
+	ChallengeID *string   `json:"challenge_id" db:"challenge_id"`
+	CodeHash    string    `json:"-" db:"code_hash"`
+	Attempts    int       `json:"attempts" db:"attempts"`
+	IsConsumed  bool      `json:"is_consumed" db:"is_consumed"`
+	IsValid     bool      `json:"is_valid" db:"is_valid"`
+	Created     time.Time `json:"created" db:"created"`
+	Expires     time.Time `json:"expires" db:"expires"`
+}
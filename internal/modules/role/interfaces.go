@@ -13,14 +13,16 @@ type RepositoryInterface interface {
 	Delete(ctx context.Context, orgId, roleId string) error
 	FindByID(ctx context.Context, orgId, roleId string) (*EntityWithPermission, error)
 	FindAll(ctx context.Context, orgId string, dto dto.SearchParams) ([]EntityWithPermission, int, error)
-	ManagePermissions(ctx context.Context, roleId string, permissions []string) error
+	// ManagePermissions replaces the permissions of the role, failing with ErrUnknownPermission
+	// when one of them is not a permission of the organization
+	ManagePermissions(ctx context.Context, orgId, roleId string, permissions []string) error
 }
 
 type ServiceInterface interface {
 	Create(ctx context.Context, params CreateRoleProps) error
 	FindAll(ctx context.Context, orgId string, dto dto.SearchParams) (*pagination.Paginated[EntityWithPermission], error)
 	GetRole(ctx context.Context, orgId, roleId string) (*EntityWithPermission, error)
-	ManagePermissions(ctx context.Context, roleId string, permissions []string) error
+	ManagePermissions(ctx context.Context, orgId, roleId string, permissions []string) error
 	Delete(ctx context.Context, orgId, roleId string) error
 	UpdateRoleInformation(ctx context.Context, orgId, roleId string, dto UpdateRoleDTO) error
 }
@@ -3,7 +3,9 @@ package role
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/bernardinorafael/internal/_shared/util"
 	"github.com/bernardinorafael/pkg/transaction"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type repo struct {
@@ -21,7 +24,10 @@ func NewRepository(db *sqlx.DB) RepositoryInterface {
 	return &repo{db}
 }
 
-func (r *repo) ManagePermissions(ctx context.Context, roleId string, permissions []string) error {
+// ErrUnknownPermission is returned when a permission granted is not one of the organization's
+var ErrUnknownPermission = errors.New("permission not found in organization")
+
+func (r *repo) ManagePermissions(ctx context.Context, orgId, roleId string, permissions []string) error {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
@@ -35,6 +41,24 @@ func (r *repo) ManagePermissions(ctx context.Context, roleId string, permissions
 			return nil
 		}
 
+		// Roles are only granted the permissions of their own organization
+		unique := slices.Compact(slices.Sorted(slices.Values(permissions)))
+
+		var found int
+		err = tx.GetContext(
+			ctx,
+			&found,
+			"SELECT COUNT(DISTINCT id) FROM permissions WHERE id = ANY($1) AND org_id = $2",
+			pq.Array(permissions),
+			orgId,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to find permissions: %w", err)
+		}
+		if found != len(unique) {
+			return ErrUnknownPermission
+		}
+
 		perms := make([]RolePermissionBatch, len(permissions))
 		for i, permissionId := range permissions {
 			perms[i] = RolePermissionBatch{
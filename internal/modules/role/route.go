@@ -8,45 +8,48 @@ import (
 	. "github.com/bernardinorafael/internal/_shared/errors"
 	"github.com/bernardinorafael/internal/_shared/util"
 	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/modules/permission"
 	"github.com/bernardinorafael/pkg/logger"
 	"github.com/go-chi/chi"
 )
 
 type controller struct {
-	ctx       context.Context
-	log       logger.Logger
-	svc       ServiceInterface
-	secretKey string
+	ctx  context.Context
+	log  logger.Logger
+	svc  ServiceInterface
+	auth *middleware.Auth
 }
 
 func NewController(
 	ctx context.Context,
 	log logger.Logger,
 	svc ServiceInterface,
-	secretKey string,
+	auth *middleware.Auth,
 ) *controller {
 	return &controller{
-		ctx:       ctx,
-		log:       log,
-		svc:       svc,
-		secretKey: secretKey,
+		ctx:  ctx,
+		log:  log,
+		svc:  svc,
+		auth: auth,
 	}
 }
 
 func (c controller) RegisterRoute(r *chi.Mux) {
-	m := middleware.NewWithAuth(c.log, c.secretKey)
+	m := c.auth
 
 	r.Route("/api/v1/roles", func(r chi.Router) {
 		r.Use(m.WithAuth)
+		// Every route names the organization, in which the role is looked up
+		r.Use(m.WithOrgScope("orgId"))
 
-		r.Post("/org/{orgId}", c.createRole)
-		r.Get("/org/{orgId}", c.getRoles)
-		r.Get("/org/{orgId}/role/{roleId}", c.getRole)
-		r.Delete("/org/{orgId}/role/{roleId}", c.deleteRole)
-		r.Patch("/org/{orgId}/role/{roleId}", c.updateRole)
+		r.With(m.WithPermission(permission.KeyRolesWrite)).Post("/org/{orgId}", c.createRole)
+		r.With(m.WithPermission(permission.KeyRolesRead)).Get("/org/{orgId}", c.getRoles)
+		r.With(m.WithPermission(permission.KeyRolesRead)).Get("/org/{orgId}/role/{roleId}", c.getRole)
+		r.With(m.WithPermission(permission.KeyRolesWrite)).Delete("/org/{orgId}/role/{roleId}", c.deleteRole)
+		r.With(m.WithPermission(permission.KeyRolesWrite)).Patch("/org/{orgId}/role/{roleId}", c.updateRole)
 
 		// Permissions
-		r.Patch("/permissions/org/{orgId}/role/{roleId}", c.managePermissions)
+		r.With(m.WithPermission(permission.KeyRolesWrite)).Patch("/permissions/org/{orgId}/role/{roleId}", c.managePermissions)
 	})
 }
 
@@ -100,7 +103,7 @@ func (c controller) managePermissions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.svc.ManagePermissions(c.ctx, roleId, input.Permissions)
+	err = c.svc.ManagePermissions(c.ctx, chi.URLParam(r, "orgId"), roleId, input.Permissions)
 	if err != nil {
 		NewHttpError(w, err)
 		return
@@ -23,9 +23,23 @@ func NewService(log logger.Logger, repo RepositoryInterface) ServiceInterface {
 	return &svc{log, repo}
 }
 
-func (s svc) ManagePermissions(ctx context.Context, roleId string, permissions []string) error {
-	err := s.repo.ManagePermissions(ctx, roleId, permissions)
+func (s svc) ManagePermissions(ctx context.Context, orgId, roleId string, permissions []string) error {
+	role, err := s.repo.FindByID(ctx, orgId, roleId)
 	if err != nil {
+		s.log.Errorw(ctx, "failed to get role", logger.Err(err))
+		return NewBadRequestError("failed to get role", err)
+	}
+	if role == nil {
+		return NewNotFoundError("role not found", nil)
+	}
+
+	err = s.repo.ManagePermissions(ctx, orgId, roleId, permissions)
+	if err != nil {
+		if errors.Is(err, ErrUnknownPermission) {
+			return NewValidationFieldError("unknown permission", err, []Field{
+				{Field: "permissions", Msg: "permission not found in organization"},
+			})
+		}
 		s.log.Errorw(ctx, "failed to manage permissions", logger.Err(err))
 		return NewBadRequestError("failed to manage permissions", err)
 	}
@@ -0,0 +1,17 @@
+package serviceaccount
+
+type CreateServiceAccountDTO struct {
+	Name   string `json:"name"`
+	RoleID string `json:"role_id"`
+}
+
+// Credentials are only returned once, on creation and when the secret is rotated
+type Credentials struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
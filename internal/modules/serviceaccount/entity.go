@@ -0,0 +1,119 @@
+package serviceaccount
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/pkg/crypto"
+)
+
+const secretSize = 32
+
+var (
+	ErrEmptyName = errors.New("name is a required field")
+	ErrEmptyRole = errors.New("role is a required field")
+)
+
+type serviceAccount struct {
+	id         string
+	orgId      string
+	name       string
+	secretHash string
+	roleId     string
+	lastUsed   *time.Time
+	created    time.Time
+	updated    time.Time
+}
+
+func NewFromDatabase(entity Entity) *serviceAccount {
+	return &serviceAccount{
+		id:         entity.ID,
+		orgId:      entity.OrgID,
+		name:       entity.Name,
+		secretHash: entity.SecretHash,
+		roleId:     entity.RoleID,
+		lastUsed:   entity.LastUsed,
+		created:    entity.Created,
+		updated:    entity.Updated,
+	}
+}
+
+// New creates a service account of the organization, acting with the permissions of the role
+// Its ID is the client ID, the secret is returned in plain text only here
+func New(orgId, name, roleId string) (*serviceAccount, string, error) {
+	s := &serviceAccount{
+		id:       util.GenID("svc"),
+		orgId:    orgId,
+		name:     strings.TrimSpace(name),
+		roleId:   roleId,
+		lastUsed: nil,
+		created:  time.Now(),
+		updated:  time.Now(),
+	}
+
+	if err := s.validate(); err != nil {
+		return nil, "", err
+	}
+
+	secret, err := s.RotateSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s, secret, nil
+}
+
+func (s *serviceAccount) validate() error {
+	if s.name == "" {
+		return ErrEmptyName
+	}
+	if s.roleId == "" {
+		return ErrEmptyRole
+	}
+	return nil
+}
+
+// RotateSecret generates a new secret, only its hash is kept
+func (s *serviceAccount) RotateSecret() (string, error) {
+	secret, err := crypto.GenerateToken(secretSize)
+	if err != nil {
+		return "", err
+	}
+
+	s.secretHash = crypto.HashToken(secret)
+	s.updated = time.Now()
+
+	return secret, nil
+}
+
+func (s *serviceAccount) Authenticate(secret string) bool {
+	if secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(crypto.HashToken(secret)), []byte(s.secretHash)) == 1
+}
+
+func (s *serviceAccount) Store() Entity {
+	return Entity{
+		ID:         s.ID(),
+		OrgID:      s.OrgID(),
+		Name:       s.Name(),
+		SecretHash: s.SecretHash(),
+		RoleID:     s.RoleID(),
+		LastUsed:   s.LastUsed(),
+		Created:    s.Created(),
+		Updated:    s.Updated(),
+	}
+}
+
+func (s *serviceAccount) ID() string           { return s.id }
+func (s *serviceAccount) OrgID() string        { return s.orgId }
+func (s *serviceAccount) Name() string         { return s.name }
+func (s *serviceAccount) SecretHash() string   { return s.secretHash }
+func (s *serviceAccount) RoleID() string       { return s.roleId }
+func (s *serviceAccount) LastUsed() *time.Time { return s.lastUsed }
+func (s *serviceAccount) Created() time.Time   { return s.created }
+func (s *serviceAccount) Updated() time.Time   { return s.updated }
@@ -0,0 +1,28 @@
+package serviceaccount
+
+import (
+	"context"
+	"time"
+)
+
+type RepositoryInterface interface {
+	Insert(ctx context.Context, entity Entity) error
+	Update(ctx context.Context, entity Entity) error
+	FindByID(ctx context.Context, serviceAccountId string) (*Entity, error)
+	FindAllByOrgID(ctx context.Context, orgId string) ([]Entity, error)
+	Delete(ctx context.Context, orgId, serviceAccountId string) error
+	// Touch records the service account was used, without changing anything else
+	Touch(ctx context.Context, serviceAccountId string, at time.Time) error
+	// IsActive reports whether the service account still exists, its tokens die with it
+	IsActive(ctx context.Context, serviceAccountId string) (bool, error)
+}
+
+type ServiceInterface interface {
+	Create(ctx context.Context, orgId string, input CreateServiceAccountDTO) (*Credentials, error)
+	GetAll(ctx context.Context, orgId string) ([]Entity, error)
+	Delete(ctx context.Context, orgId, serviceAccountId string) error
+	// RotateSecret replaces the secret of the service account, the previous one stops working
+	RotateSecret(ctx context.Context, orgId, serviceAccountId string) (*Credentials, error)
+	// IssueToken authenticates the client credentials of a service account and signs a token for it
+	IssueToken(ctx context.Context, clientId, clientSecret string) (*TokenResponse, error)
+}
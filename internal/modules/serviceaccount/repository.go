@@ -0,0 +1,159 @@
+package serviceaccount
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) RepositoryInterface {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			INSERT INTO service_accounts (
+				id,
+				org_id,
+				name,
+				secret_hash,
+				role_id,
+				last_used,
+				created,
+				updated
+			) VALUES (
+				:id,
+				:org_id,
+				:name,
+				:secret_hash,
+				:role_id,
+				:last_used,
+				:created,
+				:updated
+			)
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on insert service account: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) Update(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			UPDATE service_accounts SET
+				name = :name,
+				secret_hash = :secret_hash,
+				role_id = :role_id,
+				updated = :updated
+			WHERE id = :id
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on update service account: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) FindByID(ctx context.Context, serviceAccountId string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(ctx, &entity, "SELECT * FROM service_accounts WHERE id = $1", serviceAccountId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find service account: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) FindAllByOrgID(ctx context.Context, orgId string) ([]Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entities = []Entity{}
+	err := r.db.SelectContext(
+		ctx,
+		&entities,
+		"SELECT * FROM service_accounts WHERE org_id = $1 ORDER BY created DESC",
+		orgId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error on find service accounts: %w", err)
+	}
+
+	return entities, nil
+}
+
+func (r repo) Delete(ctx context.Context, orgId, serviceAccountId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM service_accounts WHERE id = $1 AND org_id = $2",
+		serviceAccountId,
+		orgId,
+	)
+	if err != nil {
+		return fmt.Errorf("error on delete service account: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) Touch(ctx context.Context, serviceAccountId string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "UPDATE service_accounts SET last_used = $1 WHERE id = $2", at, serviceAccountId)
+	if err != nil {
+		return fmt.Errorf("error on touch service account: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) IsActive(ctx context.Context, serviceAccountId string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var exists bool
+	err := r.db.GetContext(
+		ctx,
+		&exists,
+		"SELECT EXISTS (SELECT 1 FROM service_accounts WHERE id = $1)",
+		serviceAccountId,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error on check service account: %w", err)
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,89 @@
+package serviceaccount
+
+import (
+	"context"
+	"net/http"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/modules/permission"
+	"github.com/bernardinorafael/pkg/logger"
+	"github.com/go-chi/chi"
+)
+
+type controller struct {
+	ctx  context.Context
+	log  logger.Logger
+	svc  ServiceInterface
+	auth *middleware.Auth
+}
+
+func NewController(
+	ctx context.Context,
+	log logger.Logger,
+	svc ServiceInterface,
+	auth *middleware.Auth,
+) *controller {
+	return &controller{ctx, log, svc, auth}
+}
+
+func (c controller) RegisterRoute(r *chi.Mux) {
+	m := c.auth
+
+	r.Route("/api/v1/organizations/{orgId}/service-accounts", func(r chi.Router) {
+		r.Use(m.WithAuth, m.WithOrgScope("orgId"), m.WithPermission(permission.KeyServiceAccountsManage))
+
+		r.Post("/", c.create)
+		r.Get("/", c.getAll)
+		r.Delete("/{serviceAccountId}", c.delete)
+		r.Post("/{serviceAccountId}/secret", c.rotateSecret)
+	})
+}
+
+func (c controller) create(w http.ResponseWriter, r *http.Request) {
+	var body CreateServiceAccountDTO
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	credentials, err := c.svc.Create(r.Context(), chi.URLParam(r, "orgId"), body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusCreated, credentials)
+}
+
+func (c controller) getAll(w http.ResponseWriter, r *http.Request) {
+	accounts, err := c.svc.GetAll(r.Context(), chi.URLParam(r, "orgId"))
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, map[string]any{"service_accounts": accounts})
+}
+
+func (c controller) delete(w http.ResponseWriter, r *http.Request) {
+	err := c.svc.Delete(r.Context(), chi.URLParam(r, "orgId"), chi.URLParam(r, "serviceAccountId"))
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) rotateSecret(w http.ResponseWriter, r *http.Request) {
+	credentials, err := c.svc.RotateSecret(r.Context(), chi.URLParam(r, "orgId"), chi.URLParam(r, "serviceAccountId"))
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, credentials)
+}
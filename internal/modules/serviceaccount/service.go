@@ -0,0 +1,187 @@
+package serviceaccount
+
+import (
+	"context"
+	"time"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/internal/modules/org"
+	"github.com/bernardinorafael/internal/modules/role"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+// Service accounts have no refresh tokens, they authenticate again once the token expires
+const tokenDuration = time.Minute * 15
+
+var (
+	errInvalidClient = NewUnauthorizedError("client authentication failed", nil)
+)
+
+type svc struct {
+	log      logger.Logger
+	repo     RepositoryInterface
+	orgSvc   org.ServiceInterface
+	roleRepo role.RepositoryInterface
+	keys     *token.KeySet
+}
+
+func NewService(
+	log logger.Logger,
+	repo RepositoryInterface,
+	orgSvc org.ServiceInterface,
+	roleRepo role.RepositoryInterface,
+	keys *token.KeySet,
+) ServiceInterface {
+	return &svc{
+		log:      log,
+		repo:     repo,
+		orgSvc:   orgSvc,
+		roleRepo: roleRepo,
+		keys:     keys,
+	}
+}
+
+func (s svc) Create(ctx context.Context, orgId string, input CreateServiceAccountDTO) (*Credentials, error) {
+	if err := s.orgSvc.CheckOwner(ctx, orgId); err != nil {
+		return nil, err
+	}
+
+	// Roles of other organizations are reported as missing, like roles that don't exist
+	if _, err := s.roleRepo.FindByID(ctx, orgId, input.RoleID); err != nil {
+		return nil, NewValidationFieldError("role not found", err, []Field{
+			{Field: "role_id", Msg: "role does not exist in the organization"},
+		})
+	}
+
+	newAccount, secret, err := New(orgId, input.Name, input.RoleID)
+	if err != nil {
+		msg := "failed to validate service account"
+		s.log.Errorw(ctx, msg, logger.Err(err))
+		return nil, NewValidationFieldError(msg, err, nil)
+	}
+
+	if err := s.repo.Insert(ctx, newAccount.Store()); err != nil {
+		return nil, NewBadRequestError("error on insert service account", err)
+	}
+
+	s.log.Infow(
+		ctx,
+		"service account created",
+		logger.String("org_id", orgId),
+		logger.String("service_account_id", newAccount.ID()),
+	)
+
+	return &Credentials{ClientID: newAccount.ID(), ClientSecret: secret}, nil
+}
+
+func (s svc) GetAll(ctx context.Context, orgId string) ([]Entity, error) {
+	if err := s.orgSvc.CheckOwner(ctx, orgId); err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.repo.FindAllByOrgID(ctx, orgId)
+	if err != nil {
+		return nil, NewBadRequestError("error on find service accounts", err)
+	}
+
+	return accounts, nil
+}
+
+func (s svc) Delete(ctx context.Context, orgId, serviceAccountId string) error {
+	if _, err := s.find(ctx, orgId, serviceAccountId); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, orgId, serviceAccountId); err != nil {
+		return NewBadRequestError("error on delete service account", err)
+	}
+
+	s.log.Infow(ctx, "service account deleted", logger.String("service_account_id", serviceAccountId))
+
+	return nil
+}
+
+func (s svc) RotateSecret(ctx context.Context, orgId, serviceAccountId string) (*Credentials, error) {
+	existing, err := s.find(ctx, orgId, serviceAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := existing.RotateSecret()
+	if err != nil {
+		return nil, NewBadRequestError("error on generate secret", err)
+	}
+
+	if err := s.repo.Update(ctx, existing.Store()); err != nil {
+		return nil, NewBadRequestError("error on update service account", err)
+	}
+
+	s.log.Infow(ctx, "service account secret rotated", logger.String("service_account_id", serviceAccountId))
+
+	return &Credentials{ClientID: existing.ID(), ClientSecret: secret}, nil
+}
+
+func (s svc) IssueToken(ctx context.Context, clientId, clientSecret string) (*TokenResponse, error) {
+	record, err := s.repo.FindByID(ctx, clientId)
+	if err != nil {
+		return nil, NewBadRequestError("error on find service account", err)
+	}
+	if record == nil {
+		return nil, errInvalidClient
+	}
+
+	existing := NewFromDatabase(*record)
+	if !existing.Authenticate(clientSecret) {
+		return nil, errInvalidClient
+	}
+
+	// The permissions are those of the role when the token is issued, changes apply on the next one
+	found, err := s.roleRepo.FindByID(ctx, existing.OrgID(), existing.RoleID())
+	if err != nil {
+		return nil, NewBadRequestError("error on find service account role", err)
+	}
+	permissions := make([]string, 0, len(found.Permissions))
+	for _, p := range found.Permissions {
+		permissions = append(permissions, p.Key)
+	}
+
+	claims := token.NewServiceClaims(existing.ID(), existing.OrgID(), existing.Name(), permissions, tokenDuration)
+	accessToken, err := token.GenerateServiceToken(s.keys, claims)
+	if err != nil {
+		return nil, NewBadRequestError("error on generate service token", err)
+	}
+
+	if err := s.repo.Touch(ctx, existing.ID(), time.Now()); err != nil {
+		s.log.Errorw(ctx, "error on touch service account", logger.Err(err))
+	}
+
+	s.log.Infow(
+		ctx,
+		"service token issued",
+		logger.String("principal", token.PrincipalService),
+		logger.String("service_account_id", existing.ID()),
+	)
+
+	return &TokenResponse{
+		AccessToken: accessToken,
+		ExpiresIn:   int64(tokenDuration.Seconds()),
+	}, nil
+}
+
+// find returns the service account, reporting the ones of other organizations as missing
+func (s svc) find(ctx context.Context, orgId, serviceAccountId string) (*serviceAccount, error) {
+	if err := s.orgSvc.CheckOwner(ctx, orgId); err != nil {
+		return nil, err
+	}
+
+	record, err := s.repo.FindByID(ctx, serviceAccountId)
+	if err != nil {
+		return nil, NewBadRequestError("error on find service account", err)
+	}
+	if record == nil || record.OrgID != orgId {
+		return nil, NewNotFoundError("service account not found", nil)
+	}
+
+	return NewFromDatabase(*record), nil
+}
@@ -0,0 +1,14 @@
+package serviceaccount
+
+import "time"
+
+type Entity struct {
+	ID         string     `json:"id" db:"id"`
+	OrgID      string     `json:"org_id" db:"org_id"`
+	Name       string     `json:"name" db:"name"`
+	SecretHash string     `json:"-" db:"secret_hash"`
+	RoleID     string     `json:"role_id" db:"role_id"`
+	LastUsed   *time.Time `json:"last_used" db:"last_used"`
+	Created    time.Time  `json:"created" db:"created"`
+	Updated    time.Time  `json:"updated" db:"updated"`
+}
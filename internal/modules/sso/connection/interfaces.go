@@ -0,0 +1,10 @@
+package connection
+
+import "context"
+
+type RepositoryInterface interface {
+	Insert(ctx context.Context, entity Entity) error
+	FindByID(ctx context.Context, connectionId string) (*Entity, error)
+	FindAllByOrgID(ctx context.Context, orgId string) ([]Entity, error)
+	Delete(ctx context.Context, orgId, connectionId string) error
+}
@@ -0,0 +1,118 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) RepositoryInterface {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			INSERT INTO sso_connections (
+				id,
+				org_id,
+				name,
+				issuer,
+				client_id,
+				client_secret,
+				scopes,
+				auto_provision,
+				require_mfa,
+				default_team_id,
+				default_role_id,
+				is_enabled,
+				created,
+				updated
+			) VALUES (
+				:id,
+				:org_id,
+				:name,
+				:issuer,
+				:client_id,
+				:client_secret,
+				:scopes,
+				:auto_provision,
+				:require_mfa,
+				:default_team_id,
+				:default_role_id,
+				:is_enabled,
+				:created,
+				:updated
+			)
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on insert sso connection: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) FindByID(ctx context.Context, connectionId string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(ctx, &entity, "SELECT * FROM sso_connections WHERE id = $1", connectionId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find sso connection: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) FindAllByOrgID(ctx context.Context, orgId string) ([]Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entities = []Entity{}
+	err := r.db.SelectContext(
+		ctx,
+		&entities,
+		"SELECT * FROM sso_connections WHERE org_id = $1 ORDER BY created DESC",
+		orgId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error on find sso connections: %w", err)
+	}
+
+	return entities, nil
+}
+
+func (r repo) Delete(ctx context.Context, orgId, connectionId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM sso_connections WHERE id = $1 AND org_id = $2",
+		connectionId,
+		orgId,
+	)
+	if err != nil {
+		return fmt.Errorf("error on delete sso connection: %w", err)
+	}
+
+	return nil
+}
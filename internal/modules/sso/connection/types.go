@@ -0,0 +1,25 @@
+package connection
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type Entity struct {
+	ID            string         `json:"id" db:"id"`
+	OrgID         string         `json:"org_id" db:"org_id"`
+	Name          string         `json:"name" db:"name"`
+	Issuer        string         `json:"issuer" db:"issuer"`
+	ClientID      string         `json:"client_id" db:"client_id"`
+	ClientSecret  string         `json:"-" db:"client_secret"`
+	Scopes        pq.StringArray `json:"scopes" db:"scopes"`
+	AutoProvision bool           `json:"auto_provision" db:"auto_provision"`
+	// RequireMFA makes members enrolled in two-factor authentication complete it after the provider
+	RequireMFA    bool      `json:"require_mfa" db:"require_mfa"`
+	DefaultTeamID *string   `json:"default_team_id" db:"default_team_id"`
+	DefaultRoleID *string   `json:"default_role_id" db:"default_role_id"`
+	IsEnabled     bool      `json:"is_enabled" db:"is_enabled"`
+	Created       time.Time `json:"created" db:"created"`
+	Updated       time.Time `json:"updated" db:"updated"`
+}
@@ -0,0 +1,20 @@
+package sso
+
+type CreateConnectionDTO struct {
+	Name          string   `json:"name"`
+	Issuer        string   `json:"issuer"`
+	ClientID      string   `json:"client_id"`
+	ClientSecret  string   `json:"client_secret"`
+	Scopes        []string `json:"scopes"`
+	AutoProvision bool     `json:"auto_provision"`
+	// RequireMFA defaults to true, see connection.New
+	RequireMFA    *bool   `json:"require_mfa"`
+	DefaultTeamID *string `json:"default_team_id"`
+	DefaultRoleID *string `json:"default_role_id"`
+}
+
+type ExchangeTicketDTO struct {
+	Ticket    string `json:"ticket"`
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
+}
@@ -0,0 +1,75 @@
+package identity
+
+import (
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+)
+
+type linkedIdentity struct {
+	id           string
+	userId       string
+	connectionId string
+	issuer       string
+	subject      string
+	email        string
+	created      time.Time
+	lastLogin    time.Time
+}
+
+func NewFromDatabase(entity Entity) *linkedIdentity {
+	return &linkedIdentity{
+		id:           entity.ID,
+		userId:       entity.UserID,
+		connectionId: entity.ConnectionID,
+		issuer:       entity.Issuer,
+		subject:      entity.Subject,
+		email:        entity.Email,
+		created:      entity.Created,
+		lastLogin:    entity.LastLogin,
+	}
+}
+
+// New links the user to the identity the issuer knows by subject
+func New(userId, connectionId, issuer, subject, email string) *linkedIdentity {
+	return &linkedIdentity{
+		id:           util.GenID("lid"),
+		userId:       userId,
+		connectionId: connectionId,
+		issuer:       issuer,
+		subject:      subject,
+		email:        email,
+		created:      time.Now(),
+		lastLogin:    time.Now(),
+	}
+}
+
+// SignedIn records a sign in, keeping the email the provider reports up to date
+func (l *linkedIdentity) SignedIn(email string) {
+	if email != "" {
+		l.email = email
+	}
+	l.lastLogin = time.Now()
+}
+
+func (l *linkedIdentity) Store() Entity {
+	return Entity{
+		ID:           l.ID(),
+		UserID:       l.UserID(),
+		ConnectionID: l.ConnectionID(),
+		Issuer:       l.Issuer(),
+		Subject:      l.Subject(),
+		Email:        l.Email(),
+		Created:      l.Created(),
+		LastLogin:    l.LastLogin(),
+	}
+}
+
+func (l *linkedIdentity) ID() string           { return l.id }
+func (l *linkedIdentity) UserID() string       { return l.userId }
+func (l *linkedIdentity) ConnectionID() string { return l.connectionId }
+func (l *linkedIdentity) Issuer() string       { return l.issuer }
+func (l *linkedIdentity) Subject() string      { return l.subject }
+func (l *linkedIdentity) Email() string        { return l.email }
+func (l *linkedIdentity) Created() time.Time   { return l.created }
+func (l *linkedIdentity) LastLogin() time.Time { return l.lastLogin }
@@ -0,0 +1,10 @@
+package identity
+
+import "context"
+
+type RepositoryInterface interface {
+	Insert(ctx context.Context, entity Entity) error
+	Update(ctx context.Context, entity Entity) error
+	FindByIssuerAndSubject(ctx context.Context, issuer, subject string) (*Entity, error)
+	FindAllByUserID(ctx context.Context, userId string) ([]Entity, error)
+}
@@ -0,0 +1,117 @@
+package identity
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) RepositoryInterface {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			INSERT INTO linked_identities (
+				id,
+				user_id,
+				connection_id,
+				issuer,
+				subject,
+				email,
+				created,
+				last_login
+			) VALUES (
+				:id,
+				:user_id,
+				:connection_id,
+				:issuer,
+				:subject,
+				:email,
+				:created,
+				:last_login
+			)
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on insert linked identity: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) Update(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			UPDATE linked_identities
+			SET
+				email = :email,
+				last_login = :last_login
+			WHERE id = :id
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on update linked identity: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) FindByIssuerAndSubject(ctx context.Context, issuer, subject string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(
+		ctx,
+		&entity,
+		"SELECT * FROM linked_identities WHERE issuer = $1 AND subject = $2",
+		issuer,
+		subject,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find linked identity: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) FindAllByUserID(ctx context.Context, userId string) ([]Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entities = []Entity{}
+	err := r.db.SelectContext(
+		ctx,
+		&entities,
+		"SELECT * FROM linked_identities WHERE user_id = $1 ORDER BY created DESC",
+		userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error on find linked identities: %w", err)
+	}
+
+	return entities, nil
+}
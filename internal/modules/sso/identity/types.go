@@ -0,0 +1,14 @@
+package identity
+
+import "time"
+
+type Entity struct {
+	ID           string    `json:"id" db:"id"`
+	UserID       string    `json:"user_id" db:"user_id"`
+	ConnectionID string    `json:"connection_id" db:"connection_id"`
+	Issuer       string    `json:"issuer" db:"issuer"`
+	Subject      string    `json:"subject" db:"subject"`
+	Email        string    `json:"email" db:"email"`
+	Created      time.Time `json:"created" db:"created"`
+	LastLogin    time.Time `json:"last_login" db:"last_login"`
+}
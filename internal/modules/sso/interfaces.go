@@ -0,0 +1,22 @@
+package sso
+
+import (
+	"context"
+
+	"github.com/bernardinorafael/internal/_shared/dto"
+	"github.com/bernardinorafael/internal/modules/sso/connection"
+)
+
+type ServiceInterface interface {
+	CreateConnection(ctx context.Context, orgId string, input CreateConnectionDTO) (*connection.Entity, error)
+	GetAllConnections(ctx context.Context, orgId string) ([]connection.Entity, error)
+	DeleteConnection(ctx context.Context, orgId, connectionId string) error
+	// StartLogin returns the authorization URL of the provider of the connection
+	StartLogin(ctx context.Context, connectionId string) (redirectTo string, err error)
+	// Callback completes the sign in with the provider, returning the front end page
+	// the user is sent to, carrying either a ticket or the error
+	Callback(ctx context.Context, state, code, providerError string) (redirectTo string)
+	// ExchangeTicket trades the ticket of a completed sign in for a session. Members enrolled in
+	// two-factor authentication get a challenge instead when the connection requires it
+	ExchangeTicket(ctx context.Context, input ExchangeTicketDTO) (*dto.AccountResponse, *dto.MFAChallenge, error)
+}
@@ -0,0 +1,123 @@
+package loginstate
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/pkg/crypto"
+)
+
+const (
+	TokenSize = 32
+	// StateTTL is how long the user has to sign in with the provider
+	StateTTL = time.Minute * 10
+	// TicketTTL is how long the front end has to exchange the ticket for a session
+	TicketTTL = time.Minute
+)
+
+type loginState struct {
+	id           string
+	stateHash    string
+	connectionId string
+	nonce        string
+	codeVerifier string
+	ticketHash   *string
+	accountId    *string
+	created      time.Time
+	expires      time.Time
+}
+
+func NewFromDatabase(entity Entity) *loginState {
+	return &loginState{
+		id:           entity.ID,
+		stateHash:    entity.StateHash,
+		connectionId: entity.ConnectionID,
+		nonce:        entity.Nonce,
+		codeVerifier: entity.CodeVerifier,
+		ticketHash:   entity.TicketHash,
+		accountId:    entity.AccountID,
+		created:      entity.Created,
+		expires:      entity.Expires,
+	}
+}
+
+// New starts a sign in with the connection, returning the plain state sent to the provider
+// The nonce and the PKCE verifier never leave the server
+func New(connectionId string) (*loginState, string, error) {
+	state, err := crypto.GenerateToken(TokenSize)
+	if err != nil {
+		return nil, "", err
+	}
+	nonce, err := crypto.GenerateToken(TokenSize)
+	if err != nil {
+		return nil, "", err
+	}
+	verifier, err := crypto.GenerateToken(TokenSize)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &loginState{
+		id:           util.GenID("sls"),
+		stateHash:    crypto.HashToken(state),
+		connectionId: connectionId,
+		nonce:        nonce,
+		codeVerifier: verifier,
+		created:      time.Now(),
+		expires:      time.Now().Add(StateTTL),
+	}, state, nil
+}
+
+// CodeChallenge is the S256 PKCE challenge of the verifier
+func (l *loginState) CodeChallenge() string {
+	sum := sha256.Sum256([]byte(l.codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Complete binds the account signed in to the state, returning the plain ticket
+// the front end exchanges for a session
+func (l *loginState) Complete(accountId string) (string, error) {
+	ticket, err := crypto.GenerateToken(TokenSize)
+	if err != nil {
+		return "", err
+	}
+
+	hash := crypto.HashToken(ticket)
+	l.ticketHash = &hash
+	l.accountId = &accountId
+	l.expires = time.Now().Add(TicketTTL)
+
+	return ticket, nil
+}
+
+func (l *loginState) IsExpired() bool {
+	return time.Now().After(l.expires)
+}
+
+func (l *loginState) IsCompleted() bool {
+	return l.ticketHash != nil
+}
+
+func (l *loginState) Store() Entity {
+	return Entity{
+		ID:           l.ID(),
+		StateHash:    l.StateHash(),
+		ConnectionID: l.ConnectionID(),
+		Nonce:        l.Nonce(),
+		CodeVerifier: l.CodeVerifier(),
+		TicketHash:   l.ticketHash,
+		AccountID:    l.accountId,
+		Created:      l.Created(),
+		Expires:      l.Expires(),
+	}
+}
+
+func (l *loginState) ID() string           { return l.id }
+func (l *loginState) StateHash() string    { return l.stateHash }
+func (l *loginState) ConnectionID() string { return l.connectionId }
+func (l *loginState) Nonce() string        { return l.nonce }
+func (l *loginState) CodeVerifier() string { return l.codeVerifier }
+func (l *loginState) Created() time.Time   { return l.created }
+func (l *loginState) Expires() time.Time   { return l.expires }
@@ -0,0 +1,19 @@
+package loginstate
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAlreadyCompleted is returned by Complete when the provider already called back for the state
+var ErrAlreadyCompleted = errors.New("login state already completed")
+
+type RepositoryInterface interface {
+	Insert(ctx context.Context, entity Entity) error
+	FindByStateHash(ctx context.Context, stateHash string) (*Entity, error)
+	// Complete stores the ticket of the state, only the first callback succeeds
+	Complete(ctx context.Context, entity Entity) error
+	// ConsumeTicket deletes and returns the state holding the ticket, so it is exchanged only once
+	ConsumeTicket(ctx context.Context, ticketHash string) (*Entity, error)
+	DeleteExpired(ctx context.Context) error
+}
@@ -0,0 +1,137 @@
+package loginstate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) RepositoryInterface {
+	return &repo{db: db}
+}
+
+func (r repo) Insert(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`
+			INSERT INTO sso_login_states (
+				id,
+				state_hash,
+				connection_id,
+				nonce,
+				code_verifier,
+				ticket_hash,
+				account_id,
+				created,
+				expires
+			) VALUES (
+				:id,
+				:state_hash,
+				:connection_id,
+				:nonce,
+				:code_verifier,
+				:ticket_hash,
+				:account_id,
+				:created,
+				:expires
+			)
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on insert sso login state: %w", err)
+	}
+
+	return nil
+}
+
+func (r repo) FindByStateHash(ctx context.Context, stateHash string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(ctx, &entity, "SELECT * FROM sso_login_states WHERE state_hash = $1", stateHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on find sso login state: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) Complete(ctx context.Context, entity Entity) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := r.db.NamedExecContext(
+		ctx,
+		`
+			UPDATE sso_login_states
+			SET
+				ticket_hash = :ticket_hash,
+				account_id = :account_id,
+				expires = :expires
+			WHERE id = :id AND ticket_hash IS NULL
+		`,
+		entity,
+	)
+	if err != nil {
+		return fmt.Errorf("error on complete sso login state: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error on complete sso login state: %w", err)
+	}
+	if affected == 0 {
+		return ErrAlreadyCompleted
+	}
+
+	return nil
+}
+
+func (r repo) ConsumeTicket(ctx context.Context, ticketHash string) (*Entity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var entity Entity
+	err := r.db.GetContext(
+		ctx,
+		&entity,
+		"DELETE FROM sso_login_states WHERE ticket_hash = $1 RETURNING *",
+		ticketHash,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error on consume sso ticket: %w", err)
+	}
+
+	return &entity, nil
+}
+
+func (r repo) DeleteExpired(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM sso_login_states WHERE expires < now()")
+	if err != nil {
+		return fmt.Errorf("error on delete expired sso login states: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package loginstate
+
+import "time"
+
+type Entity struct {
+	ID           string    `json:"id" db:"id"`
+	StateHash    string    `json:"-" db:"state_hash"`
+	ConnectionID string    `json:"connection_id" db:"connection_id"`
+	Nonce        string    `json:"-" db:"nonce"`
+	CodeVerifier string    `json:"-" db:"code_verifier"`
+	TicketHash   *string   `json:"-" db:"ticket_hash"`
+	AccountID    *string   `json:"account_id" db:"account_id"`
+	Created      time.Time `json:"created" db:"created"`
+	Expires      time.Time `json:"expires" db:"expires"`
+}
@@ -0,0 +1,127 @@
+package sso
+
+import (
+	"context"
+	"net/http"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/_shared/util"
+	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/modules/permission"
+	"github.com/bernardinorafael/pkg/logger"
+	"github.com/go-chi/chi"
+)
+
+type controller struct {
+	ctx  context.Context
+	log  logger.Logger
+	svc  ServiceInterface
+	auth *middleware.Auth
+}
+
+func NewController(
+	ctx context.Context,
+	log logger.Logger,
+	svc ServiceInterface,
+	auth *middleware.Auth,
+) *controller {
+	return &controller{ctx, log, svc, auth}
+}
+
+func (c controller) RegisterRoute(r *chi.Mux) {
+	m := c.auth
+
+	r.Route("/api/v1/auth/sso", func(r chi.Router) {
+		r.Get("/callback", c.callback)
+		r.Post("/exchange", c.exchangeTicket)
+		r.Get("/{connectionId}", c.startLogin)
+	})
+
+	r.Route("/api/v1/organizations/{orgId}/sso", func(r chi.Router) {
+		r.Use(m.WithAuth, m.WithOrgScope("orgId"), m.WithPermission(permission.KeySSOManage))
+
+		r.Post("/connections", c.createConnection)
+		r.Get("/connections", c.getAllConnections)
+		r.Delete("/connections/{connectionId}", c.deleteConnection)
+	})
+}
+
+func (c controller) startLogin(w http.ResponseWriter, r *http.Request) {
+	redirectTo, err := c.svc.StartLogin(r.Context(), chi.URLParam(r, "connectionId"))
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, redirectTo, http.StatusFound)
+}
+
+func (c controller) callback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	redirectTo := c.svc.Callback(r.Context(), q.Get("state"), q.Get("code"), q.Get("error"))
+
+	http.Redirect(w, r, redirectTo, http.StatusFound)
+}
+
+func (c controller) exchangeTicket(w http.ResponseWriter, r *http.Request) {
+	var body ExchangeTicketDTO
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+	body.UserAgent = r.UserAgent()
+	body.IP = r.RemoteAddr
+
+	res, challenge, err := c.svc.ExchangeTicket(r.Context(), body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	// The second factor is completed on the login endpoint, like after a password
+	if challenge != nil {
+		util.WriteJSONResponse(w, http.StatusOK, challenge)
+		return
+	}
+
+	middleware.WriteSession(w, r, res)
+}
+
+func (c controller) createConnection(w http.ResponseWriter, r *http.Request) {
+	var body CreateConnectionDTO
+
+	if err := util.ReadRequestBody(w, r, &body); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	created, err := c.svc.CreateConnection(r.Context(), chi.URLParam(r, "orgId"), body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusCreated, map[string]any{"connection": created})
+}
+
+func (c controller) getAllConnections(w http.ResponseWriter, r *http.Request) {
+	conns, err := c.svc.GetAllConnections(r.Context(), chi.URLParam(r, "orgId"))
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteJSONResponse(w, http.StatusOK, map[string]any{"connections": conns})
+}
+
+func (c controller) deleteConnection(w http.ResponseWriter, r *http.Request) {
+	err := c.svc.DeleteConnection(r.Context(), chi.URLParam(r, "orgId"), chi.URLParam(r, "connectionId"))
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
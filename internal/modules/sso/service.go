@@ -0,0 +1,458 @@
+package sso
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/bernardinorafael/internal/_shared/dto"
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/infra/oidc"
+	"github.com/bernardinorafael/internal/modules/account"
+	"github.com/bernardinorafael/internal/modules/email"
+	"github.com/bernardinorafael/internal/modules/org"
+	"github.com/bernardinorafael/internal/modules/sso/connection"
+	"github.com/bernardinorafael/internal/modules/sso/identity"
+	"github.com/bernardinorafael/internal/modules/sso/loginstate"
+	"github.com/bernardinorafael/internal/modules/team"
+	"github.com/bernardinorafael/internal/modules/user"
+	"github.com/bernardinorafael/pkg/crypto"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+// CallbackPath is where providers send users back to, it must be registered with them
+const CallbackPath = "/api/v1/auth/sso/callback"
+
+// Errors reported to the front end when a sign in with a provider fails
+const (
+	errorAccessDenied       = "access_denied"
+	errorInvalidState       = "invalid_state"
+	errorNotProvisioned     = "not_provisioned"
+	errorAccountUnavailable = "account_unavailable"
+	errorServer             = "server_error"
+)
+
+var errNotProvisioned = errors.New("user is not linked and provisioning is disabled")
+
+type svc struct {
+	log          logger.Logger
+	connRepo     connection.RepositoryInterface
+	identityRepo identity.RepositoryInterface
+	stateRepo    loginstate.RepositoryInterface
+	orgSvc       org.ServiceInterface
+	accountRepo  account.RepositoryInterface
+	emailRepo    email.RepositoryInterface
+	accountSvc   account.ServiceInterface
+	userSvc      user.ServiceInterface
+	teamSvc      team.ServiceInterface
+	oidc         *oidc.Client
+	// callbackURL is the public URL of CallbackPath
+	callbackURL string
+	frontEndURL string
+}
+
+func NewService(
+	log logger.Logger,
+	connRepo connection.RepositoryInterface,
+	identityRepo identity.RepositoryInterface,
+	stateRepo loginstate.RepositoryInterface,
+	orgSvc org.ServiceInterface,
+	accountRepo account.RepositoryInterface,
+	emailRepo email.RepositoryInterface,
+	accountSvc account.ServiceInterface,
+	userSvc user.ServiceInterface,
+	teamSvc team.ServiceInterface,
+	oidcClient *oidc.Client,
+	publicURL string,
+	frontEndURL string,
+) ServiceInterface {
+	return &svc{
+		log:          log,
+		connRepo:     connRepo,
+		identityRepo: identityRepo,
+		stateRepo:    stateRepo,
+		orgSvc:       orgSvc,
+		accountRepo:  accountRepo,
+		emailRepo:    emailRepo,
+		accountSvc:   accountSvc,
+		userSvc:      userSvc,
+		teamSvc:      teamSvc,
+		oidc:         oidcClient,
+		callbackURL:  strings.TrimSuffix(publicURL, "/") + CallbackPath,
+		frontEndURL:  frontEndURL,
+	}
+}
+
+func (s svc) CreateConnection(ctx context.Context, orgId string, input CreateConnectionDTO) (*connection.Entity, error) {
+	if err := s.orgSvc.CheckOwner(ctx, orgId); err != nil {
+		return nil, err
+	}
+
+	requireMFA := input.RequireMFA == nil || *input.RequireMFA
+
+	conn, err := connection.New(
+		orgId,
+		input.Name,
+		input.Issuer,
+		input.ClientID,
+		input.ClientSecret,
+		input.Scopes,
+		input.AutoProvision,
+		requireMFA,
+		input.DefaultTeamID,
+		input.DefaultRoleID,
+	)
+	if err != nil {
+		msg := "failed to validate sso connection"
+		s.log.Errorw(ctx, msg, logger.Err(err))
+		return nil, NewValidationFieldError(msg, err, nil)
+	}
+
+	// A connection that can't be discovered would only fail later, at sign in
+	if _, err := s.oidc.Provider(ctx, conn.Issuer()); err != nil {
+		if errors.Is(err, oidc.ErrIssuerNotAllowed) {
+			return nil, NewValidationFieldError("issuer is not allowed", err, []Field{
+				{Field: "issuer", Msg: "issuer must be served over https from a public address"},
+			})
+		}
+		return nil, NewValidationFieldError("failed to discover the identity provider", err, []Field{
+			{Field: "issuer", Msg: "issuer does not serve a valid OpenID Connect discovery document"},
+		})
+	}
+
+	entity := conn.Store()
+	if err := s.connRepo.Insert(ctx, entity); err != nil {
+		return nil, NewBadRequestError("error on insert sso connection", err)
+	}
+
+	s.log.Infow(ctx, "sso connection created", logger.String("org_id", orgId), logger.String("issuer", conn.Issuer()))
+
+	return &entity, nil
+}
+
+func (s svc) GetAllConnections(ctx context.Context, orgId string) ([]connection.Entity, error) {
+	if err := s.orgSvc.CheckOwner(ctx, orgId); err != nil {
+		return nil, err
+	}
+
+	conns, err := s.connRepo.FindAllByOrgID(ctx, orgId)
+	if err != nil {
+		return nil, NewBadRequestError("error on find sso connections", err)
+	}
+
+	return conns, nil
+}
+
+func (s svc) DeleteConnection(ctx context.Context, orgId, connectionId string) error {
+	if err := s.orgSvc.CheckOwner(ctx, orgId); err != nil {
+		return err
+	}
+
+	record, err := s.connRepo.FindByID(ctx, connectionId)
+	if err != nil {
+		return NewBadRequestError("error on find sso connection", err)
+	}
+	if record == nil || record.OrgID != orgId {
+		return NewNotFoundError("sso connection not found", nil)
+	}
+
+	if err := s.connRepo.Delete(ctx, orgId, connectionId); err != nil {
+		return NewBadRequestError("error on delete sso connection", err)
+	}
+
+	return nil
+}
+
+func (s svc) StartLogin(ctx context.Context, connectionId string) (string, error) {
+	record, err := s.connRepo.FindByID(ctx, connectionId)
+	if err != nil {
+		return "", NewBadRequestError("error on find sso connection", err)
+	}
+	if record == nil || !record.IsEnabled {
+		return "", NewNotFoundError("sso connection not found", nil)
+	}
+	conn := connection.NewFromDatabase(*record)
+
+	provider, err := s.oidc.Provider(ctx, conn.Issuer())
+	if err != nil {
+		s.log.Errorw(ctx, "error on discover identity provider", logger.Err(err))
+		return "", NewBadRequestError("identity provider is unavailable", err)
+	}
+
+	// Abandoned sign ins are useless, this is a good moment to get rid of them
+	if err := s.stateRepo.DeleteExpired(ctx); err != nil {
+		s.log.Errorw(ctx, "error on delete expired sso login states", logger.Err(err))
+	}
+
+	newState, state, err := loginstate.New(conn.ID())
+	if err != nil {
+		return "", NewBadRequestError("error on generate sso login state", err)
+	}
+	if err := s.stateRepo.Insert(ctx, newState.Store()); err != nil {
+		return "", NewBadRequestError("error on insert sso login state", err)
+	}
+
+	return provider.AuthCodeURL(oidc.AuthRequest{
+		ClientID:      conn.ClientID(),
+		RedirectURI:   s.callbackURL,
+		Scopes:        conn.Scopes(),
+		State:         state,
+		Nonce:         newState.Nonce(),
+		CodeChallenge: newState.CodeChallenge(),
+	}), nil
+}
+
+func (s svc) Callback(ctx context.Context, state, code, providerError string) string {
+	if providerError != "" {
+		s.log.Warnw(ctx, "identity provider denied the sign in", logger.String("error", providerError))
+		return s.callbackPage(url.Values{"error": {errorAccessDenied}})
+	}
+
+	record, err := s.stateRepo.FindByStateHash(ctx, crypto.HashToken(state))
+	if err != nil {
+		s.log.Errorw(ctx, "error on find sso login state", logger.Err(err))
+		return s.callbackPage(url.Values{"error": {errorServer}})
+	}
+	if record == nil {
+		return s.callbackPage(url.Values{"error": {errorInvalidState}})
+	}
+	current := loginstate.NewFromDatabase(*record)
+	if current.IsExpired() || current.IsCompleted() {
+		return s.callbackPage(url.Values{"error": {errorInvalidState}})
+	}
+
+	accountId, err := s.authenticate(ctx, *record, code)
+	if err != nil {
+		s.log.Errorw(ctx, "sso sign in failed", logger.String("connection_id", current.ConnectionID()), logger.Err(err))
+		if errors.Is(err, errNotProvisioned) {
+			return s.callbackPage(url.Values{"error": {errorNotProvisioned}})
+		}
+		return s.callbackPage(url.Values{"error": {errorAccountUnavailable}})
+	}
+
+	ticket, err := current.Complete(accountId)
+	if err != nil {
+		return s.callbackPage(url.Values{"error": {errorServer}})
+	}
+	if err := s.stateRepo.Complete(ctx, current.Store()); err != nil {
+		if errors.Is(err, loginstate.ErrAlreadyCompleted) {
+			return s.callbackPage(url.Values{"error": {errorInvalidState}})
+		}
+		s.log.Errorw(ctx, "error on complete sso login state", logger.Err(err))
+		return s.callbackPage(url.Values{"error": {errorServer}})
+	}
+
+	return s.callbackPage(url.Values{"ticket": {ticket}})
+}
+
+// authenticate exchanges the code with the provider and returns the account of the
+// user it identifies, linking or provisioning the user on the first sign in
+func (s svc) authenticate(ctx context.Context, state loginstate.Entity, code string) (string, error) {
+	record, err := s.connRepo.FindByID(ctx, state.ConnectionID)
+	if err != nil {
+		return "", err
+	}
+	if record == nil || !record.IsEnabled {
+		return "", errors.New("sso connection not found or disabled")
+	}
+
+	provider, err := s.oidc.Provider(ctx, record.Issuer)
+	if err != nil {
+		return "", err
+	}
+
+	tokens, err := provider.Exchange(ctx, record.ClientID, record.ClientSecret, code, s.callbackURL, state.CodeVerifier)
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, tokens.IDToken, record.ClientID, state.Nonce)
+	if err != nil {
+		return "", err
+	}
+
+	userId, err := s.resolveUser(ctx, *record, claims)
+	if err != nil {
+		return "", err
+	}
+
+	return s.accountSvc.ProvisionAccount(ctx, userId)
+}
+
+// resolveUser finds the user linked to the identity. On the first sign in the identity
+// is linked to the member of the organization owning its verified email, or to a new
+// user when the connection provisions them
+func (s svc) resolveUser(ctx context.Context, conn connection.Entity, claims *oidc.IDClaims) (string, error) {
+	linked, err := s.identityRepo.FindByIssuerAndSubject(ctx, claims.Issuer, claims.Subject)
+	if err != nil {
+		return "", err
+	}
+	if linked != nil {
+		current := identity.NewFromDatabase(*linked)
+		current.SignedIn(claims.Email)
+		if err := s.identityRepo.Update(ctx, current.Store()); err != nil {
+			s.log.Errorw(ctx, "error on update linked identity", logger.Err(err))
+		}
+		return current.UserID(), nil
+	}
+
+	userId, err := s.findMember(ctx, conn.OrgID, claims)
+	if err != nil {
+		return "", err
+	}
+
+	if userId == "" {
+		if !conn.AutoProvision {
+			return "", errNotProvisioned
+		}
+		userId, err = s.provisionUser(ctx, conn, claims)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	newIdentity := identity.New(userId, conn.ID, claims.Issuer, claims.Subject, claims.Email)
+	if err := s.identityRepo.Insert(ctx, newIdentity.Store()); err != nil {
+		return "", err
+	}
+
+	s.log.Infow(
+		ctx,
+		"identity linked",
+		logger.String("user_id", userId),
+		logger.String("issuer", claims.Issuer),
+	)
+
+	return userId, nil
+}
+
+// findMember returns the member of the organization owning the email of the identity
+// Unverified emails are never trusted, neither the ones reported by the provider, which
+// anyone could claim there, nor the ones users added here without confirming them
+func (s svc) findMember(ctx context.Context, orgId string, claims *oidc.IDClaims) (string, error) {
+	if claims.Email == "" || !claims.EmailVerified {
+		return "", nil
+	}
+
+	// Providers don't keep the case the address was typed with here
+	found, err := s.emailRepo.FindAllVerifiedByEmail(ctx, claims.Email)
+	if err != nil {
+		return "", err
+	}
+	if len(found) == 0 {
+		return "", nil
+	}
+
+	userId := found[0].UserID
+	for _, v := range found {
+		if !v.IsVerified {
+			return "", errors.New("email is not verified")
+		}
+		if v.UserID != userId {
+			return "", errors.New("email is verified by more than one user")
+		}
+	}
+
+	memberOf, err := s.accountRepo.FindOrgByUserID(ctx, userId)
+	if err != nil {
+		return "", err
+	}
+	if memberOf == nil || memberOf.ID != orgId {
+		return "", errors.New("email belongs to a user outside the organization")
+	}
+
+	return userId, nil
+}
+
+// provisionUser creates the user of the identity, adding it to the default team of the connection
+func (s svc) provisionUser(ctx context.Context, conn connection.Entity, claims *oidc.IDClaims) (string, error) {
+	if claims.Email == "" || !claims.EmailVerified {
+		return "", errors.New("provider did not report a verified email")
+	}
+
+	username := claims.PreferredUsername
+	if username == "" {
+		username, _, _ = strings.Cut(claims.Email, "@")
+	}
+	fullName := claims.Name
+	if len(fullName) < 3 {
+		fullName = username
+	}
+
+	input := user.UserRegisterDTO{
+		FullName:     fullName,
+		Username:     username,
+		EmailAddress: claims.Email,
+		PhoneNumber:  claims.PhoneNumber,
+	}
+
+	userId, err := s.userSvc.Create(ctx, input)
+	var appErr ApplicationError
+	if errors.As(err, &appErr) && appErr.Code == ResourceAlreadyTaken && hasField(appErr, "username") {
+		// Usernames are picked by the provider users, the subject makes this one unique
+		sum := sha256.Sum256([]byte(claims.Issuer + claims.Subject))
+		input.Username = username + "-" + hex.EncodeToString(sum[:])[:6]
+		userId, err = s.userSvc.Create(ctx, input)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	s.log.Infow(ctx, "user provisioned", logger.String("user_id", userId), logger.String("org_id", conn.OrgID))
+
+	if conn.DefaultTeamID != nil && conn.DefaultRoleID != nil {
+		err = s.teamSvc.AddMember(ctx, team.AddMemberParams{
+			TeamID: *conn.DefaultTeamID,
+			UserID: userId,
+			RoleID: *conn.DefaultRoleID,
+			OrgID:  conn.OrgID,
+		})
+		if err != nil {
+			// The identity is linked anyway, an admin can still add the user to a team
+			s.log.Errorw(ctx, "error on add provisioned user to team", logger.Err(err))
+		}
+	}
+
+	return userId, nil
+}
+
+func (s svc) ExchangeTicket(ctx context.Context, input ExchangeTicketDTO) (*dto.AccountResponse, *dto.MFAChallenge, error) {
+	errInvalidTicket := NewForbiddenError("sign in ticket is invalid or expired", ExpiredLink, nil)
+
+	record, err := s.stateRepo.ConsumeTicket(ctx, crypto.HashToken(input.Ticket))
+	if err != nil {
+		return nil, nil, NewBadRequestError("error on consume sign in ticket", err)
+	}
+	if record == nil || record.AccountID == nil {
+		return nil, nil, errInvalidTicket
+	}
+	if loginstate.NewFromDatabase(*record).IsExpired() {
+		return nil, nil, errInvalidTicket
+	}
+
+	conn, err := s.connRepo.FindByID(ctx, record.ConnectionID)
+	if err != nil {
+		return nil, nil, NewBadRequestError("error on find sso connection", err)
+	}
+	if conn == nil || !conn.IsEnabled {
+		return nil, nil, errInvalidTicket
+	}
+
+	return s.accountSvc.IssueSession(ctx, *record.AccountID, input.UserAgent, input.IP, conn.RequireMFA)
+}
+
+func (s svc) callbackPage(params url.Values) string {
+	return s.frontEndURL + "/sso/callback?" + params.Encode()
+}
+
+func hasField(err ApplicationError, field string) bool {
+	for _, f := range err.Fields {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,432 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bernardinorafael/internal/_shared/dto"
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/infra/oidc"
+	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/internal/modules/account"
+	"github.com/bernardinorafael/internal/modules/email"
+	"github.com/bernardinorafael/internal/modules/org"
+	"github.com/bernardinorafael/internal/modules/sso/connection"
+	"github.com/bernardinorafael/internal/modules/sso/identity"
+	"github.com/bernardinorafael/internal/modules/sso/loginstate"
+	"github.com/bernardinorafael/internal/modules/user"
+	"github.com/bernardinorafael/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testOrgID    = "org_test"
+	testClientID = "client_test"
+	frontEndURL  = "https://app.example.com"
+)
+
+// testIssuer is a stand-in OpenID Connect provider, issuing ID tokens for the identity set on it
+type testIssuer struct {
+	srv *httptest.Server
+	key *rsa.PrivateKey
+
+	subject       string
+	email         string
+	emailVerified bool
+	// nonce is the one of the login state the next token is issued for
+	nonce string
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	iss := &testIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 iss.srv.URL,
+			"authorization_endpoint": iss.srv.URL + "/authorize",
+			"token_endpoint":         iss.srv.URL + "/token",
+			"jwks_uri":               iss.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if clientId, _, ok := r.BasicAuth(); !ok || clientId != testClientID {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            iss.srv.URL,
+			"aud":            testClientID,
+			"sub":            iss.subject,
+			"nonce":          iss.nonce,
+			"email":          iss.email,
+			"email_verified": iss.emailVerified,
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		})
+		idToken.Header["kid"] = "test"
+		signed, err := idToken.SignedString(key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, map[string]any{
+			"access_token": "provider-access-token",
+			"token_type":   "Bearer",
+			"id_token":     signed,
+			"expires_in":   60,
+		})
+	})
+
+	iss.srv = httptest.NewServer(mux)
+	t.Cleanup(iss.srv.Close)
+
+	return iss
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type fakeConnRepo struct {
+	connection.RepositoryInterface
+	conns map[string]connection.Entity
+}
+
+func (r *fakeConnRepo) FindByID(_ context.Context, connectionId string) (*connection.Entity, error) {
+	conn, ok := r.conns[connectionId]
+	if !ok {
+		return nil, nil
+	}
+	return &conn, nil
+}
+
+type fakeIdentityRepo struct {
+	identity.RepositoryInterface
+	linked []identity.Entity
+}
+
+func (r *fakeIdentityRepo) FindByIssuerAndSubject(_ context.Context, issuer, subject string) (*identity.Entity, error) {
+	return nil, nil
+}
+
+func (r *fakeIdentityRepo) Insert(_ context.Context, entity identity.Entity) error {
+	r.linked = append(r.linked, entity)
+	return nil
+}
+
+type fakeStateRepo struct {
+	loginstate.RepositoryInterface
+	states map[string]loginstate.Entity
+}
+
+func (r *fakeStateRepo) FindByStateHash(_ context.Context, stateHash string) (*loginstate.Entity, error) {
+	state, ok := r.states[stateHash]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (r *fakeStateRepo) Complete(_ context.Context, entity loginstate.Entity) error {
+	r.states[entity.StateHash] = entity
+	return nil
+}
+
+func (r *fakeStateRepo) ConsumeTicket(_ context.Context, ticketHash string) (*loginstate.Entity, error) {
+	for hash, state := range r.states {
+		if state.TicketHash != nil && *state.TicketHash == ticketHash {
+			delete(r.states, hash)
+			return &state, nil
+		}
+	}
+	return nil, nil
+}
+
+// fakeEmailRepo matches addresses like the database does, regardless of case and only verified ones
+type fakeEmailRepo struct {
+	email.RepositoryInterface
+	emails []email.Entity
+}
+
+func (r *fakeEmailRepo) FindAllVerifiedByEmail(_ context.Context, address string) ([]email.Entity, error) {
+	var found []email.Entity
+	for _, v := range r.emails {
+		if strings.EqualFold(v.Email, address) && v.IsVerified {
+			found = append(found, v)
+		}
+	}
+	return found, nil
+}
+
+type fakeAccountRepo struct {
+	account.RepositoryInterface
+	orgs map[string]string
+}
+
+func (r *fakeAccountRepo) FindOrgByUserID(_ context.Context, userId string) (*org.EntityWithSettings, error) {
+	orgId, ok := r.orgs[userId]
+	if !ok {
+		return nil, nil
+	}
+	return &org.EntityWithSettings{Entity: org.Entity{ID: orgId}}, nil
+}
+
+type fakeOrgRepo struct {
+	org.RepositoryInterface
+	ownerId string
+}
+
+func (r *fakeOrgRepo) FindByID(_ context.Context, id string) (*org.EntityWithOwner, error) {
+	return &org.EntityWithOwner{Owner: user.Entity{ID: r.ownerId}}, nil
+}
+
+type fakeAccountSvc struct {
+	account.ServiceInterface
+	// requiredMFA records what the last session was issued with
+	requiredMFA *bool
+}
+
+func (s *fakeAccountSvc) ProvisionAccount(_ context.Context, userId string) (string, error) {
+	return "acc_" + userId, nil
+}
+
+func (s *fakeAccountSvc) IssueSession(
+	_ context.Context,
+	accountId, userAgent, ip string,
+	requireMFA bool,
+) (*dto.AccountResponse, *dto.MFAChallenge, error) {
+	s.requiredMFA = &requireMFA
+	if requireMFA {
+		return nil, &dto.MFAChallenge{MFARequired: true}, nil
+	}
+	return &dto.AccountResponse{AccessToken: "access-token"}, nil, nil
+}
+
+type testEnv struct {
+	svc        *svc
+	issuer     *testIssuer
+	conn       connection.Entity
+	states     *fakeStateRepo
+	identities *fakeIdentityRepo
+	emails     *fakeEmailRepo
+	accountSvc *fakeAccountSvc
+}
+
+func newTestEnv(t *testing.T, requireMFA bool) *testEnv {
+	t.Helper()
+
+	iss := newTestIssuer(t)
+
+	conn, err := connection.New(testOrgID, "Test", iss.srv.URL, testClientID, "secret", nil, false, requireMFA, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create connection: %v", err)
+	}
+
+	env := &testEnv{
+		issuer:     iss,
+		conn:       conn.Store(),
+		states:     &fakeStateRepo{states: make(map[string]loginstate.Entity)},
+		identities: &fakeIdentityRepo{},
+		emails:     &fakeEmailRepo{},
+		accountSvc: &fakeAccountSvc{},
+	}
+	env.svc = &svc{
+		log:          logger.New(logger.LogParams{}),
+		connRepo:     &fakeConnRepo{conns: map[string]connection.Entity{conn.ID(): env.conn}},
+		identityRepo: env.identities,
+		stateRepo:    env.states,
+		orgSvc:       org.NewService(logger.New(logger.LogParams{}), &fakeOrgRepo{ownerId: "usr_owner"}),
+		accountRepo:  &fakeAccountRepo{orgs: map[string]string{"usr_member": testOrgID, "usr_outsider": "org_other"}},
+		emailRepo:    env.emails,
+		accountSvc:   env.accountSvc,
+		// The stand-in issuer is served over http on the loopback
+		oidc:        oidc.NewClient(iss.srv.Client(), true),
+		callbackURL: "https://api.example.com" + CallbackPath,
+		frontEndURL: frontEndURL,
+	}
+
+	return env
+}
+
+// signIn starts a sign in with the connection and calls back as the provider would,
+// returning the parameters the front end page is opened with
+func (e *testEnv) signIn(t *testing.T) url.Values {
+	t.Helper()
+
+	state, plain, err := loginstate.New(e.conn.ID)
+	if err != nil {
+		t.Fatalf("failed to create login state: %v", err)
+	}
+	e.states.states[state.StateHash()] = state.Store()
+	e.issuer.nonce = state.Nonce()
+
+	redirectTo := e.svc.Callback(context.Background(), plain, "code", "")
+	if !strings.HasPrefix(redirectTo, frontEndURL+"/sso/callback?") {
+		t.Fatalf("unexpected redirect %q", redirectTo)
+	}
+
+	parsed, err := url.Parse(redirectTo)
+	if err != nil {
+		t.Fatalf("failed to parse redirect: %v", err)
+	}
+	return parsed.Query()
+}
+
+func TestCallbackLinksMemberByVerifiedEmailRegardlessOfCase(t *testing.T) {
+	env := newTestEnv(t, true)
+	env.emails.emails = []email.Entity{{ID: "eml_1", UserID: "usr_member", Email: "ana@example.com", IsVerified: true}}
+	env.issuer.subject = "provider-subject"
+	env.issuer.email = "Ana@Example.COM"
+	env.issuer.emailVerified = true
+
+	params := env.signIn(t)
+
+	if params.Get("ticket") == "" {
+		t.Fatalf("expected a ticket, got error %q", params.Get("error"))
+	}
+	if len(env.identities.linked) != 1 || env.identities.linked[0].UserID != "usr_member" {
+		t.Fatalf("expected the identity to be linked to usr_member, got %+v", env.identities.linked)
+	}
+}
+
+func TestCallbackDoesNotLinkUnverifiedEmails(t *testing.T) {
+	tests := []struct {
+		name          string
+		stored        email.Entity
+		emailVerified bool
+	}{
+		{
+			name:          "email unverified here",
+			stored:        email.Entity{ID: "eml_1", UserID: "usr_member", Email: "ana@example.com", IsVerified: false},
+			emailVerified: true,
+		},
+		{
+			name:          "email unverified at the provider",
+			stored:        email.Entity{ID: "eml_1", UserID: "usr_member", Email: "ana@example.com", IsVerified: true},
+			emailVerified: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, true)
+			env.emails.emails = []email.Entity{tt.stored}
+			env.issuer.subject = "provider-subject"
+			env.issuer.email = "ana@example.com"
+			env.issuer.emailVerified = tt.emailVerified
+
+			params := env.signIn(t)
+
+			// The connection doesn't provision users, so an identity without a member is refused
+			if got := params.Get("error"); got != errorNotProvisioned {
+				t.Fatalf("expected error %q, got %q", errorNotProvisioned, got)
+			}
+			if len(env.identities.linked) != 0 {
+				t.Fatalf("expected no identity linked, got %+v", env.identities.linked)
+			}
+		})
+	}
+}
+
+func TestCallbackRefusesMembersOfOtherOrganizations(t *testing.T) {
+	env := newTestEnv(t, true)
+	env.emails.emails = []email.Entity{{ID: "eml_1", UserID: "usr_outsider", Email: "bob@example.com", IsVerified: true}}
+	env.issuer.subject = "provider-subject"
+	env.issuer.email = "bob@example.com"
+	env.issuer.emailVerified = true
+
+	params := env.signIn(t)
+
+	if got := params.Get("error"); got != errorAccountUnavailable {
+		t.Fatalf("expected error %q, got %q", errorAccountUnavailable, got)
+	}
+}
+
+func TestExchangeTicketFollowsConnectionMFA(t *testing.T) {
+	for _, requireMFA := range []bool{true, false} {
+		env := newTestEnv(t, requireMFA)
+		env.emails.emails = []email.Entity{{ID: "eml_1", UserID: "usr_member", Email: "ana@example.com", IsVerified: true}}
+		env.issuer.subject = "provider-subject"
+		env.issuer.email = "ana@example.com"
+		env.issuer.emailVerified = true
+
+		params := env.signIn(t)
+
+		res, challenge, err := env.svc.ExchangeTicket(context.Background(), ExchangeTicketDTO{Ticket: params.Get("ticket")})
+		if err != nil {
+			t.Fatalf("require mfa %t: unexpected error: %v", requireMFA, err)
+		}
+		if env.accountSvc.requiredMFA == nil || *env.accountSvc.requiredMFA != requireMFA {
+			t.Fatalf("require mfa %t: session was not issued with the connection setting", requireMFA)
+		}
+		if requireMFA && (challenge == nil || res != nil) {
+			t.Fatalf("require mfa %t: expected a challenge instead of a session", requireMFA)
+		}
+		if !requireMFA && (res == nil || challenge != nil) {
+			t.Fatalf("require mfa %t: expected a session", requireMFA)
+		}
+	}
+}
+
+func TestCreateConnectionRefusesPrivateIssuers(t *testing.T) {
+	env := newTestEnv(t, true)
+	// The client a server runs with, reaching public https issuers only
+	env.svc.oidc = oidc.NewClient(nil, false)
+
+	ctx := context.WithValue(context.Background(), middleware.AuthKey{}, &token.AccountClaims{UserID: "usr_owner"})
+
+	issuers := []string{
+		env.issuer.srv.URL,
+		"https://127.0.0.1",
+		"https://localhost",
+		"https://169.254.169.254",
+		"https://10.0.0.1",
+		"ftp://example.com",
+	}
+	for _, issuer := range issuers {
+		_, err := env.svc.CreateConnection(ctx, testOrgID, CreateConnectionDTO{
+			Name:         "Test",
+			Issuer:       issuer,
+			ClientID:     testClientID,
+			ClientSecret: "secret",
+		})
+
+		var appErr ApplicationError
+		if !errors.As(err, &appErr) || !errors.Is(appErr.Err, oidc.ErrIssuerNotAllowed) {
+			t.Fatalf("issuer %s: expected the issuer to be refused, got %v", issuer, err)
+		}
+	}
+}
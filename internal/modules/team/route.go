@@ -9,45 +9,53 @@ import (
 	"github.com/bernardinorafael/internal/_shared/util"
 
 	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/internal/modules/permission"
 	"github.com/bernardinorafael/pkg/logger"
 	"github.com/go-chi/chi"
 )
 
 type controller struct {
-	ctx       context.Context
-	log       logger.Logger
-	svc       ServiceInterface
-	secretKey string
+	ctx  context.Context
+	log  logger.Logger
+	svc  ServiceInterface
+	auth *middleware.Auth
 }
 
 func NewController(
 	ctx context.Context,
 	log logger.Logger,
 	svc ServiceInterface,
-	secretKey string,
+	auth *middleware.Auth,
 ) *controller {
 	return &controller{
-		ctx:       ctx,
-		log:       log,
-		svc:       svc,
-		secretKey: secretKey,
+		ctx:  ctx,
+		log:  log,
+		svc:  svc,
+		auth: auth,
 	}
 }
 
 func (c controller) RegisterRoute(r *chi.Mux) {
-	m := middleware.NewWithAuth(c.log, c.secretKey)
+	m := c.auth
 
 	r.Route("/api/v1/teams", func(r chi.Router) {
 		r.Use(m.WithAuth)
 
-		r.Post("/", c.create)
-		r.Get("/organization/{orgId}/owner/{ownerId}", c.getAll)
-		r.Get("/{teamId}/organization/{orgId}", c.getByID)
-		r.Get("/{slug}/organization/{orgId}", c.getBySlug)
-		r.Post("/{teamId}/members", c.addMember)
-		r.Get("/member/{userId}/organization/{orgId}", c.getByMember)
-		r.Get("/{slug}/members/organization/{orgId}", c.getMembersByTeamID)
-		r.Delete("/{teamId}/members/{userId}/organization/{orgId}", c.deleteMember)
+		// Both read the organization from the body
+		r.With(m.WithPermission(permission.KeyTeamsWrite)).Post("/", c.create)
+		r.With(m.WithPermission(permission.KeyTeamsWrite)).Post("/{teamId}/members", c.addMember)
+
+		r.Group(func(r chi.Router) {
+			r.Use(m.WithOrgScope("orgId"))
+
+			r.With(m.WithPermission(permission.KeyTeamsRead)).Get("/organization/{orgId}/owner/{ownerId}", c.getAll)
+			r.With(m.WithPermission(permission.KeyTeamsRead)).Get("/{teamId}/organization/{orgId}", c.getByID)
+			r.With(m.WithPermission(permission.KeyTeamsRead)).Get("/{slug}/organization/{orgId}", c.getBySlug)
+			r.With(m.WithPermission(permission.KeyTeamsRead)).Get("/member/{userId}/organization/{orgId}", c.getByMember)
+			r.With(m.WithPermission(permission.KeyTeamsRead)).Get("/{slug}/members/organization/{orgId}", c.getMembersByTeamID)
+			r.With(m.WithPermission(permission.KeyTeamsWrite)).Delete("/{teamId}/members/{userId}/organization/{orgId}", c.deleteMember)
+		})
 	})
 }
 
@@ -108,6 +116,7 @@ func (c controller) getByMember(w http.ResponseWriter, r *http.Request) {
 }
 
 func (c controller) addMember(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.AuthKey{}).(*token.AccountClaims)
 	var body AddMemberParams
 	body.TeamID = chi.URLParam(r, "teamId")
 
@@ -115,6 +124,10 @@ func (c controller) addMember(w http.ResponseWriter, r *http.Request) {
 		NewHttpError(w, err)
 		return
 	}
+	if !claims.ActsOn(body.OrgID) {
+		NewHttpError(w, NewForbiddenError("service accounts can't act on other organizations", MissingPermission, nil))
+		return
+	}
 
 	if err := c.svc.AddMember(c.ctx, body); err != nil {
 		NewHttpError(w, err)
@@ -174,12 +187,17 @@ func (c controller) getAll(w http.ResponseWriter, r *http.Request) {
 }
 
 func (c controller) create(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.AuthKey{}).(*token.AccountClaims)
 	var body CreateTeamDTO
 
 	if err := util.ReadRequestBody(w, r, &body); err != nil {
 		NewHttpError(w, err)
 		return
 	}
+	if !claims.ActsOn(body.OrgID) {
+		NewHttpError(w, NewForbiddenError("service accounts can't act on other organizations", MissingPermission, nil))
+		return
+	}
 
 	if err := c.svc.Create(c.ctx, body); err != nil {
 		NewHttpError(w, err)
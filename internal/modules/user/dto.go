@@ -1,5 +1,7 @@
 package user
 
+import "time"
+
 type UserRegisterDTO struct {
 	FullName     string `json:"full_name"`
 	Username     string `json:"username"`
@@ -12,3 +14,9 @@ type UpdateProfileDTO struct {
 	Username             string `json:"username" db:"username"`
 	IgnorePasswordPolicy bool   `json:"ignore_password_policy" db:"ignore_password_policy"`
 }
+
+type BanDTO struct {
+	Reason string `json:"reason"`
+	// Until makes the ban temporary, it is lifted on its own once passed
+	Until *time.Time `json:"until"`
+}
@@ -22,20 +22,31 @@ var (
 	// Username validation errors
 	ErrEmptyUsername  = errors.New("username is a required field")
 	ErrUsernameLocked = errors.New("username is locked for update")
+	// Ban errors
+	ErrEmptyBanReason = errors.New("ban reason is a required field")
+	ErrBanUntilInPast = errors.New("ban expiry must be in the future")
+	ErrAlreadyBanned  = errors.New("user is already banned")
+	ErrNotBanned      = errors.New("user is not banned")
 	// ID validation errors
 	ErrInvalidID = errors.New("invalid ksuid format")
 )
 
 type User struct {
-	id           string
-	fullName     string
-	username     string
-	phoneNumber  string
-	emailAddress string
-	avatarURL    *string
+	id            string
+	fullName      string
+	username      string
+	phoneNumber   string
+	phoneVerified bool
+	emailAddress  string
+	avatarURL     *string
 
 	banned               bool
+	banReason            *string
+	bannedUntil          *time.Time
+	bannedBy             *string
+	bannedAt             *time.Time
 	locked               bool
+	lockedUntil          *time.Time
 	ignorePasswordPolicy bool
 
 	usernameLastUpdated time.Time
@@ -47,15 +58,21 @@ type User struct {
 // NewFromEntity creates a new user entity from an existing one
 func NewFromEntity(u Entity) (*User, error) {
 	user := User{
-		id:           u.ID,
-		fullName:     u.FullName,
-		username:     u.Username,
-		phoneNumber:  u.PhoneNumber,
-		emailAddress: u.EmailAddress,
-		avatarURL:    u.AvatarURL,
+		id:            u.ID,
+		fullName:      u.FullName,
+		username:      u.Username,
+		phoneNumber:   u.PhoneNumber,
+		phoneVerified: u.PhoneVerified,
+		emailAddress:  u.EmailAddress,
+		avatarURL:     u.AvatarURL,
 
 		banned:               u.Banned,
+		banReason:            u.BanReason,
+		bannedUntil:          u.BannedUntil,
+		bannedBy:             u.BannedBy,
+		bannedAt:             u.BannedAt,
 		locked:               u.Locked,
+		lockedUntil:          u.LockedUntil,
 		ignorePasswordPolicy: u.IgnorePasswordPolicy,
 
 		usernameLastUpdated: u.UsernameLastUpdated,
@@ -84,6 +101,7 @@ func NewUser(name, username, phone, email string) (*User, error) {
 
 		banned:               false,
 		locked:               false,
+		lockedUntil:          nil,
 		ignorePasswordPolicy: false,
 
 		usernameLastUpdated: time.Now(),
@@ -122,28 +140,94 @@ func (u *User) ToggleLock() error {
 	return u.Lock()
 }
 
+// Lock locks the user until it is unlocked by an admin
 func (u *User) Lock() error {
 	if u.locked {
 		return fmt.Errorf("user is already locked")
 	}
 
 	u.locked = true
+	u.lockedUntil = nil
 	u.updated = time.Now()
 
 	return nil
 }
 
+// LockUntil locks the user temporarily, it is lifted automatically once until passes
+func (u *User) LockUntil(until time.Time) {
+	u.locked = true
+	u.lockedUntil = &until
+	u.updated = time.Now()
+}
+
 func (u *User) Unlock() error {
 	if !u.locked {
 		return fmt.Errorf("user is already unlocked")
 	}
 
 	u.locked = false
+	u.lockedUntil = nil
 	u.updated = time.Now()
 
 	return nil
 }
 
+// IsLocked reports whether the user is locked, ignoring temporary locks already expired
+func (u *User) IsLocked() bool {
+	if !u.locked {
+		return false
+	}
+	return u.lockedUntil == nil || u.lockedUntil.After(time.Now())
+}
+
+// Ban bans the user on behalf of bannedBy, until nil bans until the user is unbanned
+// A ban already expired can be replaced by a new one
+func (u *User) Ban(reason, bannedBy string, until *time.Time) error {
+	if u.IsBanned() {
+		return ErrAlreadyBanned
+	}
+	if reason == "" {
+		return ErrEmptyBanReason
+	}
+	if until != nil && !until.After(time.Now()) {
+		return ErrBanUntilInPast
+	}
+
+	now := time.Now()
+	u.banned = true
+	u.banReason = &reason
+	u.bannedUntil = until
+	u.bannedBy = &bannedBy
+	u.bannedAt = &now
+	u.updated = now
+
+	return nil
+}
+
+// Unban lifts the ban, clearing its metadata
+func (u *User) Unban() error {
+	if !u.banned {
+		return ErrNotBanned
+	}
+
+	u.banned = false
+	u.banReason = nil
+	u.bannedUntil = nil
+	u.bannedBy = nil
+	u.bannedAt = nil
+	u.updated = time.Now()
+
+	return nil
+}
+
+// IsBanned reports whether the user is banned, ignoring temporary bans already expired
+func (u *User) IsBanned() bool {
+	if !u.banned {
+		return false
+	}
+	return u.bannedUntil == nil || u.bannedUntil.After(time.Now())
+}
+
 func (u *User) ChangeEmail(email string) error {
 	if err := u.validate(); err != nil {
 		return err
@@ -160,6 +244,10 @@ func (u *User) ChangePhone(phone string) error {
 		return err
 	}
 
+	// A new number is unverified until a code sent to it is confirmed
+	if phone != u.phoneNumber {
+		u.phoneVerified = false
+	}
 	u.phoneNumber = phone
 	u.updated = time.Now()
 	return nil
@@ -210,15 +298,21 @@ func (u *User) ChangePasswordPolicy(ignore bool) {
 // Store stores the user entity in the database
 func (u *User) Store() Entity {
 	return Entity{
-		ID:           u.ID(),
-		FullName:     u.FullName(),
-		Username:     u.Username(),
-		AvatarURL:    u.AvatarURL(),
-		PhoneNumber:  u.Phone(),
-		EmailAddress: u.Email(),
+		ID:            u.ID(),
+		FullName:      u.FullName(),
+		Username:      u.Username(),
+		AvatarURL:     u.AvatarURL(),
+		PhoneNumber:   u.Phone(),
+		PhoneVerified: u.PhoneVerified(),
+		EmailAddress:  u.Email(),
 
 		Banned:               u.Banned(),
+		BanReason:            u.BanReason(),
+		BannedUntil:          u.BannedUntil(),
+		BannedBy:             u.BannedBy(),
+		BannedAt:             u.BannedAt(),
 		Locked:               u.Locked(),
+		LockedUntil:          u.LockedUntil(),
 		IgnorePasswordPolicy: u.IgnorePasswordPolicy(),
 
 		UsernameLastUpdated: u.UsernameLastUpdated(),
@@ -236,8 +330,14 @@ func (u *User) AvatarURL() *string             { return u.avatarURL }
 func (u *User) UsernameLastUpdated() time.Time { return u.usernameLastUpdated }
 func (u *User) UsernameLockoutEnd() time.Time  { return u.usernameLockoutEnd }
 func (u *User) Phone() string                  { return u.phoneNumber }
+func (u *User) PhoneVerified() bool            { return u.phoneVerified }
 func (u *User) Banned() bool                   { return u.banned }
+func (u *User) BanReason() *string             { return u.banReason }
+func (u *User) BannedUntil() *time.Time        { return u.bannedUntil }
+func (u *User) BannedBy() *string              { return u.bannedBy }
+func (u *User) BannedAt() *time.Time           { return u.bannedAt }
 func (u *User) Locked() bool                   { return u.locked }
+func (u *User) LockedUntil() *time.Time        { return u.lockedUntil }
 func (u *User) IgnorePasswordPolicy() bool     { return u.ignorePasswordPolicy }
 func (u *User) Created() time.Time             { return u.created }
 func (u *User) Updated() time.Time             { return u.updated }
@@ -10,11 +10,15 @@ import (
 )
 
 type ServiceInterface interface {
+	// Create adds a user on behalf of an admin or an identity provider, who vouch for the email
 	Create(ctx context.Context, dto UserRegisterDTO) (userId string, err error)
 	FindByID(ctx context.Context, userId string) (*CompleteEntity, error)
 	Delete(ctx context.Context, userId string) error
 	GetAll(ctx context.Context, dto dto.SearchParams) (*pagination.Paginated[EntityWithTeam], error)
 	ToggleLock(ctx context.Context, userId string) error
+	// Ban bans the user on behalf of the signed in user and revokes all their sessions
+	Ban(ctx context.Context, userId string, dto BanDTO) error
+	Unban(ctx context.Context, userId string) error
 	UpdateProfile(ctx context.Context, userId string, dto UpdateProfileDTO) error
 	// Emails methods
 	FindAllEmails(ctx context.Context, userId string) ([]email.Entity, error)
@@ -29,6 +33,7 @@ type RepositoryInterface interface {
 	FindByID(ctx context.Context, userId string) (*Entity, error)
 	FindCompleteByID(ctx context.Context, userId string) (*CompleteEntity, error)
 	GetAll(ctx context.Context, params dto.SearchParams) ([]EntityWithTeam, int, error)
-	Create(ctx context.Context, user Entity) error
+	// Create inserts the user with its primary email, verified only when the caller vouches for it
+	Create(ctx context.Context, user Entity, emailVerified bool) error
 	Update(ctx context.Context, user Entity) error
 }
@@ -11,7 +11,7 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-func (r repo) Create(ctx context.Context, usr user.Entity) error {
+func (r repo) Create(ctx context.Context, usr user.Entity, emailVerified bool) error {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
@@ -54,15 +54,31 @@ func (r repo) Create(ctx context.Context, usr user.Entity) error {
 		emailId := util.GenID("email")
 		_, err = tx.ExecContext(
 			ctx,
-			"INSERT INTO emails (id, user_id, email, is_primary, is_verified) VALUES ($1, $2, $3, true, true)",
+			"INSERT INTO emails (id, user_id, email, is_primary, is_verified) VALUES ($1, $2, $3, true, $4)",
 			emailId,
 			usr.ID,
 			usr.EmailAddress,
+			emailVerified,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to create email: %w", err)
 		}
 
+		// Users provisioned by a provider may have no phone number
+		if usr.PhoneNumber != "" {
+			_, err = tx.ExecContext(
+				ctx,
+				"INSERT INTO phones (id, user_id, phone_number, is_primary, is_verified) VALUES ($1, $2, $3, true, $4)",
+				util.GenID("phone"),
+				usr.ID,
+				usr.PhoneNumber,
+				usr.PhoneVerified,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to create phone: %w", err)
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -21,10 +21,16 @@ func (r repo) Update(ctx context.Context, entity user.Entity) error {
 			username_last_updated = :username_last_updated,
 			username_lockout_end = :username_lockout_end,
 			locked = :locked,
+			locked_until = :locked_until,
 			banned = :banned,
+			ban_reason = :ban_reason,
+			banned_until = :banned_until,
+			banned_by = :banned_by,
+			banned_at = :banned_at,
 			avatar_url = :avatar_url,
 			ignore_password_policy = :ignore_password_policy,
 			phone_number = :phone_number,
+			phone_verified = :phone_verified,
 			email_address = :email_address
 		WHERE
 			id = :id
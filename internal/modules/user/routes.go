@@ -9,51 +9,55 @@ import (
 	. "github.com/bernardinorafael/internal/_shared/errors"
 	"github.com/bernardinorafael/internal/_shared/util"
 	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/modules/permission"
 	"github.com/bernardinorafael/pkg/logger"
 	"github.com/go-chi/chi"
 )
 
 type controller struct {
-	ctx       context.Context
-	log       logger.Logger
-	svc       ServiceInterface
-	secretKey string
+	ctx  context.Context
+	log  logger.Logger
+	svc  ServiceInterface
+	auth *middleware.Auth
 }
 
 func NewController(
 	ctx context.Context,
 	log logger.Logger,
 	svc ServiceInterface,
-	secretKey string,
+	auth *middleware.Auth,
 ) *controller {
 	return &controller{
-		ctx:       ctx,
-		log:       log,
-		svc:       svc,
-		secretKey: secretKey,
+		ctx:  ctx,
+		log:  log,
+		svc:  svc,
+		auth: auth,
 	}
 }
 
 func (c controller) RegisterRoute(r *chi.Mux) {
-	m := middleware.NewWithAuth(c.log, c.secretKey)
+	m := c.auth
 
 	r.Route("/api/v1/users", func(r chi.Router) {
-		r.Use(m.WithAuth)
+		// Users don't belong to a single organization, service accounts could reach anyone's
+		r.Use(m.WithAuth, m.DenyService)
 
 		// Users
-		r.Get("/", c.getAllUsers)
-		r.Post("/", c.create)
-		r.Get("/{userId}", c.getUser)
-		r.Delete("/{userId}", c.delete)
-		r.Patch("/{userId}/toggle-lock", c.toggleLock)
-		r.Patch("/{userId}/profile", c.updateProfile)
-		r.Patch("/{userId}/profile/avatar", c.uploadAvatar)
+		r.With(m.WithPermission(permission.KeyUsersRead)).Get("/", c.getAllUsers)
+		r.With(m.WithPermission(permission.KeyUsersWrite)).Post("/", c.create)
+		r.With(m.WithPermission(permission.KeyUsersRead)).Get("/{userId}", c.getUser)
+		r.With(m.WithPermission(permission.KeyUsersWrite), m.DenyImpersonation, m.RequireRecentAuth(middleware.RecentAuthMaxAge)).Delete("/{userId}", c.delete)
+		r.With(m.WithPermission(permission.KeyUsersModerate), m.DenyImpersonation).Patch("/{userId}/toggle-lock", c.toggleLock)
+		r.With(m.WithPermission(permission.KeyUsersModerate), m.DenyImpersonation).Patch("/{userId}/ban", c.ban)
+		r.With(m.WithPermission(permission.KeyUsersModerate), m.DenyImpersonation).Patch("/{userId}/unban", c.unban)
+		r.With(m.WithPermission(permission.KeyUsersWrite)).Patch("/{userId}/profile", c.updateProfile)
+		r.With(m.WithPermission(permission.KeyUsersWrite)).Patch("/{userId}/profile/avatar", c.uploadAvatar)
 
 		// Emails
 		// TODO: Move this to a dedicated emails router
-		r.Get("/{userId}/emails", c.getEmails)
-		r.Patch("/{userId}/emails/{emailId}/set-primary", c.setPrimaryEmail)
-		r.Get("/emails/{emailId}", c.getEmail)
+		r.With(m.WithPermission(permission.KeyUsersRead)).Get("/{userId}/emails", c.getEmails)
+		r.With(m.WithPermission(permission.KeyUsersWrite), m.DenyImpersonation, m.RequireRecentAuth(middleware.RecentAuthMaxAge)).Patch("/{userId}/emails/{emailId}/set-primary", c.setPrimaryEmail)
+		r.With(m.WithPermission(permission.KeyUsersRead)).Get("/emails/{emailId}", c.getEmail)
 	})
 }
 
@@ -134,7 +138,34 @@ func (c controller) getEmails(w http.ResponseWriter, r *http.Request) {
 }
 
 func (c controller) toggleLock(w http.ResponseWriter, r *http.Request) {
-	if err := c.svc.ToggleLock(c.ctx, chi.URLParam(r, "userId")); err != nil {
+	if err := c.svc.ToggleLock(r.Context(), chi.URLParam(r, "userId")); err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) ban(w http.ResponseWriter, r *http.Request) {
+	var body BanDTO
+
+	err := util.ReadRequestBody(w, r, &body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	err = c.svc.Ban(r.Context(), chi.URLParam(r, "userId"), body)
+	if err != nil {
+		NewHttpError(w, err)
+		return
+	}
+
+	util.WriteSuccessResponse(w, http.StatusOK)
+}
+
+func (c controller) unban(w http.ResponseWriter, r *http.Request) {
+	if err := c.svc.Unban(r.Context(), chi.URLParam(r, "userId")); err != nil {
 		NewHttpError(w, err)
 		return
 	}
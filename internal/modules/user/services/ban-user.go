@@ -0,0 +1,130 @@
+package usersvc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	. "github.com/bernardinorafael/internal/_shared/errors"
+	"github.com/bernardinorafael/internal/infra/http/middleware"
+	"github.com/bernardinorafael/internal/infra/token"
+	"github.com/bernardinorafael/internal/modules/permission"
+	"github.com/bernardinorafael/internal/modules/user"
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+func (s svc) Ban(ctx context.Context, userId string, input user.BanDTO) error {
+	claims, err := s.moderatorClaims(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if claims.UserID == userId {
+		return NewBadRequestError("can't ban yourself", nil)
+	}
+
+	foundUser, err := s.userRepo.FindCompleteByID(ctx, userId)
+	if err != nil {
+		return NewNotFoundError("failed to retrieve user", err)
+	}
+
+	u, err := user.NewFromEntity(foundUser.User)
+	if err != nil {
+		return NewValidationFieldError("error on init user entity", err, nil)
+	}
+
+	err = u.Ban(strings.TrimSpace(input.Reason), claims.UserID, input.Until)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrAlreadyBanned):
+			return NewConflictError("user is already banned", ResourceAlreadyTaken, err, nil)
+		case errors.Is(err, user.ErrEmptyBanReason):
+			return NewValidationFieldError("a reason is required to ban a user", err, nil)
+		case errors.Is(err, user.ErrBanUntilInPast):
+			return NewValidationFieldError("ban expiry must be in the future", err, nil)
+		}
+		return NewBadRequestError("error on ban user", err)
+	}
+
+	err = s.userRepo.Update(ctx, u.Store())
+	if err != nil {
+		return NewBadRequestError("error on ban user", err)
+	}
+
+	// Access tokens already issued are rejected by the session check on every request
+	err = s.sessionRepo.RevokeAll(ctx, u.Username())
+	if err != nil {
+		return NewBadRequestError("error on revoke user sessions", err)
+	}
+
+	s.log.Warnw(
+		ctx,
+		"user banned",
+		logger.String("user_id", u.ID()),
+		logger.String("banned_by", claims.UserID),
+	)
+
+	return nil
+}
+
+func (s svc) Unban(ctx context.Context, userId string) error {
+	claims, err := s.moderatorClaims(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	foundUser, err := s.userRepo.FindCompleteByID(ctx, userId)
+	if err != nil {
+		return NewNotFoundError("failed to retrieve user", err)
+	}
+
+	u, err := user.NewFromEntity(foundUser.User)
+	if err != nil {
+		return NewValidationFieldError("error on init user entity", err, nil)
+	}
+
+	err = u.Unban()
+	if err != nil {
+		return NewBadRequestError("error on unban user", err)
+	}
+
+	err = s.userRepo.Update(ctx, u.Store())
+	if err != nil {
+		return NewBadRequestError("error on unban user", err)
+	}
+
+	s.log.Infow(
+		ctx,
+		"user unbanned",
+		logger.String("user_id", u.ID()),
+		logger.String("unbanned_by", claims.UserID),
+	)
+
+	return nil
+}
+
+// moderatorClaims returns the claims of the signed in user when they may lock, ban or unban the target
+// Bans record who issued them, so only users acting as themselves may moderate
+func (s svc) moderatorClaims(ctx context.Context, targetUserId string) (*token.AccountClaims, error) {
+	claims, ok := ctx.Value(middleware.AuthKey{}).(*token.AccountClaims)
+	if !ok {
+		return nil, NewBadRequestError("userId not found in context", nil)
+	}
+	if claims.IsService() || claims.UserID == "" {
+		return nil, NewForbiddenError("moderating users requires a user", MissingPermission, nil)
+	}
+	if claims.IsImpersonated() {
+		return nil, NewForbiddenError("not allowed while impersonating the user", ImpersonationForbidden, nil)
+	}
+
+	// Grants limited to an organization only reach its users, the others are reported as
+	// a missing permission so they can't be told apart
+	granted, err := s.permRepo.HasPlatformPermissionOver(ctx, claims.UserID, permission.KeyPlatformModerateUsers, targetUserId)
+	if err != nil {
+		return nil, NewBadRequestError("error on find platform permissions by user id", err)
+	}
+	if !granted {
+		return nil, NewForbiddenError("missing permission "+permission.KeyPlatformModerateUsers, MissingPermission, nil)
+	}
+
+	return claims, nil
+}
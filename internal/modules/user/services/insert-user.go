@@ -20,7 +20,7 @@ func (s svc) Create(ctx context.Context, dto user.UserRegisterDTO) (userId strin
 		return "", NewValidationFieldError(msg, err, nil)
 	}
 
-	if err := s.userRepo.Create(ctx, newUser.Store()); err != nil {
+	if err := s.userRepo.Create(ctx, newUser.Store(), true); err != nil {
 		msg := "failed to create user"
 		var pqErr *pq.Error
 		// 23505 is the code for unique constraint violation
@@ -2,7 +2,10 @@ package usersvc
 
 import (
 	"github.com/bernardinorafael/internal/mailer"
+	"github.com/bernardinorafael/internal/modules/account/attempt"
+	"github.com/bernardinorafael/internal/modules/account/session"
 	"github.com/bernardinorafael/internal/modules/email"
+	"github.com/bernardinorafael/internal/modules/permission"
 	"github.com/bernardinorafael/internal/modules/user"
 	"github.com/bernardinorafael/internal/uploader"
 	"github.com/bernardinorafael/pkg/logger"
@@ -11,6 +14,9 @@ import (
 type svc struct {
 	log          logger.Logger
 	userRepo     user.RepositoryInterface
+	attemptRepo  attempt.RepositoryInterface
+	sessionRepo  session.RepositoryInterface
+	permRepo     permission.RepositoryInterface
 	emailService email.ServiceInterface
 	mailer       mailer.Mailer
 	uploader     uploader.UploaderInterface
@@ -19,6 +25,9 @@ type svc struct {
 func New(
 	log logger.Logger,
 	userRepo user.RepositoryInterface,
+	attemptRepo attempt.RepositoryInterface,
+	sessionRepo session.RepositoryInterface,
+	permRepo permission.RepositoryInterface,
 	emailService email.ServiceInterface,
 	mailer mailer.Mailer,
 	uploader uploader.UploaderInterface,
@@ -26,6 +35,9 @@ func New(
 	return &svc{
 		log:          log,
 		userRepo:     userRepo,
+		attemptRepo:  attemptRepo,
+		sessionRepo:  sessionRepo,
+		permRepo:     permRepo,
 		emailService: emailService,
 		mailer:       mailer,
 		uploader:     uploader,
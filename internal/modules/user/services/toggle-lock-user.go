@@ -5,9 +5,19 @@ import (
 
 	. "github.com/bernardinorafael/internal/_shared/errors"
 	"github.com/bernardinorafael/internal/modules/user"
+	"github.com/bernardinorafael/pkg/logger"
 )
 
 func (s svc) ToggleLock(ctx context.Context, userId string) error {
+	// A lock keeps the user from signing in, so it takes the same grant as a ban
+	claims, err := s.moderatorClaims(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if claims.UserID == userId {
+		return NewBadRequestError("can't lock yourself", nil)
+	}
+
 	foundUser, err := s.userRepo.FindCompleteByID(ctx, userId)
 	if err != nil {
 		return NewNotFoundError("failed to retrieve user", err)
@@ -28,5 +38,20 @@ func (s svc) ToggleLock(ctx context.Context, userId string) error {
 		return NewBadRequestError("error on toggle lock user status", err)
 	}
 
+	// An admin unlock gives the user a fresh start on failed login attempts
+	if !user.Locked() {
+		err = s.attemptRepo.DeleteByUsername(ctx, user.Username())
+		if err != nil {
+			return NewBadRequestError("error on reset login attempts", err)
+		}
+	}
+
+	s.log.Infow(
+		ctx,
+		"user lock toggled",
+		logger.String("user_id", user.ID()),
+		logger.String("locked_by", claims.UserID),
+	)
+
 	return nil
 }
@@ -17,16 +17,23 @@ type EntityWithTeam struct {
 }
 
 type Entity struct {
-	ID           string  `json:"id" db:"id"`
-	FullName     string  `json:"full_name" db:"full_name"`
-	Username     string  `json:"username" db:"username"`
-	PhoneNumber  string  `json:"phone_number" db:"phone_number"`
-	EmailAddress string  `json:"email_address" db:"email_address"`
-	AvatarURL    *string `json:"avatar_url" db:"avatar_url"`
-
-	Banned               bool `json:"banned" db:"banned"`
-	Locked               bool `json:"locked" db:"locked"`
-	IgnorePasswordPolicy bool `json:"ignore_password_policy" db:"ignore_password_policy"`
+	ID          string `json:"id" db:"id"`
+	FullName    string `json:"full_name" db:"full_name"`
+	Username    string `json:"username" db:"username"`
+	PhoneNumber string `json:"phone_number" db:"phone_number"`
+	// PhoneVerified is reset whenever the phone number changes
+	PhoneVerified bool    `json:"phone_verified" db:"phone_verified"`
+	EmailAddress  string  `json:"email_address" db:"email_address"`
+	AvatarURL     *string `json:"avatar_url" db:"avatar_url"`
+
+	Banned               bool       `json:"banned" db:"banned"`
+	BanReason            *string    `json:"ban_reason" db:"ban_reason"`
+	BannedUntil          *time.Time `json:"banned_until" db:"banned_until"`
+	BannedBy             *string    `json:"banned_by" db:"banned_by"`
+	BannedAt             *time.Time `json:"banned_at" db:"banned_at"`
+	Locked               bool       `json:"locked" db:"locked"`
+	LockedUntil          *time.Time `json:"locked_until" db:"locked_until"`
+	IgnorePasswordPolicy bool       `json:"ignore_password_policy" db:"ignore_password_policy"`
 
 	UsernameLastUpdated time.Time `json:"username_last_updated" db:"username_last_updated"`
 	UsernameLockoutEnd  time.Time `json:"username_lockout_end" db:"username_lockout_end"`
@@ -0,0 +1,39 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type fileSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSender returns a Sender appending the messages to a file instead of sending them
+func NewFileSender(path string) Sender {
+	return &fileSender{path: path}
+}
+
+func (s *fileSender) Send(ctx context.Context, to, body string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error on open sms file: %w", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), to, body)
+	if err != nil {
+		return fmt.Errorf("error on write sms file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *fileSender) Delivers() bool { return false }
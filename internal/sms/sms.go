@@ -0,0 +1,67 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bernardinorafael/pkg/logger"
+)
+
+// Drivers a Sender can be built with
+const (
+	DriverLog    = "log"
+	DriverFile   = "file"
+	DriverTwilio = "twilio"
+)
+
+// ErrDisabled is returned by the Sender built when no driver is configured
+var ErrDisabled = errors.New("sms is disabled")
+
+// Sender delivers text messages to phone numbers
+type Sender interface {
+	Send(ctx context.Context, to, body string) error
+	// Delivers reports whether the messages really reach the phone. Only then a text
+	// message proves the user holds the number, so sign-in and recovery codes may be sent
+	Delivers() bool
+}
+
+type Config struct {
+	// Driver is one of DriverLog, DriverFile or DriverTwilio, text messages are disabled when empty
+	Driver string
+	// FilePath is where DriverFile appends the messages
+	FilePath string
+
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	// TwilioFrom is the number the messages are sent from
+	TwilioFrom string
+}
+
+// New builds the Sender of the driver configured. DriverLog and DriverFile don't reach
+// a phone, they let messages be read during local development
+func New(log logger.Logger, config Config) (Sender, error) {
+	switch config.Driver {
+	case "":
+		return disabledSender{}, nil
+	case DriverLog:
+		return NewLogSender(log), nil
+	case DriverFile:
+		if config.FilePath == "" {
+			return nil, fmt.Errorf("file path is required for the %s driver", DriverFile)
+		}
+		return NewFileSender(config.FilePath), nil
+	case DriverTwilio:
+		if config.TwilioAccountSID == "" || config.TwilioAuthToken == "" || config.TwilioFrom == "" {
+			return nil, fmt.Errorf("account sid, auth token and from number are required for the %s driver", DriverTwilio)
+		}
+		return NewTwilioSender(config.TwilioAccountSID, config.TwilioAuthToken, config.TwilioFrom), nil
+	}
+
+	return nil, fmt.Errorf("unknown sms driver %q", config.Driver)
+}
+
+type disabledSender struct{}
+
+func (disabledSender) Send(ctx context.Context, to, body string) error { return ErrDisabled }
+func (disabledSender) Delivers() bool                                  { return false }
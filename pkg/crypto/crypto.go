@@ -1,23 +1,168 @@
 package crypto
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"fmt"
+	"strings"
+	"sync"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword encrypts a password using bcrypt
+const argon2idPrefix = "$argon2id$"
+
+// PasswordParams are the argon2id parameters new passwords are hashed with
+type PasswordParams struct {
+	// Memory is the amount of memory used, in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultPasswordParams follows the OWASP recommendation for argon2id
+var DefaultPasswordParams = PasswordParams{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+var params = DefaultPasswordParams
+
+// SetPasswordParams changes the parameters used by HashPassword, zero values keep the default
+// Hashes created with weaker parameters are reported by NeedsRehash
+func SetPasswordParams(p PasswordParams) {
+	if p.Memory == 0 {
+		p.Memory = DefaultPasswordParams.Memory
+	}
+	if p.Iterations == 0 {
+		p.Iterations = DefaultPasswordParams.Iterations
+	}
+	if p.Parallelism == 0 {
+		p.Parallelism = DefaultPasswordParams.Parallelism
+	}
+	if p.SaltLength == 0 {
+		p.SaltLength = DefaultPasswordParams.SaltLength
+	}
+	if p.KeyLength == 0 {
+		p.KeyLength = DefaultPasswordParams.KeyLength
+	}
+	params = p
+}
+
+// HashPassword encrypts a password using argon2id with the current parameters
+// The result is encoded as $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
 func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+	p := params
+
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
 		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
-	return string(hashedPassword), err
+
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	encoded := fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		p.Memory,
+		p.Iterations,
+		p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+
+	return encoded, nil
 }
 
 // PasswordMatches compares a plain text password with an encrypted password
+// The algorithm is identified by the hash prefix, so legacy bcrypt hashes are still verified
 // Returns true if the password matches, false otherwise
 func PasswordMatches(password, encrypted string) bool {
+	if strings.HasPrefix(encrypted, argon2idPrefix) {
+		p, salt, key, err := decodeArgon2id(encrypted)
+		if err != nil {
+			return false
+		}
+		other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+		return subtle.ConstantTimeCompare(key, other) == 1
+	}
+
 	err := bcrypt.CompareHashAndPassword([]byte(encrypted), []byte(password))
 	return err == nil
 }
+
+// dummyHash is checked when there is no password to check against, hashed on first use
+// so it follows the parameters set at startup
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword("not a password anyone has")
+	return hash
+})
+
+// DummyPasswordCheck takes as long as checking a password, for users that don't exist
+// to not be told apart from wrong passwords by the response time. It never matches
+func DummyPasswordCheck(password string) {
+	PasswordMatches(password, dummyHash())
+}
+
+// NeedsRehash reports whether an encrypted password was created with a legacy
+// algorithm or with parameters weaker than the current ones
+func NeedsRehash(encrypted string) bool {
+	if !strings.HasPrefix(encrypted, argon2idPrefix) {
+		return true
+	}
+
+	p, _, _, err := decodeArgon2id(encrypted)
+	if err != nil {
+		return true
+	}
+
+	return p.Memory < params.Memory ||
+		p.Iterations < params.Iterations ||
+		p.Parallelism < params.Parallelism ||
+		p.SaltLength < params.SaltLength ||
+		p.KeyLength < params.KeyLength
+}
+
+func decodeArgon2id(encoded string) (PasswordParams, []byte, []byte, error) {
+	var p PasswordParams
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, fmt.Errorf("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2id version: %w", err)
+	}
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism)
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
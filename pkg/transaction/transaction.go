@@ -7,7 +7,20 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+type txKey struct{}
+
+// WithTx returns a copy of ctx carrying tx, so that nested ExecTx calls
+// made with it join tx instead of opening a new transaction
+func WithTx(ctx context.Context, tx *sqlx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
 func ExecTx(ctx context.Context, db *sqlx.DB, fn func(*sqlx.Tx) error) error {
+	// Join the outer transaction, the caller is responsible for commit/rollback
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(tx)
+	}
+
 	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to start tx: %w", err)